@@ -0,0 +1,84 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+func TestImportArchiveRequests(t *testing.T) {
+	s := newTestServer(t, store.NewMemoryStore())
+
+	// Just over the 32MB limit, otherwise a valid archive
+	large := `{"version": 1, "campaign": {"title": "Harbour Town", "description": "` + strings.Repeat("a", 32<<20) + `"}}`
+	if rec := s.do(t, owner, "POST", "/api/campaigns/import", large); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("archive over the limit returned %d, want %d: %.200s", rec.Code, http.StatusRequestEntityTooLarge, rec.Body.String())
+	}
+
+	s.run(t, []step{
+		{name: "import malformed JSON", user: owner, method: "POST", path: "/api/campaigns/import", body: `{"version": 1, "campaign": {`, status: http.StatusBadRequest},
+		{name: "import unsupported version", user: owner, method: "POST", path: "/api/campaigns/import", body: `{"version": 99, "campaign": {"title": "Harbour Town"}}`, status: http.StatusBadRequest},
+		{name: "import relationship to a missing character", user: owner, method: "POST", path: "/api/campaigns/import", body: `{"version": 1, "campaign": {"title": "Harbour Town"}, "characters": [{"id": "c1", "name": "Aldo"}], "relationships": [{"id": "r1", "source_character_id": "c1", "target_character_id": "c2", "relation_type": "friend"}]}`, status: http.StatusBadRequest},
+		{name: "list campaigns after rejected imports", user: owner, method: "GET", path: "/api/campaigns", status: http.StatusOK, check: hasLen(0)},
+		{name: "import archive", user: owner, method: "POST", path: "/api/campaigns/import", body: `{"version": 1, "campaign": {"title": "Harbour Town"}, "characters": [{"id": "c1", "name": "Aldo"}]}`, status: http.StatusCreated, check: hasField("title", "Harbour Town")},
+		{name: "import without a token", method: "POST", path: "/api/campaigns/import", body: `{"version": 1, "campaign": {"title": "Harbour Town"}}`, status: http.StatusUnauthorized},
+	})
+}
+
+// uploadVault posts data as the "file" of a Markdown vault import.
+func (s *testServer) uploadVault(t *testing.T, userID, filename string, data []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	w, err := mw.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/api/campaigns/import/markdown", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+testToken(t, userID))
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestImportVaultRequests(t *testing.T) {
+	s := newTestServer(t, store.NewMemoryStore())
+
+	var vault bytes.Buffer
+	zw := zip.NewWriter(&vault)
+	w, err := zw.Create("Aldo.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("---\ntype: character\n---\n# Aldo\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := s.uploadVault(t, owner, "Harbour Town.zip", vault.Bytes()); rec.Code != http.StatusCreated || !strings.Contains(rec.Body.String(), `"title":"Harbour Town"`) {
+		t.Errorf("vault import returned %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := s.uploadVault(t, owner, "notes.zip", []byte("# Aldo")); rec.Code != http.StatusBadRequest {
+		t.Errorf("import of a file that is not a zip returned %d, want %d: %s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	// Over the 32MB upload limit
+	if rec := s.uploadVault(t, owner, "large.zip", bytes.Repeat([]byte("x"), 32<<20)); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("vault over the limit returned %d, want %d: %.200s", rec.Code, http.StatusRequestEntityTooLarge, rec.Body.String())
+	}
+}
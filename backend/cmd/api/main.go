@@ -1,17 +1,20 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/minato-wing/lore-keeper/backend/internal/database"
-	"github.com/minato-wing/lore-keeper/backend/internal/handlers"
 	"github.com/minato-wing/lore-keeper/backend/internal/middleware"
 	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
 )
 
 func main() {
@@ -19,8 +22,14 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	if err := database.InitSupabase(); err != nil {
-		log.Fatal("Failed to initialize Supabase:", err)
+	dataStore, err := store.Open(context.Background())
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	authenticator, err := newAuthenticator()
+	if err != nil {
+		log.Fatal("Failed to initialize auth:", err)
 	}
 
 	r := gin.Default()
@@ -41,126 +50,58 @@ func main() {
 		MaxAge:           12 * 3600, // 12 hours
 	}))
 
-	campaignHandler := handlers.NewCampaignHandler()
-	characterHandler := handlers.NewCharacterHandler()
-	relationshipHandler := handlers.NewRelationshipHandler()
-	loreEntryHandler := handlers.NewLoreEntryHandler()
-	aiService := services.NewAIService()
-
-	api := r.Group("/api")
-	{
-		api.GET("/health", func(c *gin.Context) {
-			c.JSON(200, gin.H{"status": "ok"})
-		})
-
-		protected := api.Group("")
-		protected.Use(middleware.AuthMiddleware())
-		{
-			campaigns := protected.Group("/campaigns")
-			{
-				campaigns.GET("", campaignHandler.GetCampaigns)
-				campaigns.GET("/:id", campaignHandler.GetCampaign)
-				campaigns.POST("", campaignHandler.CreateCampaign)
-				campaigns.PUT("/:id", campaignHandler.UpdateCampaign)
-				campaigns.DELETE("/:id", campaignHandler.DeleteCampaign)
-			}
-
-			characters := protected.Group("/characters")
-			{
-				characters.GET("", characterHandler.GetCharacters)
-				characters.GET("/:id", characterHandler.GetCharacter)
-				characters.POST("", characterHandler.CreateCharacter)
-				characters.PUT("/:id", characterHandler.UpdateCharacter)
-				characters.DELETE("/:id", characterHandler.DeleteCharacter)
-			}
-
-			relationships := protected.Group("/relationships")
-			{
-				relationships.GET("", relationshipHandler.GetRelationships)
-				relationships.POST("", relationshipHandler.CreateRelationship)
-				relationships.PUT("/:id", relationshipHandler.UpdateRelationship)
-				relationships.DELETE("/:id", relationshipHandler.DeleteRelationship)
-			}
-
-			loreEntries := protected.Group("/lore-entries")
-			{
-				loreEntries.GET("", loreEntryHandler.GetLoreEntries)
-				loreEntries.GET("/:id", loreEntryHandler.GetLoreEntry)
-				loreEntries.POST("", loreEntryHandler.CreateLoreEntry)
-				loreEntries.PUT("/:id", loreEntryHandler.UpdateLoreEntry)
-				loreEntries.DELETE("/:id", loreEntryHandler.DeleteLoreEntry)
-			}
-
-			ai := protected.Group("/ai")
-			{
-				ai.POST("/deep-dive", func(c *gin.Context) {
-					var req struct {
-						Input map[string]interface{} `json:"input" binding:"required"`
-					}
-					if err := c.ShouldBindJSON(&req); err != nil {
-						c.JSON(400, gin.H{"error": err.Error()})
-						return
-					}
-
-					suggestions, err := aiService.GenerateDeepDive(req.Input)
-					if err != nil {
-						c.JSON(500, gin.H{"error": err.Error()})
-						return
-					}
-
-					c.JSON(200, gin.H{"suggestions": suggestions})
-				})
-
-				ai.POST("/consistency-check", func(c *gin.Context) {
-					var req struct {
-						CampaignID string `json:"campaign_id" binding:"required"`
-						NewContent string `json:"new_content" binding:"required"`
-					}
-					if err := c.ShouldBindJSON(&req); err != nil {
-						c.JSON(400, gin.H{"error": err.Error()})
-						return
-					}
-
-					var loreEntries []struct {
-						Content string `json:"content"`
-					}
-					_, err := database.Client.From("lore_entries").
-						Select("content", "", false).
-						Eq("campaign_id", req.CampaignID).
-						ExecuteTo(&loreEntries)
-
-					if err != nil {
-						c.JSON(500, gin.H{"error": err.Error()})
-						return
-					}
-
-					existingLore := make([]string, len(loreEntries))
-					for i, entry := range loreEntries {
-						existingLore[i] = entry.Content
-					}
-
-					isConsistent, warnings, err := aiService.CheckConsistency(req.NewContent, existingLore)
-					if err != nil {
-						c.JSON(500, gin.H{"error": err.Error()})
-						return
-					}
-
-					c.JSON(200, gin.H{
-						"is_consistent": isConsistent,
-						"warnings":      warnings,
-					})
-				})
-			}
-		}
+	embedder := services.NewEmbedderFromEnv()
+	aiService, err := services.NewAIService()
+	if err != nil {
+		log.Fatal("Failed to initialize AI service:", err)
 	}
 
+	// Deleted content goes to the trash and is purged once the retention
+	// period has passed
+	trashService := services.NewTrashService(dataStore, services.TrashRetentionFromEnv())
+	go trashService.Run(context.Background(), time.Hour)
+
+	registerRoutes(r, dataStore, authenticator, aiService, embedder, trashService)
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Server starting on port %s", port)
-	if err := r.Run(":" + port); err != nil {
+	// Without login anyone who can connect is the local user, so only this
+	// machine may
+	addr := ":" + port
+	if os.Getenv("LOCAL_USER_ID") != "" {
+		addr = "127.0.0.1:" + port
+	}
+
+	log.Printf("Server starting on %s", addr)
+	if err := r.Run(addr); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
 }
+
+// newAuthenticator picks the auth mode: LOCAL_USER_ID runs without login as that
+// user, JWT_SECRET verifies tokens locally, and Supabase Auth is the default.
+// Local auth is for a single user's own database, so it is refused with a
+// shared storage backend.
+func newAuthenticator() (middleware.Authenticator, error) {
+	if userID := os.Getenv("LOCAL_USER_ID"); userID != "" {
+		if backend := store.Backend(); backend != "sqlite" && backend != "memory" {
+			return nil, fmt.Errorf("LOCAL_USER_ID needs STORAGE_BACKEND=sqlite or memory, not %s", backend)
+		}
+		log.Printf("Local auth mode: all requests run as user %s", userID)
+		return middleware.NewLocalAuthenticator(userID, os.Getenv("LOCAL_USER_EMAIL")), nil
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return middleware.NewJWTAuthenticator(secret), nil
+	}
+
+	if database.Client == nil {
+		if err := database.InitSupabase(); err != nil {
+			return nil, err
+		}
+	}
+	return middleware.NewSupabaseAuthenticator(database.Client), nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestLocalAuthNeedsLocalStorage(t *testing.T) {
+	tests := []struct {
+		backend     string
+		databaseURL string
+		wantErr     bool
+	}{
+		{backend: "sqlite"},
+		{backend: "memory"},
+		{backend: "postgres", wantErr: true},
+		{backend: "supabase", wantErr: true},
+		// The backend Open picks when STORAGE_BACKEND is unset
+		{databaseURL: "postgres://localhost/lorekeeper", wantErr: true},
+		{wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend+tt.databaseURL, func(t *testing.T) {
+			t.Setenv("LOCAL_USER_ID", owner)
+			t.Setenv("STORAGE_BACKEND", tt.backend)
+			t.Setenv("DATABASE_URL", tt.databaseURL)
+
+			_, err := newAuthenticator()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// Members the test campaign is shared with, by role.
+const (
+	editor = "00000000-0000-0000-0000-00000000000b"
+	player = "00000000-0000-0000-0000-00000000000c"
+	viewer = "00000000-0000-0000-0000-00000000000d"
+)
+
+// sharedCampaignSteps create a campaign with two characters related to each
+// other, a lore entry and a relation type.
+var sharedCampaignSteps = []step{
+	{name: "create campaign", user: owner, method: "POST", path: "/api/campaigns", body: `{"title": "Harbour Town"}`, status: http.StatusCreated, save: "campaign"},
+	{name: "create character", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Innkeeper"}`, status: http.StatusCreated, save: "innkeeper"},
+	{name: "create second character", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Smuggler"}`, status: http.StatusCreated, save: "smuggler"},
+	{name: "create relationship", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "debtor"}`, status: http.StatusCreated, save: "relationship"},
+	{name: "create lore entry", user: owner, method: "POST", path: "/api/lore-entries", body: `{"campaign_id": "{campaign}", "title": "The Drowned Rat", "content": "A harbour tavern."}`, status: http.StatusCreated, save: "lore"},
+	{name: "create relation type", user: owner, method: "POST", path: "/api/campaigns/{campaign}/relation-types", body: `{"name": "rival", "symmetric": true}`, status: http.StatusCreated, save: "relationType"},
+}
+
+// newSharedCampaign sets up a server whose campaign, created by running
+// steps, is shared with editor, player and viewer.
+func newSharedCampaign(t *testing.T, steps []step) *testServer {
+	t.Helper()
+	s := newTestServer(t, store.NewMemoryStore())
+	s.run(t, steps)
+
+	for user, role := range map[string]string{editor: models.RoleEditor, player: models.RolePlayer, viewer: models.RoleViewer} {
+		rec := s.do(t, owner, "POST", "/api/campaigns/{campaign}/invitations", `{"email": "`+user+`@example.com", "role": "`+role+`"}`)
+		var invitation models.Invitation
+		if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &invitation) != nil {
+			t.Fatalf("invite %s: %d %s", role, rec.Code, rec.Body.String())
+		}
+		if rec := s.do(t, user, "POST", "/api/invitations/accept", `{"code": "`+invitation.Code+`"}`); rec.Code != http.StatusOK {
+			t.Fatalf("accept as %s: %d %s", role, rec.Code, rec.Body.String())
+		}
+	}
+	return s
+}
+
+// TestRoutePermissions sends each route as every role and checks that exactly
+// the roles from the route's minimum up are let through. Each request gets a
+// fresh campaign so earlier writes and deletes cannot decide the outcome.
+func TestRoutePermissions(t *testing.T) {
+	routes := []struct {
+		method  string
+		path    string
+		body    string
+		minimum string
+	}{
+		{"GET", "/api/campaigns/{campaign}", "", models.RoleViewer},
+		{"PUT", "/api/campaigns/{campaign}", `{"title": "Harbour City"}`, models.RoleEditor},
+		{"DELETE", "/api/campaigns/{campaign}", "", models.RoleOwner},
+		{"GET", "/api/campaigns/{campaign}/export", "", models.RoleViewer},
+		{"GET", "/api/campaigns/{campaign}/export/markdown", "", models.RoleViewer},
+		{"GET", "/api/campaigns/{campaign}/search?q=tavern", "", models.RoleViewer},
+		{"GET", "/api/campaigns/{campaign}/graph", "", models.RoleViewer},
+		{"GET", "/api/campaigns/{campaign}/graph/analysis", "", models.RoleViewer},
+		{"GET", "/api/campaigns/{campaign}/members", "", models.RoleViewer},
+		{"PUT", "/api/campaigns/{campaign}/members/" + viewer, `{"role": "player"}`, models.RoleOwner},
+		{"GET", "/api/campaigns/{campaign}/invitations", "", models.RoleOwner},
+		{"POST", "/api/campaigns/{campaign}/invitations", `{"role": "viewer"}`, models.RoleOwner},
+		{"GET", "/api/campaigns/{campaign}/share-links", "", models.RoleOwner},
+		{"POST", "/api/campaigns/{campaign}/share-links", `{"label": "players"}`, models.RoleOwner},
+		{"GET", "/api/campaigns/{campaign}/trash", "", models.RoleEditor},
+		{"GET", "/api/campaigns/{campaign}/provenance", "", models.RoleEditor},
+		{"POST", "/api/campaigns/{campaign}/proposals/apply", `{"proposals": [{"id": "p1", "kind": "lore_entry", "lore_entry": {"title": "Docks", "content": "Busy."}}]}`, models.RoleEditor},
+		{"GET", "/api/campaigns/{campaign}/relation-types", "", models.RoleViewer},
+		{"POST", "/api/campaigns/{campaign}/relation-types", `{"name": "mentor", "inverse_name": "apprentice"}`, models.RoleEditor},
+		{"PUT", "/api/campaigns/{campaign}/relation-types/{relationType}", `{"name": "enemy", "symmetric": true}`, models.RoleEditor},
+		{"DELETE", "/api/campaigns/{campaign}/relation-types/{relationType}", "", models.RoleEditor},
+
+		{"GET", "/api/characters?campaign_id={campaign}", "", models.RoleViewer},
+		{"GET", "/api/characters/{innkeeper}", "", models.RoleViewer},
+		{"POST", "/api/characters", `{"campaign_id": "{campaign}", "name": "Harbourmaster"}`, models.RoleEditor},
+		{"PUT", "/api/characters/{innkeeper}", `{"campaign_id": "{campaign}", "name": "Old Innkeeper"}`, models.RoleEditor},
+		{"DELETE", "/api/characters/{innkeeper}", "", models.RoleEditor},
+		{"GET", "/api/characters/{innkeeper}/revisions", "", models.RoleEditor},
+
+		{"GET", "/api/relationships?campaign_id={campaign}", "", models.RoleViewer},
+		{"POST", "/api/relationships", `{"campaign_id": "{campaign}", "source_character_id": "{smuggler}", "target_character_id": "{innkeeper}", "relation_type": "creditor"}`, models.RoleEditor},
+		{"PUT", "/api/relationships/{relationship}", `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "friend"}`, models.RoleEditor},
+		{"DELETE", "/api/relationships/{relationship}", "", models.RoleEditor},
+
+		{"GET", "/api/lore-entries?campaign_id={campaign}", "", models.RoleViewer},
+		{"GET", "/api/lore-entries/{lore}", "", models.RoleViewer},
+		{"POST", "/api/lore-entries", `{"campaign_id": "{campaign}", "title": "Docks", "content": "Busy."}`, models.RoleEditor},
+		{"PUT", "/api/lore-entries/{lore}", `{"campaign_id": "{campaign}", "title": "The Drowned Rat", "content": "Closed."}`, models.RoleEditor},
+		{"DELETE", "/api/lore-entries/{lore}", "", models.RoleEditor},
+		{"GET", "/api/lore-entries/{lore}/revisions", "", models.RoleEditor},
+
+		{"POST", "/api/ai/deep-dive", `{"campaign_id": "{campaign}", "character_id": "{innkeeper}"}`, models.RoleEditor},
+		{"POST", "/api/ai/consistency-check", `{"campaign_id": "{campaign}", "new_content": "The tavern burned down."}`, models.RoleEditor},
+	}
+
+	roles := []struct {
+		user string
+		role string
+	}{
+		{owner, models.RoleOwner},
+		{editor, models.RoleEditor},
+		{player, models.RolePlayer},
+		{viewer, models.RoleViewer},
+		{stranger, ""},
+	}
+	rank := map[string]int{models.RoleViewer: 1, models.RolePlayer: 2, models.RoleEditor: 3, models.RoleOwner: 4}
+
+	for _, route := range routes {
+		for _, r := range roles {
+			name := r.role
+			if name == "" {
+				name = "non-member"
+			}
+			t.Run(route.method+" "+route.path+" as "+name, func(t *testing.T) {
+				s := newSharedCampaign(t, sharedCampaignSteps)
+				rec := s.do(t, r.user, route.method, route.path, route.body)
+
+				allowed := rank[r.role] >= rank[route.minimum]
+				switch {
+				case allowed && (rec.Code < 200 || rec.Code > 299):
+					t.Errorf("got %d, want success: %s", rec.Code, rec.Body.String())
+				case !allowed && rec.Code != http.StatusForbidden:
+					t.Errorf("got %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body.String())
+				}
+			})
+		}
+	}
+}
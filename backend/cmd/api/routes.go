@@ -0,0 +1,142 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/handlers"
+	"github.com/minato-wing/lore-keeper/backend/internal/middleware"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// registerRoutes wires the API handlers onto r.
+func registerRoutes(r *gin.Engine, dataStore store.Store, authenticator middleware.Authenticator, aiService *services.AIService, embedder services.Embedder, trashService *services.TrashService) {
+	checker := services.NewConsistencyChecker(dataStore, aiService, embedder)
+
+	campaignHandler := handlers.NewCampaignHandler(dataStore)
+	characterHandler := handlers.NewCharacterHandler(dataStore, embedder, checker)
+	relationshipHandler := handlers.NewRelationshipHandler(dataStore)
+	loreEntryHandler := handlers.NewLoreEntryHandler(dataStore, embedder, checker)
+	searchHandler := handlers.NewSearchHandler(dataStore, embedder)
+	aiHandler := handlers.NewAIHandler(dataStore, aiService, checker, embedder)
+	proposalHandler := handlers.NewProposalHandler(dataStore, embedder)
+	extractionHandler := handlers.NewExtractionHandler(dataStore, aiService, embedder)
+	archiveHandler := handlers.NewArchiveHandler(dataStore, embedder)
+	graphHandler := handlers.NewGraphHandler(dataStore)
+	memberHandler := handlers.NewMemberHandler(dataStore)
+	revisionHandler := handlers.NewRevisionHandler(dataStore, embedder)
+	shareLinkHandler := handlers.NewShareLinkHandler(dataStore, services.NewShareLinkService(dataStore))
+	trashHandler := handlers.NewTrashHandler(dataStore, trashService)
+
+	api := r.Group("/api")
+	{
+		api.GET("/health", func(c *gin.Context) {
+			c.JSON(200, gin.H{"status": "ok"})
+		})
+
+		// Read-only share links work without an account; the token is the
+		// only credential
+		shared := api.Group("/share/:token")
+		{
+			shared.GET("", shareLinkHandler.GetSharedCampaign)
+			shared.GET("/characters", shareLinkHandler.GetSharedCharacters)
+			shared.GET("/characters/:characterId", shareLinkHandler.GetSharedCharacter)
+			shared.GET("/relationships", shareLinkHandler.GetSharedRelationships)
+			shared.GET("/lore-entries", shareLinkHandler.GetSharedLoreEntries)
+			shared.GET("/lore-entries/:entryId", shareLinkHandler.GetSharedLoreEntry)
+		}
+
+		protected := api.Group("")
+		protected.Use(middleware.AuthMiddleware(authenticator))
+		{
+			campaigns := protected.Group("/campaigns")
+			{
+				campaigns.GET("", campaignHandler.GetCampaigns)
+				campaigns.GET("/:id", campaignHandler.GetCampaign)
+				campaigns.POST("", campaignHandler.CreateCampaign)
+				campaigns.PUT("/:id", campaignHandler.UpdateCampaign)
+				campaigns.DELETE("/:id", campaignHandler.DeleteCampaign)
+				campaigns.POST("/import", archiveHandler.ImportCampaign)
+				campaigns.POST("/import/markdown", archiveHandler.ImportCampaignMarkdown)
+				campaigns.GET("/:id/export", archiveHandler.ExportCampaign)
+				campaigns.GET("/:id/export/markdown", archiveHandler.ExportCampaignMarkdown)
+				campaigns.GET("/:id/search", searchHandler.Search)
+				campaigns.GET("/:id/members", memberHandler.GetMembers)
+				campaigns.PUT("/:id/members/:userId", memberHandler.UpdateMember)
+				campaigns.DELETE("/:id/members/:userId", memberHandler.DeleteMember)
+				campaigns.GET("/:id/invitations", memberHandler.GetInvitations)
+				campaigns.POST("/:id/invitations", memberHandler.CreateInvitation)
+				campaigns.DELETE("/:id/invitations/:invitationId", memberHandler.DeleteInvitation)
+				campaigns.GET("/:id/trash", trashHandler.GetTrash)
+				campaigns.DELETE("/:id/trash", trashHandler.EmptyTrash)
+				campaigns.POST("/:id/trash/:type/:itemId/restore", trashHandler.RestoreTrashItem)
+				campaigns.DELETE("/:id/trash/:type/:itemId", trashHandler.PurgeTrashItem)
+				campaigns.GET("/:id/share-links", shareLinkHandler.GetShareLinks)
+				campaigns.POST("/:id/share-links", shareLinkHandler.CreateShareLink)
+				campaigns.DELETE("/:id/share-links/:linkId", shareLinkHandler.DeleteShareLink)
+				campaigns.GET("/:id/relation-types", relationshipHandler.GetRelationTypes)
+				campaigns.POST("/:id/relation-types", relationshipHandler.CreateRelationType)
+				campaigns.PUT("/:id/relation-types/:typeId", relationshipHandler.UpdateRelationType)
+				campaigns.DELETE("/:id/relation-types/:typeId", relationshipHandler.DeleteRelationType)
+				campaigns.GET("/:id/graph", graphHandler.ExportGraph)
+				campaigns.GET("/:id/graph/analysis", graphHandler.AnalyzeGraph)
+				campaigns.GET("/:id/graph/path", graphHandler.GetPath)
+				campaigns.POST("/:id/proposals/apply", proposalHandler.ApplyProposals)
+				campaigns.GET("/:id/provenance", proposalHandler.GetProvenance)
+				campaigns.POST("/:id/relationships/extract", extractionHandler.ExtractRelationships)
+				campaigns.POST("/:id/session-notes/ingest", extractionHandler.IngestSessionNotes)
+			}
+
+			characters := protected.Group("/characters")
+			{
+				characters.GET("", characterHandler.GetCharacters)
+				characters.GET("/:id", characterHandler.GetCharacter)
+				characters.POST("", characterHandler.CreateCharacter)
+				characters.PUT("/:id", characterHandler.UpdateCharacter)
+				characters.DELETE("/:id", characterHandler.DeleteCharacter)
+				characters.GET("/:id/revisions", revisionHandler.GetCharacterRevisions)
+				characters.GET("/:id/revisions/diff", revisionHandler.DiffCharacterRevisions)
+				characters.POST("/:id/revisions/:revisionId/restore", revisionHandler.RestoreCharacterRevision)
+			}
+
+			trash := protected.Group("/trash")
+			{
+				trash.GET("/campaigns", trashHandler.GetTrashedCampaigns)
+				trash.POST("/campaigns/:id/restore", trashHandler.RestoreCampaign)
+				trash.DELETE("/campaigns/:id", trashHandler.PurgeCampaign)
+			}
+
+			invitations := protected.Group("/invitations")
+			{
+				invitations.GET("", memberHandler.GetMyInvitations)
+				invitations.POST("/accept", memberHandler.AcceptInvitation)
+			}
+
+			relationships := protected.Group("/relationships")
+			{
+				relationships.GET("", relationshipHandler.GetRelationships)
+				relationships.POST("", relationshipHandler.CreateRelationship)
+				relationships.PUT("/:id", relationshipHandler.UpdateRelationship)
+				relationships.DELETE("/:id", relationshipHandler.DeleteRelationship)
+			}
+
+			loreEntries := protected.Group("/lore-entries")
+			{
+				loreEntries.GET("", loreEntryHandler.GetLoreEntries)
+				loreEntries.GET("/:id", loreEntryHandler.GetLoreEntry)
+				loreEntries.POST("", loreEntryHandler.CreateLoreEntry)
+				loreEntries.PUT("/:id", loreEntryHandler.UpdateLoreEntry)
+				loreEntries.DELETE("/:id", loreEntryHandler.DeleteLoreEntry)
+				loreEntries.GET("/:id/revisions", revisionHandler.GetLoreEntryRevisions)
+				loreEntries.GET("/:id/revisions/diff", revisionHandler.DiffLoreEntryRevisions)
+				loreEntries.POST("/:id/revisions/:revisionId/restore", revisionHandler.RestoreLoreEntryRevision)
+			}
+
+			ai := protected.Group("/ai")
+			{
+				ai.POST("/deep-dive", aiHandler.DeepDive)
+				ai.POST("/deep-dive/stream", aiHandler.StreamDeepDive)
+				ai.POST("/consistency-check", aiHandler.CheckConsistency)
+			}
+		}
+	}
+}
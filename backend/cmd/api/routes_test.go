@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/minato-wing/lore-keeper/backend/internal/middleware"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+const testJWTSecret = "test-secret"
+
+func TestMain(m *testing.M) {
+	// The auth middleware logs every request
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// Users of the test campaigns. owner creates them; stranger never joins.
+const (
+	owner    = "00000000-0000-0000-0000-00000000000a"
+	stranger = "00000000-0000-0000-0000-00000000000f"
+)
+
+// testServer serves the API routes from a store, authenticating HS256 tokens
+// signed with testJWTSecret. The model always finds content consistent.
+type testServer struct {
+	router *gin.Engine
+	// ids holds the IDs saved by earlier steps, by name
+	ids map[string]string
+}
+
+func newTestServer(t *testing.T, dataStore store.Store) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	llm := services.NewFakeProvider(`{"is_consistent": true, "warnings": []}`)
+	aiService := services.NewAIServiceWithProviders(llm, llm, llm)
+	embedder := services.NewHashingEmbedder(services.EmbeddingDimensions)
+
+	r := gin.New()
+	registerRoutes(r, dataStore, middleware.NewJWTAuthenticator(testJWTSecret), aiService, embedder,
+		services.NewTrashService(dataStore, 30*24*time.Hour))
+	return &testServer{router: r, ids: map[string]string{}}
+}
+
+func testToken(t *testing.T, userID string) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   userID,
+		"email": userID + "@example.com",
+	}).SignedString([]byte(testJWTSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// expand replaces each {name} in s with the ID saved under name.
+func (s *testServer) expand(text string) string {
+	for name, id := range s.ids {
+		text = strings.ReplaceAll(text, "{"+name+"}", id)
+	}
+	return text
+}
+
+// do sends a request as userID, or without a token when userID is empty.
+func (s *testServer) do(t *testing.T, userID, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, s.expand(path), strings.NewReader(s.expand(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("Authorization", "Bearer "+testToken(t, userID))
+	}
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// step is one request of a scenario and the response it expects.
+type step struct {
+	name   string
+	user   string
+	method string
+	path   string
+	body   string
+	status int
+	// save stores the "id" of the response under this name for later steps
+	save  string
+	check func(t *testing.T, body []byte)
+}
+
+func (s *testServer) run(t *testing.T, steps []step) {
+	t.Helper()
+	for _, st := range steps {
+		rec := s.do(t, st.user, st.method, st.path, st.body)
+		if rec.Code != st.status {
+			t.Fatalf("%s: %s %s returned %d, want %d: %s", st.name, st.method, s.expand(st.path), rec.Code, st.status, rec.Body.String())
+		}
+		if st.save != "" {
+			var created struct {
+				ID string `json:"id"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+				t.Fatalf("%s: no id in %s", st.name, rec.Body.String())
+			}
+			s.ids[st.save] = created.ID
+		}
+		if st.check != nil {
+			st.check(t, rec.Body.Bytes())
+		}
+	}
+}
+
+// hasField checks that the response object has key set to want.
+func hasField(key string, want interface{}) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		t.Helper()
+		var object map[string]interface{}
+		if err := json.Unmarshal(body, &object); err != nil {
+			t.Fatalf("response is not an object: %s", body)
+		}
+		if object[key] != want {
+			t.Errorf("%s = %v, want %v in %s", key, object[key], want, body)
+		}
+	}
+}
+
+// hasLen checks that the response is a list of n items.
+func hasLen(n int) func(t *testing.T, body []byte) {
+	return func(t *testing.T, body []byte) {
+		t.Helper()
+		var list []json.RawMessage
+		if err := json.Unmarshal(body, &list); err != nil {
+			t.Fatalf("response is not a list: %s", body)
+		}
+		if len(list) != n {
+			t.Errorf("got %d items, want %d: %s", len(list), n, body)
+		}
+	}
+}
+
+const missingID = "00000000-0000-0000-0000-000000000000"
+
+// crudSteps creates, reads, updates and deletes a campaign with its
+// characters, relationships and lore, along with the requests that must be
+// refused on the way.
+var crudSteps = []step{
+	{name: "no token", method: "GET", path: "/api/campaigns", status: http.StatusUnauthorized},
+	{name: "create campaign", user: owner, method: "POST", path: "/api/campaigns", body: `{"title": "Harbour Town"}`, status: http.StatusCreated, save: "campaign"},
+	{name: "create campaign without title", user: owner, method: "POST", path: "/api/campaigns", body: `{}`, status: http.StatusBadRequest},
+	{name: "get campaign", user: owner, method: "GET", path: "/api/campaigns/{campaign}", status: http.StatusOK, check: hasField("title", "Harbour Town")},
+	{name: "get campaign as stranger", user: stranger, method: "GET", path: "/api/campaigns/{campaign}", status: http.StatusForbidden},
+	{name: "get missing campaign", user: owner, method: "GET", path: "/api/campaigns/" + missingID, status: http.StatusForbidden},
+	{name: "update campaign", user: owner, method: "PUT", path: "/api/campaigns/{campaign}", body: `{"title": "Harbour City"}`, status: http.StatusOK, check: hasField("title", "Harbour City")},
+	{name: "update campaign as stranger", user: stranger, method: "PUT", path: "/api/campaigns/{campaign}", body: `{"title": "Mine"}`, status: http.StatusForbidden},
+	{name: "list campaigns", user: owner, method: "GET", path: "/api/campaigns", status: http.StatusOK, check: hasLen(1)},
+	{name: "list campaigns as stranger", user: stranger, method: "GET", path: "/api/campaigns", status: http.StatusOK, check: hasLen(0)},
+
+	{name: "create character", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Innkeeper", "role": "NPC"}`, status: http.StatusCreated, save: "innkeeper"},
+	{name: "create second character", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Smuggler", "role": "NPC"}`, status: http.StatusCreated, save: "smuggler"},
+	{name: "create character without name", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}"}`, status: http.StatusBadRequest},
+	{name: "create character as stranger", user: stranger, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Spy"}`, status: http.StatusForbidden},
+	{name: "list characters", user: owner, method: "GET", path: "/api/characters?campaign_id={campaign}", status: http.StatusOK, check: hasLen(2)},
+	{name: "list characters as stranger", user: stranger, method: "GET", path: "/api/characters?campaign_id={campaign}", status: http.StatusForbidden},
+	{name: "get character", user: owner, method: "GET", path: "/api/characters/{innkeeper}", status: http.StatusOK, check: hasField("name", "Innkeeper")},
+	{name: "get character as stranger", user: stranger, method: "GET", path: "/api/characters/{innkeeper}", status: http.StatusForbidden},
+	{name: "get missing character", user: owner, method: "GET", path: "/api/characters/" + missingID, status: http.StatusNotFound},
+	{name: "update character", user: owner, method: "PUT", path: "/api/characters/{innkeeper}", body: `{"campaign_id": "{campaign}", "name": "Old Innkeeper", "role": "NPC"}`, status: http.StatusOK, check: hasField("name", "Old Innkeeper")},
+	{name: "update character as stranger", user: stranger, method: "PUT", path: "/api/characters/{innkeeper}", body: `{"campaign_id": "{campaign}", "name": "Spy"}`, status: http.StatusForbidden},
+	{name: "update missing character", user: owner, method: "PUT", path: "/api/characters/" + missingID, body: `{"campaign_id": "{campaign}", "name": "Nobody"}`, status: http.StatusNotFound},
+
+	{name: "create relationship", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "debtor"}`, status: http.StatusCreated, save: "relationship"},
+	{name: "create duplicate relationship", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "friend"}`, status: http.StatusConflict},
+	{name: "create relationship to missing character", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "` + missingID + `", "relation_type": "friend"}`, status: http.StatusNotFound},
+	{name: "create relationship as stranger", user: stranger, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{smuggler}", "target_character_id": "{innkeeper}", "relation_type": "friend"}`, status: http.StatusForbidden},
+	{name: "list relationships", user: owner, method: "GET", path: "/api/relationships?campaign_id={campaign}", status: http.StatusOK, check: hasLen(1)},
+	{name: "update relationship", user: owner, method: "PUT", path: "/api/relationships/{relationship}", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "creditor"}`, status: http.StatusOK, check: hasField("relation_type", "creditor")},
+	{name: "update missing relationship", user: owner, method: "PUT", path: "/api/relationships/" + missingID, body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{smuggler}", "relation_type": "friend"}`, status: http.StatusNotFound},
+	{name: "delete relationship as stranger", user: stranger, method: "DELETE", path: "/api/relationships/{relationship}", status: http.StatusForbidden},
+	{name: "delete relationship", user: owner, method: "DELETE", path: "/api/relationships/{relationship}", status: http.StatusNoContent},
+	{name: "delete relationship twice", user: owner, method: "DELETE", path: "/api/relationships/{relationship}", status: http.StatusNotFound},
+	{name: "list relationships after delete", user: owner, method: "GET", path: "/api/relationships?campaign_id={campaign}", status: http.StatusOK, check: hasLen(0)},
+
+	{name: "create lore entry", user: owner, method: "POST", path: "/api/lore-entries", body: `{"campaign_id": "{campaign}", "title": "The Drowned Rat", "category": "location", "content": "A harbour tavern."}`, status: http.StatusCreated, save: "lore"},
+	{name: "create lore entry without content", user: owner, method: "POST", path: "/api/lore-entries", body: `{"campaign_id": "{campaign}", "title": "Empty"}`, status: http.StatusBadRequest},
+	{name: "create lore entry as stranger", user: stranger, method: "POST", path: "/api/lore-entries", body: `{"campaign_id": "{campaign}", "title": "Spy", "content": "x"}`, status: http.StatusForbidden},
+	{name: "list lore entries", user: owner, method: "GET", path: "/api/lore-entries?campaign_id={campaign}", status: http.StatusOK, check: hasLen(1)},
+	{name: "get lore entry", user: owner, method: "GET", path: "/api/lore-entries/{lore}", status: http.StatusOK, check: hasField("title", "The Drowned Rat")},
+	{name: "get lore entry as stranger", user: stranger, method: "GET", path: "/api/lore-entries/{lore}", status: http.StatusForbidden},
+	{name: "get missing lore entry", user: owner, method: "GET", path: "/api/lore-entries/" + missingID, status: http.StatusNotFound},
+	{name: "update lore entry", user: owner, method: "PUT", path: "/api/lore-entries/{lore}", body: `{"campaign_id": "{campaign}", "title": "The Drowned Rat", "content": "A tavern on the docks."}`, status: http.StatusOK, check: hasField("content", "A tavern on the docks.")},
+	{name: "delete lore entry as stranger", user: stranger, method: "DELETE", path: "/api/lore-entries/{lore}", status: http.StatusForbidden},
+	{name: "delete lore entry", user: owner, method: "DELETE", path: "/api/lore-entries/{lore}", status: http.StatusNoContent},
+	{name: "get deleted lore entry", user: owner, method: "GET", path: "/api/lore-entries/{lore}", status: http.StatusNotFound},
+
+	{name: "delete character as stranger", user: stranger, method: "DELETE", path: "/api/characters/{smuggler}", status: http.StatusForbidden},
+	{name: "delete character", user: owner, method: "DELETE", path: "/api/characters/{smuggler}", status: http.StatusNoContent},
+	{name: "get deleted character", user: owner, method: "GET", path: "/api/characters/{smuggler}", status: http.StatusNotFound},
+	{name: "list characters after delete", user: owner, method: "GET", path: "/api/characters?campaign_id={campaign}", status: http.StatusOK, check: hasLen(1)},
+	{name: "restore character from the trash", user: owner, method: "POST", path: "/api/campaigns/{campaign}/trash/characters/{smuggler}/restore", status: http.StatusNoContent},
+	{name: "get restored character", user: owner, method: "GET", path: "/api/characters/{smuggler}", status: http.StatusOK},
+
+	{name: "delete campaign as stranger", user: stranger, method: "DELETE", path: "/api/campaigns/{campaign}", status: http.StatusForbidden},
+	{name: "delete campaign", user: owner, method: "DELETE", path: "/api/campaigns/{campaign}", status: http.StatusNoContent},
+	{name: "get deleted campaign", user: owner, method: "GET", path: "/api/campaigns/{campaign}", status: http.StatusForbidden},
+	{name: "get character of deleted campaign", user: owner, method: "GET", path: "/api/characters/{innkeeper}", status: http.StatusForbidden},
+}
+
+func TestCRUDMemoryStore(t *testing.T) {
+	newTestServer(t, store.NewMemoryStore()).run(t, crudSteps)
+}
+
+// TestCRUDSQLiteStore runs the same requests against a SQLite file migrated
+// from scratch, so SQLStore must answer them as MemoryStore does.
+func TestCRUDSQLiteStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lore-keeper.db")
+	dataStore, err := store.NewSQLiteStore(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataStore.Close()
+
+	// Every migration file is recorded as applied
+	files, err := filepath.Glob("../../internal/store/migrations/sqlite/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var applied int
+	if err := db.QueryRow("select count(*) from schema_migrations").Scan(&applied); err != nil {
+		t.Fatal(err)
+	}
+	if applied != len(files) {
+		t.Errorf("%d migrations applied, want %d", applied, len(files))
+	}
+
+	newTestServer(t, dataStore).run(t, crudSteps)
+
+	// Opening the file again finds nothing left to migrate
+	reopened, err := store.NewSQLiteStore(context.Background(), path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	reopened.Close()
+}
+
+// TestCRUDPostgresStore runs the same requests against the PostgreSQL that
+// DATABASE_URL points at, skipping without one. The test users' campaigns are
+// deleted before and after.
+func TestCRUDPostgresStore(t *testing.T) {
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+	dataStore, err := store.NewPostgresStore(context.Background(), databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dataStore.Close()
+
+	deleteCampaigns := func() {
+		for _, userID := range []string{owner, stranger} {
+			campaigns, err := dataStore.ListCampaigns(context.Background(), userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, campaign := range campaigns {
+				if err := dataStore.DeleteCampaign(context.Background(), campaign.ID); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	deleteCampaigns()
+	defer deleteCampaigns()
+
+	newTestServer(t, dataStore).run(t, crudSteps)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// secretMarker appears only in GM-only content, so any response that
+// contains it has leaked some.
+const secretMarker = "kraken"
+
+var secretCampaignSteps = append(append([]step{}, sharedCampaignSteps...),
+	step{name: "create GM-only character", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Kraken Cultist", "visibility": "gm"}`, status: http.StatusCreated, save: "cultist"},
+	step{name: "create character with a GM-only attribute", user: owner, method: "POST", path: "/api/characters", body: `{"campaign_id": "{campaign}", "name": "Harbourmaster", "attributes": {"age": 61, "oath": "serves the kraken"}, "attribute_visibility": {"oath": "gm"}}`, status: http.StatusCreated, save: "harbourmaster"},
+	step{name: "create GM-only relationship", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{smuggler}", "target_character_id": "{harbourmaster}", "relation_type": "informant", "description": "reports to the kraken", "visibility": "gm"}`, status: http.StatusCreated},
+	step{name: "create relationship to the GM-only character", user: owner, method: "POST", path: "/api/relationships", body: `{"campaign_id": "{campaign}", "source_character_id": "{innkeeper}", "target_character_id": "{cultist}", "relation_type": "kraken_ally"}`, status: http.StatusCreated},
+	step{name: "create GM-only lore entry", user: owner, method: "POST", path: "/api/lore-entries", body: `{"campaign_id": "{campaign}", "title": "Kraken Lair", "content": "Beneath the harbour.", "visibility": "gm"}`, status: http.StatusCreated, save: "lair"},
+	step{name: "update GM-only lore entry without visibility", user: owner, method: "PUT", path: "/api/lore-entries/{lair}", body: `{"campaign_id": "{campaign}", "title": "Kraken Lair", "content": "Beneath the old harbour."}`, status: http.StatusOK},
+	step{name: "update GM-only character without visibility", user: owner, method: "PUT", path: "/api/characters/{cultist}", body: `{"campaign_id": "{campaign}", "name": "Kraken Cultist", "role": "Villain"}`, status: http.StatusOK},
+)
+
+// TestGMContentDoesNotLeak reads the campaign through every view players,
+// viewers and share links have and checks none of them shows GM-only
+// content, while the GMs see it.
+func TestGMContentDoesNotLeak(t *testing.T) {
+	s := newSharedCampaign(t, secretCampaignSteps)
+
+	rec := s.do(t, owner, "POST", "/api/campaigns/{campaign}/share-links", `{"label": "players"}`)
+	var link struct {
+		Token string `json:"token"`
+	}
+	if rec.Code != http.StatusCreated || json.Unmarshal(rec.Body.Bytes(), &link) != nil || link.Token == "" {
+		t.Fatalf("create share link: %d %s", rec.Code, rec.Body.String())
+	}
+	s.ids["token"] = link.Token
+
+	memberViews := []string{
+		// The query is echoed, so it names the secrets without the marker
+		"/api/campaigns/{campaign}/search?q=cultist%20lair%20oath",
+		"/api/campaigns/{campaign}/search?q=cultist%20lair%20oath&type=character",
+		"/api/campaigns/{campaign}/export",
+		"/api/campaigns/{campaign}/export/markdown",
+		"/api/campaigns/{campaign}/graph",
+		"/api/campaigns/{campaign}/graph?format=graphml",
+		"/api/campaigns/{campaign}/graph?format=dot",
+		"/api/campaigns/{campaign}/graph/analysis",
+		"/api/characters?campaign_id={campaign}",
+		"/api/characters/{harbourmaster}",
+		"/api/characters/{cultist}",
+		"/api/relationships?campaign_id={campaign}",
+		"/api/lore-entries?campaign_id={campaign}",
+	}
+	shareViews := []string{
+		"/api/share/{token}",
+		"/api/share/{token}/characters",
+		"/api/share/{token}/characters/{harbourmaster}",
+		"/api/share/{token}/characters/{cultist}",
+		"/api/share/{token}/relationships",
+		"/api/share/{token}/lore-entries",
+	}
+
+	check := func(t *testing.T, user, path string) {
+		t.Helper()
+		rec := s.do(t, user, "GET", path, "")
+		if rec.Code >= 500 {
+			t.Fatalf("GET %s: %d %s", path, rec.Code, rec.Body.String())
+		}
+		if body := readableBody(t, rec.Body.Bytes()); strings.Contains(strings.ToLower(body), secretMarker) {
+			t.Errorf("GET %s shows GM-only content: %s", path, body)
+		}
+	}
+
+	for _, user := range []string{player, viewer} {
+		for _, path := range memberViews {
+			check(t, user, path)
+		}
+	}
+	for _, path := range shareViews {
+		check(t, "", path)
+	}
+
+	// The harbourmaster is visible, just without the oath
+	rec = s.do(t, player, "GET", "/api/characters/{harbourmaster}", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"age":61`) {
+		t.Errorf("player cannot see the harbourmaster's public attributes: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// GMs still see everything, so the marker does reach the views
+	for _, user := range []string{owner, editor} {
+		for _, path := range []string{"/api/campaigns/{campaign}/search?q=cultist%20lair%20oath", "/api/campaigns/{campaign}/export", "/api/campaigns/{campaign}/export/markdown", "/api/characters?campaign_id={campaign}", "/api/relationships?campaign_id={campaign}", "/api/lore-entries?campaign_id={campaign}"} {
+			rec := s.do(t, user, "GET", path, "")
+			if rec.Code != http.StatusOK || !strings.Contains(strings.ToLower(readableBody(t, rec.Body.Bytes())), secretMarker) {
+				t.Errorf("GET %s as a GM does not show the GM-only content: %d %s", path, rec.Code, rec.Body.String())
+			}
+		}
+	}
+}
+
+// readableBody returns body as text, with a zip archive (the Markdown export)
+// replaced by the names and contents of its files.
+func readableBody(t *testing.T, body []byte) string {
+	t.Helper()
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return string(body)
+	}
+
+	var text strings.Builder
+	for _, file := range archive.File {
+		r, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		text.WriteString(file.Name + "\n" + string(content) + "\n")
+	}
+	return text.String()
+}
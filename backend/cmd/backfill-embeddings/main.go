@@ -0,0 +1,115 @@
+// Command backfill-embeddings computes embeddings for characters and lore
+// entries that were saved without one. It uses the same storage and embedding
+// configuration as the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"github.com/joho/godotenv"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+func main() {
+	batchSize := flag.Int("batch", 50, "rows fetched per query")
+	dryRun := flag.Bool("dry-run", false, "only report how many rows are missing embeddings")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+
+	ctx := context.Background()
+
+	dataStore, err := store.Open(ctx)
+	if err != nil {
+		log.Fatal("Failed to initialize storage:", err)
+	}
+
+	embedder := services.NewEmbedderFromEnv()
+
+	characters, failed := backfillCharacters(ctx, dataStore, embedder, *batchSize, *dryRun)
+	report("Characters", characters, failed, *dryRun)
+
+	loreEntries, failed := backfillLoreEntries(ctx, dataStore, embedder, *batchSize, *dryRun)
+	report("Lore entries", loreEntries, failed, *dryRun)
+}
+
+func report(kind string, done, failed int, dryRun bool) {
+	if dryRun {
+		log.Printf("%s missing embeddings: %d", kind, done)
+		return
+	}
+	log.Printf("%s: %d embedded, %d failed", kind, done, failed)
+}
+
+// Rows that are skipped (dry run) or fail to embed stay in the "missing" set,
+// so the offset moves past them and the loop still terminates.
+func backfillCharacters(ctx context.Context, s store.Store, embedder services.Embedder, batchSize int, dryRun bool) (int, int) {
+	done, failed, offset := 0, 0, 0
+	for {
+		characters, err := s.ListCharactersMissingEmbedding(ctx, batchSize, offset)
+		if err != nil {
+			log.Fatal("Failed to list characters:", err)
+		}
+		if len(characters) == 0 {
+			return done, failed
+		}
+
+		for _, character := range characters {
+			if dryRun {
+				done++
+				offset++
+				continue
+			}
+
+			embedding, err := embedder.Embed(ctx, services.CharacterEmbeddingText(&character))
+			if err == nil {
+				err = s.SetCharacterEmbedding(ctx, character.ID, embedding)
+			}
+			if err != nil {
+				log.Printf("Character %s: %v", character.ID, err)
+				failed++
+				offset++
+				continue
+			}
+			done++
+		}
+	}
+}
+
+func backfillLoreEntries(ctx context.Context, s store.Store, embedder services.Embedder, batchSize int, dryRun bool) (int, int) {
+	done, failed, offset := 0, 0, 0
+	for {
+		loreEntries, err := s.ListLoreEntriesMissingEmbedding(ctx, batchSize, offset)
+		if err != nil {
+			log.Fatal("Failed to list lore entries:", err)
+		}
+		if len(loreEntries) == 0 {
+			return done, failed
+		}
+
+		for _, loreEntry := range loreEntries {
+			if dryRun {
+				done++
+				offset++
+				continue
+			}
+
+			embedding, err := embedder.Embed(ctx, services.LoreEntryEmbeddingText(&loreEntry))
+			if err == nil {
+				err = s.SetLoreEntryEmbedding(ctx, loreEntry.ID, embedding)
+			}
+			if err != nil {
+				log.Printf("Lore entry %s: %v", loreEntry.ID, err)
+				failed++
+				offset++
+				continue
+			}
+			done++
+		}
+	}
+}
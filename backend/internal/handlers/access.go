@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// authorizeCampaign loads a campaign and checks that userID has at least role
+// in it, writing the error response if not. Every handler that reads or
+// writes a campaign's data goes through here.
+func authorizeCampaign(c *gin.Context, s store.Store, campaignID, userID, role string) (*models.Campaign, bool) {
+	campaign, err := services.NewAccessService(s).Authorize(c.Request.Context(), campaignID, userID, role)
+	switch {
+	case err == nil:
+		return campaign, true
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	case errors.Is(err, services.ErrAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return nil, false
+}
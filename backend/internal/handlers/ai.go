@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type AIHandler struct {
+	store    store.Store
+	ai       *services.AIService
+	checker  *services.ConsistencyChecker
+	grounder *services.DeepDiveGrounder
+}
+
+func NewAIHandler(s store.Store, ai *services.AIService, checker *services.ConsistencyChecker, embedder services.Embedder) *AIHandler {
+	return &AIHandler{
+		store:    s,
+		ai:       ai,
+		checker:  checker,
+		grounder: services.NewDeepDiveGrounder(s, embedder),
+	}
+}
+
+func (h *AIHandler) DeepDive(c *gin.Context) {
+	req, grounding, ok := h.bindDeepDive(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.ai.GenerateDeepDive(c.Request.Context(), req.Input, grounding)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// StreamDeepDive streams deep-dive output as server-sent events: "text" with
+// raw output as it arrives, "suggestion" for each completed suggestion (with its
+// proposal, if any), then "done" with the full result, or "error" if generation
+// fails midway.
+func (h *AIHandler) StreamDeepDive(c *gin.Context) {
+	req, grounding, ok := h.bindDeepDive(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	index := 0
+	send := func(event string, data interface{}) error {
+		c.SSEvent(event, data)
+		c.Writer.Flush()
+		// Stop generating once the client has gone away
+		return c.Request.Context().Err()
+	}
+
+	result, err := h.ai.StreamDeepDive(c.Request.Context(), req.Input, grounding,
+		func(text string) error {
+			return send("text", gin.H{"text": text})
+		},
+		func(suggestion string, proposal *models.Proposal) error {
+			index++
+			event := gin.H{"index": index - 1, "suggestion": suggestion}
+			if proposal != nil {
+				event["proposal"] = proposal
+			}
+			return send("suggestion", event)
+		},
+	)
+	if err != nil {
+		send("error", gin.H{"error": err.Error()})
+		return
+	}
+
+	send("done", result)
+}
+
+// bindDeepDive reads a deep-dive request and loads the campaign material it is
+// grounded in. It writes the error response and returns false on failure.
+func (h *AIHandler) bindDeepDive(c *gin.Context) (*models.DeepDiveRequest, *services.DeepDiveContext, bool) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, nil, false
+	}
+
+	var req models.DeepDiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+	if len(req.Input) == 0 && req.CharacterID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "input or character_id is required"})
+		return nil, nil, false
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, req.CampaignID, userID, models.RoleEditor)
+	if !ok {
+		return nil, nil, false
+	}
+
+	grounding, err := h.grounder.Ground(c.Request.Context(), campaign, req.CharacterID, req.Input)
+	if errors.Is(err, store.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
+		return nil, nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, nil, false
+	}
+
+	return &req, grounding, true
+}
+
+// CheckConsistency retrieves the characters and lore entries most related to the
+// new content and asks the model whether the new content contradicts them.
+func (h *AIHandler) CheckConsistency(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ConsistencyCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, req.CampaignID, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	result, err := h.checker.Check(c.Request.Context(), req.CampaignID, req.NewContent)
+	if errors.Is(err, services.ErrUnparseableResponse) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+// maxVaultUploadSize caps the size of an uploaded Markdown zip.
+const maxVaultUploadSize = 32 << 20
+
+// maxArchiveImportSize caps the size of an imported JSON archive.
+const maxArchiveImportSize = 32 << 20
+
+type ArchiveHandler struct {
+	store    store.Store
+	archives *services.ArchiveService
+}
+
+func NewArchiveHandler(s store.Store, embedder services.Embedder) *ArchiveHandler {
+	return &ArchiveHandler{store: s, archives: services.NewArchiveService(s, embedder)}
+}
+
+// ExportCampaign downloads the campaign as a versioned JSON archive.
+func (h *ArchiveHandler) ExportCampaign(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	archive, err := h.archives.Export(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s.json"`, campaign.ID))
+	c.JSON(http.StatusOK, archive)
+}
+
+// ExportCampaignMarkdown downloads the campaign as a zip of Markdown notes
+// that can be opened as an Obsidian vault.
+func (h *ArchiveHandler) ExportCampaignMarkdown(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	vault, err := h.archives.ExportMarkdown(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s.zip"`, campaign.ID))
+	c.Data(http.StatusOK, "application/zip", vault)
+}
+
+// ImportCampaign creates a new campaign owned by the caller from an archive.
+func (h *ArchiveHandler) ImportCampaign(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxArchiveImportSize)
+	var archive models.CampaignArchive
+	if err := c.ShouldBindJSON(&archive); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("archive is larger than %d bytes", tooLarge.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign, err := h.archives.Import(c.Request.Context(), userID, &archive)
+	if errors.Is(err, services.ErrInvalidArchive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, campaign)
+}
+
+// ImportCampaignMarkdown creates a new campaign owned by the caller from a zip
+// of Markdown notes, uploaded as the "file" form field.
+func (h *ArchiveHandler) ImportCampaignMarkdown(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVaultUploadSize)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("vault is larger than %d bytes", tooLarge.Limit)})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Named after the zip unless the vault says otherwise
+	title := strings.TrimSuffix(filepath.Base(header.Filename), filepath.Ext(header.Filename))
+
+	campaign, err := h.archives.ImportMarkdown(c.Request.Context(), userID, data, title)
+	if errors.Is(err, services.ErrInvalidArchive) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, campaign)
+}
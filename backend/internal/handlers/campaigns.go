@@ -4,15 +4,18 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minato-wing/lore-keeper/backend/internal/database"
 	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
 	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
 )
 
-type CampaignHandler struct{}
+type CampaignHandler struct {
+	store store.Store
+}
 
-func NewCampaignHandler() *CampaignHandler {
-	return &CampaignHandler{}
+func NewCampaignHandler(s store.Store) *CampaignHandler {
+	return &CampaignHandler{store: s}
 }
 
 func (h *CampaignHandler) GetCampaigns(c *gin.Context) {
@@ -22,17 +25,21 @@ func (h *CampaignHandler) GetCampaigns(c *gin.Context) {
 		return
 	}
 
-	var campaigns []models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("*", "", false).
-		Eq("user_id", userID).
-		ExecuteTo(&campaigns)
-
+	campaigns, err := h.store.ListCampaigns(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	access := services.NewAccessService(h.store)
+	for i := range campaigns {
+		campaigns[i].Role, err = access.Role(c.Request.Context(), &campaigns[i], userID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, campaigns)
 }
 
@@ -44,16 +51,8 @@ func (h *CampaignHandler) GetCampaign(c *gin.Context) {
 		return
 	}
 
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("*", "", false).
-		Eq("id", id).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "campaign not found"})
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
 		return
 	}
 
@@ -73,28 +72,18 @@ func (h *CampaignHandler) CreateCampaign(c *gin.Context) {
 		return
 	}
 
-	campaign := map[string]interface{}{
-		"user_id":     userID,
-		"title":       req.Title,
-		"description": req.Description,
-	}
-
-	var result []models.Campaign
-	_, err := database.Client.From("campaigns").
-		Insert(campaign, false, "", "", "").
-		ExecuteTo(&result)
-
+	campaign, err := h.store.CreateCampaign(c.Request.Context(), &models.Campaign{
+		UserID:      userID,
+		Title:       req.Title,
+		Description: req.Description,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	campaign.Role = models.RoleOwner
 
-	if len(result) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create campaign"})
-		return
-	}
-
-	c.JSON(http.StatusCreated, result[0])
+	c.JSON(http.StatusCreated, campaign)
 }
 
 func (h *CampaignHandler) UpdateCampaign(c *gin.Context) {
@@ -111,31 +100,25 @@ func (h *CampaignHandler) UpdateCampaign(c *gin.Context) {
 		return
 	}
 
-	update := map[string]interface{}{
-		"title":       req.Title,
-		"description": req.Description,
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleEditor)
+	if !ok {
+		return
 	}
 
-	var result []models.Campaign
-	_, err := database.Client.From("campaigns").
-		Update(update, "", "").
-		Eq("id", id).
-		Eq("user_id", userID).
-		ExecuteTo(&result)
+	campaign.Title = req.Title
+	campaign.Description = req.Description
 
+	result, err := h.store.UpdateCampaign(c.Request.Context(), campaign)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	result.Role = campaign.Role
 
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "campaign not found"})
-		return
-	}
-
-	c.JSON(http.StatusOK, result[0])
+	c.JSON(http.StatusOK, result)
 }
 
+// DeleteCampaign moves the campaign to the trash. See TrashHandler.
 func (h *CampaignHandler) DeleteCampaign(c *gin.Context) {
 	id := c.Param("id")
 	userID, exists := utils.GetUserID(c)
@@ -144,13 +127,12 @@ func (h *CampaignHandler) DeleteCampaign(c *gin.Context) {
 		return
 	}
 
-	_, _, err := database.Client.From("campaigns").
-		Delete("", "").
-		Eq("id", id).
-		Eq("user_id", userID).
-		Execute()
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleOwner)
+	if !ok {
+		return
+	}
 
-	if err != nil {
+	if err := services.TrashCampaign(c.Request.Context(), h.store, campaign); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -1,18 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minato-wing/lore-keeper/backend/internal/database"
 	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
 	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
 )
 
-type CharacterHandler struct{}
+type CharacterHandler struct {
+	store    store.Store
+	embedder services.Embedder
+	checker  *services.ConsistencyChecker
+}
 
-func NewCharacterHandler() *CharacterHandler {
-	return &CharacterHandler{}
+func NewCharacterHandler(s store.Store, embedder services.Embedder, checker *services.ConsistencyChecker) *CharacterHandler {
+	return &CharacterHandler{store: s, embedder: embedder, checker: checker}
 }
 
 func (h *CharacterHandler) GetCharacters(c *gin.Context) {
@@ -28,32 +34,18 @@ func (h *CharacterHandler) GetCharacters(c *gin.Context) {
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", campaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	campaign, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleViewer)
+	if !ok {
 		return
 	}
 
-	var characters []models.Character
-	_, err = database.Client.From("characters").
-		Select("*", "", false).
-		Eq("campaign_id", campaignID).
-		ExecuteTo(&characters)
-
+	characters, err := h.store.ListCharacters(c.Request.Context(), campaignID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, characters)
+	c.JSON(http.StatusOK, services.NewVisibility(campaign.Role, characters).Characters(characters))
 }
 
 func (h *CharacterHandler) GetCharacter(c *gin.Context) {
@@ -65,30 +57,20 @@ func (h *CharacterHandler) GetCharacter(c *gin.Context) {
 
 	id := c.Param("id")
 
-	// Get character with campaign info to verify ownership
-	var character models.Character
-	_, err := database.Client.From("characters").
-		Select("*", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&character)
-
+	character, err := h.store.GetCharacter(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", character.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
+	campaign, ok := authorizeCampaign(c, h.store, character.CampaignID, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
 
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	// GM-only characters do not exist as far as players are concerned
+	if !services.NewVisibility(campaign.Role, nil).Character(character) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
 		return
 	}
 
@@ -102,50 +84,45 @@ func (h *CharacterHandler) CreateCharacter(c *gin.Context) {
 		return
 	}
 
+	mode, ok := checkMode(c)
+	if !ok {
+		return
+	}
+
 	var req models.CreateCharacterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", req.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	if _, ok := authorizeCampaign(c, h.store, req.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	character := map[string]interface{}{
-		"campaign_id": req.CampaignID,
-		"name":        req.Name,
-		"role":        req.Role,
-		"attributes":  req.Attributes,
-		"background":  req.Background,
+	character := &models.Character{
+		CampaignID:          req.CampaignID,
+		Name:                req.Name,
+		Role:                req.Role,
+		Attributes:          req.Attributes,
+		Background:          req.Background,
+		Visibility:          req.Visibility,
+		AttributeVisibility: services.SecretAttributes(req.Attributes, req.AttributeVisibility),
 	}
 
-	var result []models.Character
-	_, err = database.Client.From("characters").
-		Insert(character, false, "", "", "").
-		ExecuteTo(&result)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	report, ok := guardWrite(c, h.checker, mode, character.CampaignID, services.CharacterEmbeddingText(character))
+	if !ok {
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create character"})
+	character.Embedding = embed(c.Request.Context(), h.embedder, services.CharacterEmbeddingText(character))
+
+	character, err := h.store.CreateCharacter(c.Request.Context(), character)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, result[0])
+	c.JSON(http.StatusCreated, guardedCharacter{character, report})
 }
 
 func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
@@ -157,6 +134,11 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 
 	id := c.Param("id")
 
+	mode, ok := checkMode(c)
+	if !ok {
+		return
+	}
+
 	var req models.CreateCharacterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -164,58 +146,56 @@ func (h *CharacterHandler) UpdateCharacter(c *gin.Context) {
 	}
 
 	// Get character to verify ownership
-	var character models.Character
-	_, err := database.Client.From("characters").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&character)
-
+	character, err := h.store.GetCharacter(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", character.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if _, ok := authorizeCampaign(c, h.store, character.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	update := map[string]interface{}{
-		"name":       req.Name,
-		"role":       req.Role,
-		"attributes": req.Attributes,
-		"background": req.Background,
+	previousText := services.CharacterEmbeddingText(character)
+	character.Name = req.Name
+	character.Role = req.Role
+	character.Attributes = req.Attributes
+	character.Background = req.Background
+	character.Visibility = services.UpdatedVisibility(character.Visibility, req.Visibility)
+	if req.AttributeVisibility != nil {
+		character.AttributeVisibility = req.AttributeVisibility
 	}
+	character.AttributeVisibility = services.SecretAttributes(character.Attributes, character.AttributeVisibility)
 
-	var result []models.Character
-	_, err = database.Client.From("characters").
-		Update(update, "", "").
-		Eq("id", id).
-		ExecuteTo(&result)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	report, ok := guardWrite(c, h.checker, mode, character.CampaignID, services.CharacterEmbeddingText(character), character.ID)
+	if !ok {
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
+	// Only re-embed when the text the embedding is built from has changed
+	if text := services.CharacterEmbeddingText(character); text != previousText {
+		character.Embedding = embed(c.Request.Context(), h.embedder, text)
+	}
+
+	var result *models.Character
+	err = h.store.WithTx(c.Request.Context(), func(tx store.Store) error {
+		var err error
+		result, err = services.SaveCharacter(c.Request.Context(), tx, character, userID)
+		return err
+	})
+	if errors.Is(err, store.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result[0])
+	c.JSON(http.StatusOK, guardedCharacter{result, report})
 }
 
+// DeleteCharacter moves the character and its relationships to the trash.
 func (h *CharacterHandler) DeleteCharacter(c *gin.Context) {
 	userID, exists := utils.GetUserID(c)
 	if !exists {
@@ -226,38 +206,17 @@ func (h *CharacterHandler) DeleteCharacter(c *gin.Context) {
 	id := c.Param("id")
 
 	// Get character to verify ownership
-	var character models.Character
-	_, err := database.Client.From("characters").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&character)
-
+	character, err := h.store.GetCharacter(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", character.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if _, ok := authorizeCampaign(c, h.store, character.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	_, _, err = database.Client.From("characters").
-		Delete("", "").
-		Eq("id", id).
-		Execute()
-
-	if err != nil {
+	if err := services.TrashCharacter(c.Request.Context(), h.store, character); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+)
+
+// Modes for the ?check= parameter of guarded writes. In warn mode the entity is
+// saved and the warnings are returned with it; in block mode an inconsistent
+// write is rejected with 409 and nothing is saved.
+const (
+	checkWarn  = "warn"
+	checkBlock = "block"
+)
+
+// consistencyReport is added to the saved entity in guarded-write responses.
+type consistencyReport struct {
+	Consistency      *models.ConsistencyCheckResponse `json:"consistency,omitempty"`
+	ConsistencyError string                           `json:"consistency_error,omitempty"`
+}
+
+type guardedCharacter struct {
+	*models.Character
+	*consistencyReport
+}
+
+type guardedLoreEntry struct {
+	*models.LoreEntry
+	*consistencyReport
+}
+
+// checkMode reads ?check=. It writes a 400 response and returns false when the
+// value is not recognised.
+func checkMode(c *gin.Context) (string, bool) {
+	mode := c.Query("check")
+	switch mode {
+	case "", checkWarn, checkBlock:
+		return mode, true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "check must be warn or block"})
+		return "", false
+	}
+}
+
+// guardWrite runs the consistency check for a guarded write. It returns false
+// when the response has been written and the write must not go ahead. A failed
+// check only blocks in block mode; in warn mode the error is reported instead.
+func guardWrite(c *gin.Context, checker *services.ConsistencyChecker, mode, campaignID, content string, exclude ...string) (*consistencyReport, bool) {
+	if mode == "" {
+		return nil, true
+	}
+
+	result, err := checker.Check(c.Request.Context(), campaignID, content, exclude...)
+	if err != nil {
+		if mode == checkWarn {
+			return &consistencyReport{ConsistencyError: err.Error()}, true
+		}
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrUnparseableResponse) {
+			status = http.StatusBadGateway
+		}
+		c.JSON(status, gin.H{"error": "consistency check failed: " + err.Error()})
+		return nil, false
+	}
+
+	if mode == checkBlock && !result.IsConsistent {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":       "content conflicts with existing entries",
+			"consistency": result,
+		})
+		return nil, false
+	}
+
+	return &consistencyReport{Consistency: result}, true
+}
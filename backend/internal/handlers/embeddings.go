@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"context"
+	"log"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+)
+
+// embed computes the embedding for text. A provider failure should not lose the
+// user's write, so errors are logged and the row is saved without an embedding;
+// cmd/backfill-embeddings picks those rows up later.
+func embed(ctx context.Context, embedder services.Embedder, text string) []float32 {
+	if embedder == nil {
+		return nil
+	}
+
+	embedding, err := embedder.Embed(ctx, text)
+	if err != nil {
+		log.Printf("Embedding error: %v", err)
+		return nil
+	}
+
+	return embedding
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type ExtractionHandler struct {
+	store         store.Store
+	relationships *services.RelationshipExtractor
+	sessionNotes  *services.SessionNotesIngester
+}
+
+func NewExtractionHandler(s store.Store, ai *services.AIService, embedder services.Embedder) *ExtractionHandler {
+	return &ExtractionHandler{
+		store:         s,
+		relationships: services.NewRelationshipExtractor(s, ai),
+		sessionNotes:  services.NewSessionNotesIngester(s, ai, embedder),
+	}
+}
+
+// ExtractRelationships proposes relationships between existing characters
+// found in the posted text, or in the campaign itself when no text is given.
+// Nothing is saved; accepted proposals go to the apply endpoint.
+func (h *ExtractionHandler) ExtractRelationships(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// The body is optional
+	var req models.ExtractRelationshipsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, id, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	result, err := h.relationships.Extract(c.Request.Context(), id, req.Text)
+	if errors.Is(err, services.ErrUnparseableResponse) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// IngestSessionNotes extracts new characters and lore, and additions to
+// existing ones, from a session's notes. The changeset is not saved; accepted
+// proposals go to the apply endpoint.
+func (h *ExtractionHandler) IngestSessionNotes(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	var req models.IngestSessionNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, id, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	changeset, err := h.sessionNotes.Ingest(c.Request.Context(), id, req.Notes)
+	if errors.Is(err, services.ErrUnparseableResponse) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, changeset)
+}
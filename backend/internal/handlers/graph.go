@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type GraphHandler struct {
+	store  store.Store
+	graphs *services.GraphService
+}
+
+func NewGraphHandler(s store.Store) *GraphHandler {
+	return &GraphHandler{store: s, graphs: services.NewGraphService(s)}
+}
+
+// ExportGraph returns the relationship map as GraphML, DOT or Cytoscape.js
+// JSON (the default), chosen with ?format=.
+func (h *GraphHandler) ExportGraph(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	format := c.DefaultQuery("format", services.GraphFormatCytoscape)
+	if format != services.GraphFormatGraphML && format != services.GraphFormatDOT && format != services.GraphFormatCytoscape {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be graphml, dot or cytoscape"})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	graph, err := h.graphs.Load(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch format {
+	case services.GraphFormatGraphML:
+		data, err := graph.GraphML()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s.graphml"`, campaign.ID))
+		c.Data(http.StatusOK, "application/graphml+xml; charset=utf-8", data)
+	case services.GraphFormatDOT:
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="campaign-%s.gv"`, campaign.ID))
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", graph.DOT())
+	default:
+		c.JSON(http.StatusOK, graph.Cytoscape())
+	}
+}
+
+// AnalyzeGraph returns centrality, components, isolated characters and
+// communities of the relationship map.
+func (h *GraphHandler) AnalyzeGraph(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	graph, err := h.graphs.Load(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, graph.Analyze())
+}
+
+// GetPath returns the shortest chain of relationships between the characters
+// ?from= and ?to=.
+func (h *GraphHandler) GetPath(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	graph, err := h.graphs.Load(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	path, err := graph.ShortestPath(from, to)
+	if errors.Is(err, services.ErrCharacterNotInGraph) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, path)
+}
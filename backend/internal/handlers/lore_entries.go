@@ -1,18 +1,24 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minato-wing/lore-keeper/backend/internal/database"
 	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
 	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
 )
 
-type LoreEntryHandler struct{}
+type LoreEntryHandler struct {
+	store    store.Store
+	embedder services.Embedder
+	checker  *services.ConsistencyChecker
+}
 
-func NewLoreEntryHandler() *LoreEntryHandler {
-	return &LoreEntryHandler{}
+func NewLoreEntryHandler(s store.Store, embedder services.Embedder, checker *services.ConsistencyChecker) *LoreEntryHandler {
+	return &LoreEntryHandler{store: s, embedder: embedder, checker: checker}
 }
 
 func (h *LoreEntryHandler) GetLoreEntries(c *gin.Context) {
@@ -28,32 +34,18 @@ func (h *LoreEntryHandler) GetLoreEntries(c *gin.Context) {
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", campaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	campaign, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleViewer)
+	if !ok {
 		return
 	}
 
-	var loreEntries []models.LoreEntry
-	_, err = database.Client.From("lore_entries").
-		Select("*", "", false).
-		Eq("campaign_id", campaignID).
-		ExecuteTo(&loreEntries)
-
+	loreEntries, err := h.store.ListLoreEntries(c.Request.Context(), campaignID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, loreEntries)
+	c.JSON(http.StatusOK, services.NewVisibility(campaign.Role, nil).LoreEntries(loreEntries))
 }
 
 func (h *LoreEntryHandler) GetLoreEntry(c *gin.Context) {
@@ -65,29 +57,19 @@ func (h *LoreEntryHandler) GetLoreEntry(c *gin.Context) {
 
 	id := c.Param("id")
 
-	var loreEntry models.LoreEntry
-	_, err := database.Client.From("lore_entries").
-		Select("*", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&loreEntry)
-
+	loreEntry, err := h.store.GetLoreEntry(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", loreEntry.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
+	campaign, ok := authorizeCampaign(c, h.store, loreEntry.CampaignID, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
 
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if !services.NewVisibility(campaign.Role, nil).LoreEntry(loreEntry) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
 		return
 	}
 
@@ -101,49 +83,43 @@ func (h *LoreEntryHandler) CreateLoreEntry(c *gin.Context) {
 		return
 	}
 
+	mode, ok := checkMode(c)
+	if !ok {
+		return
+	}
+
 	var req models.CreateLoreEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", req.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	if _, ok := authorizeCampaign(c, h.store, req.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	loreEntry := map[string]interface{}{
-		"campaign_id": req.CampaignID,
-		"title":       req.Title,
-		"category":    req.Category,
-		"content":     req.Content,
+	loreEntry := &models.LoreEntry{
+		CampaignID: req.CampaignID,
+		Title:      req.Title,
+		Category:   req.Category,
+		Content:    req.Content,
+		Visibility: req.Visibility,
 	}
 
-	var result []models.LoreEntry
-	_, err = database.Client.From("lore_entries").
-		Insert(loreEntry, false, "", "", "").
-		ExecuteTo(&result)
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	report, ok := guardWrite(c, h.checker, mode, loreEntry.CampaignID, services.LoreEntryEmbeddingText(loreEntry))
+	if !ok {
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create lore entry"})
+	loreEntry.Embedding = embed(c.Request.Context(), h.embedder, services.LoreEntryEmbeddingText(loreEntry))
+
+	loreEntry, err := h.store.CreateLoreEntry(c.Request.Context(), loreEntry)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, result[0])
+	c.JSON(http.StatusCreated, guardedLoreEntry{loreEntry, report})
 }
 
 func (h *LoreEntryHandler) UpdateLoreEntry(c *gin.Context) {
@@ -155,6 +131,11 @@ func (h *LoreEntryHandler) UpdateLoreEntry(c *gin.Context) {
 
 	id := c.Param("id")
 
+	mode, ok := checkMode(c)
+	if !ok {
+		return
+	}
+
 	var req models.CreateLoreEntryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -162,57 +143,51 @@ func (h *LoreEntryHandler) UpdateLoreEntry(c *gin.Context) {
 	}
 
 	// Get lore entry to verify ownership
-	var loreEntry models.LoreEntry
-	_, err := database.Client.From("lore_entries").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&loreEntry)
-
+	loreEntry, err := h.store.GetLoreEntry(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", loreEntry.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if _, ok := authorizeCampaign(c, h.store, loreEntry.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	update := map[string]interface{}{
-		"title":    req.Title,
-		"category": req.Category,
-		"content":  req.Content,
+	previousText := services.LoreEntryEmbeddingText(loreEntry)
+	loreEntry.Title = req.Title
+	loreEntry.Category = req.Category
+	loreEntry.Content = req.Content
+	loreEntry.Visibility = services.UpdatedVisibility(loreEntry.Visibility, req.Visibility)
+
+	report, ok := guardWrite(c, h.checker, mode, loreEntry.CampaignID, services.LoreEntryEmbeddingText(loreEntry), loreEntry.ID)
+	if !ok {
+		return
 	}
 
-	var result []models.LoreEntry
-	_, err = database.Client.From("lore_entries").
-		Update(update, "", "").
-		Eq("id", id).
-		ExecuteTo(&result)
+	// Only re-embed when the text the embedding is built from has changed
+	if text := services.LoreEntryEmbeddingText(loreEntry); text != previousText {
+		loreEntry.Embedding = embed(c.Request.Context(), h.embedder, text)
+	}
 
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var result *models.LoreEntry
+	err = h.store.WithTx(c.Request.Context(), func(tx store.Store) error {
+		var err error
+		result, err = services.SaveLoreEntry(c.Request.Context(), tx, loreEntry, userID)
+		return err
+	})
+	if errors.Is(err, store.ErrConflict) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
-
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, result[0])
+	c.JSON(http.StatusOK, guardedLoreEntry{result, report})
 }
 
+// DeleteLoreEntry moves the lore entry to the trash.
 func (h *LoreEntryHandler) DeleteLoreEntry(c *gin.Context) {
 	userID, exists := utils.GetUserID(c)
 	if !exists {
@@ -223,38 +198,17 @@ func (h *LoreEntryHandler) DeleteLoreEntry(c *gin.Context) {
 	id := c.Param("id")
 
 	// Get lore entry to verify ownership
-	var loreEntry models.LoreEntry
-	_, err := database.Client.From("lore_entries").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&loreEntry)
-
+	loreEntry, err := h.store.GetLoreEntry(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", loreEntry.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+	if _, ok := authorizeCampaign(c, h.store, loreEntry.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	_, _, err = database.Client.From("lore_entries").
-		Delete("", "").
-		Eq("id", id).
-		Execute()
-
-	if err != nil {
+	if err := services.TrashLoreEntry(c.Request.Context(), h.store, loreEntry); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type MemberHandler struct {
+	store  store.Store
+	access *services.AccessService
+}
+
+func NewMemberHandler(s store.Store) *MemberHandler {
+	return &MemberHandler{store: s, access: services.NewAccessService(s)}
+}
+
+// writeAccessError maps errors from AccessService to responses.
+func writeAccessError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrAccessDenied), errors.Is(err, services.ErrInvitationEmail):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvitationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *MemberHandler) GetMembers(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	members, err := h.access.Members(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, members)
+}
+
+func (h *MemberHandler) UpdateMember(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.UpdateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	member, err := h.access.UpdateMember(c.Request.Context(), campaign, c.Param("userId"), req.Role)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// DeleteMember removes a member. The owner can remove anyone else, and any
+// member can remove themselves to leave the campaign.
+func (h *MemberHandler) DeleteMember(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	memberID := c.Param("userId")
+	role := models.RoleOwner
+	if memberID == userID {
+		role = models.RoleViewer
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, role)
+	if !ok {
+		return
+	}
+
+	if err := h.access.RemoveMember(c.Request.Context(), campaign, memberID); err != nil {
+		writeAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (h *MemberHandler) GetInvitations(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaignID := c.Param("id")
+
+	if _, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleOwner); !ok {
+		return
+	}
+
+	invitations, err := h.store.ListInvitations(c.Request.Context(), campaignID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *MemberHandler) CreateInvitation(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	invitation, err := h.access.Invite(c.Request.Context(), campaign, userID, req.Email, req.Role)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, invitation)
+}
+
+func (h *MemberHandler) DeleteInvitation(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaignID := c.Param("id")
+
+	if _, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleOwner); !ok {
+		return
+	}
+
+	invitation, err := h.store.GetInvitation(c.Request.Context(), c.Param("invitationId"))
+	if err != nil || invitation.CampaignID != campaignID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "invitation not found"})
+		return
+	}
+
+	if err := h.store.DeleteInvitation(c.Request.Context(), invitation.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetMyInvitations lists the pending invitations addressed to the signed-in
+// user's email.
+func (h *MemberHandler) GetMyInvitations(c *gin.Context) {
+	if _, exists := utils.GetUserID(c); !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	email, ok := utils.GetUserEmail(c)
+	if !ok {
+		c.JSON(http.StatusOK, []models.Invitation{})
+		return
+	}
+
+	invitations, err := h.access.PendingInvitations(c.Request.Context(), email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, invitations)
+}
+
+func (h *MemberHandler) AcceptInvitation(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email, _ := utils.GetUserEmail(c)
+	campaign, err := h.access.Accept(c.Request.Context(), req.Code, userID, email)
+	if err != nil {
+		writeAccessError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
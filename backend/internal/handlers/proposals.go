@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type ProposalHandler struct {
+	store   store.Store
+	applier *services.ProposalApplier
+}
+
+func NewProposalHandler(s store.Store, embedder services.Embedder) *ProposalHandler {
+	return &ProposalHandler{store: s, applier: services.NewProposalApplier(s, embedder)}
+}
+
+// ApplyProposals writes accepted AI proposals to the campaign. Either every
+// proposal is applied or none is.
+func (h *ProposalHandler) ApplyProposals(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	var req models.ApplyProposalsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, id, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	result, err := h.applier.Apply(c.Request.Context(), id, userID, req.Source, req.Proposals)
+	switch {
+	case errors.Is(err, services.ErrInvalidProposal):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// GetProvenance lists the AI-generated content accepted into the campaign,
+// optionally narrowed to one entity with ?entity_id=. Records keep the content
+// as it was accepted, secrets included, so only the GMs may read them.
+func (h *ProposalHandler) GetProvenance(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	if _, ok := authorizeCampaign(c, h.store, id, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	records, err := h.store.ListProvenance(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	provenance := make([]models.Provenance, 0, len(records))
+	entityID := c.Query("entity_id")
+	for _, p := range records {
+		if entityID == "" || p.EntityID == entityID {
+			provenance = append(provenance, p)
+		}
+	}
+
+	c.JSON(http.StatusOK, provenance)
+}
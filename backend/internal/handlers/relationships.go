@@ -1,18 +1,37 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minato-wing/lore-keeper/backend/internal/database"
 	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
 	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
 )
 
-type RelationshipHandler struct{}
+type RelationshipHandler struct {
+	store         store.Store
+	relationships *services.RelationshipService
+}
 
-func NewRelationshipHandler() *RelationshipHandler {
-	return &RelationshipHandler{}
+func NewRelationshipHandler(s store.Store) *RelationshipHandler {
+	return &RelationshipHandler{store: s, relationships: services.NewRelationshipService(s)}
+}
+
+// writeRelationshipError maps errors from RelationshipService to responses.
+func writeRelationshipError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRelationType):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
 func (h *RelationshipHandler) GetRelationships(c *gin.Context) {
@@ -28,31 +47,36 @@ func (h *RelationshipHandler) GetRelationships(c *gin.Context) {
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", campaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
+	campaign, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
 
+	relationships, err := h.store.ListRelationships(c.Request.Context(), campaignID)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	var relationships []models.Relationship
-	_, err = database.Client.From("relationships").
-		Select("*", "", false).
-		Eq("campaign_id", campaignID).
-		ExecuteTo(&relationships)
-
+	characters, err := h.store.ListCharacters(c.Request.Context(), campaignID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	relationships = services.NewVisibility(campaign.Role, characters).Relationships(relationships)
+
+	// Optionally narrow to one character's relationships, whichever side
+	if characterID := c.Query("character_id"); characterID != "" {
+		filtered := []models.Relationship{}
+		for _, r := range relationships {
+			if r.SourceCharacterID == characterID || r.TargetCharacterID == characterID {
+				filtered = append(filtered, r)
+			}
+		}
+		relationships = filtered
+	}
+
 	c.JSON(http.StatusOK, relationships)
 }
 
@@ -69,44 +93,36 @@ func (h *RelationshipHandler) CreateRelationship(c *gin.Context) {
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err := database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", req.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
-
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "campaign not found or access denied"})
+	if _, ok := authorizeCampaign(c, h.store, req.CampaignID, userID, models.RoleEditor); !ok {
 		return
 	}
 
-	relationship := map[string]interface{}{
-		"campaign_id":         req.CampaignID,
-		"source_character_id": req.SourceCharacterID,
-		"target_character_id": req.TargetCharacterID,
-		"relation_type":       req.RelationType,
-		"description":         req.Description,
+	for _, characterID := range []string{req.SourceCharacterID, req.TargetCharacterID} {
+		character, err := h.store.GetCharacter(c.Request.Context(), characterID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
+			return
+		}
+		if character.CampaignID != req.CampaignID {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "characters must belong to the campaign"})
+			return
+		}
 	}
 
-	var result []models.Relationship
-	_, err = database.Client.From("relationships").
-		Insert(relationship, false, "", "", "").
-		ExecuteTo(&result)
-
+	relationship, err := h.relationships.Create(c.Request.Context(), &models.Relationship{
+		CampaignID:        req.CampaignID,
+		SourceCharacterID: req.SourceCharacterID,
+		TargetCharacterID: req.TargetCharacterID,
+		RelationType:      req.RelationType,
+		Description:       req.Description,
+		Visibility:        req.Visibility,
+	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	if len(result) == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create relationship"})
+		writeRelationshipError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, result[0])
+	c.JSON(http.StatusCreated, relationship)
 }
 
 func (h *RelationshipHandler) UpdateRelationship(c *gin.Context) {
@@ -125,98 +141,166 @@ func (h *RelationshipHandler) UpdateRelationship(c *gin.Context) {
 	}
 
 	// Get relationship to verify ownership
-	var relationship models.Relationship
-	_, err := database.Client.From("relationships").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&relationship)
-
+	relationship, err := h.store.GetRelationship(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "relationship not found"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", relationship.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
+	if _, ok := authorizeCampaign(c, h.store, relationship.CampaignID, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	relationship.RelationType = req.RelationType
+	relationship.Description = req.Description
+	relationship.Visibility = services.UpdatedVisibility(relationship.Visibility, req.Visibility)
 
+	result, err := h.relationships.Update(c.Request.Context(), relationship)
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		writeRelationshipError(c, err)
 		return
 	}
 
-	update := map[string]interface{}{
-		"relation_type": req.RelationType,
-		"description":   req.Description,
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RelationshipHandler) DeleteRelationship(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
 
-	var result []models.Relationship
-	_, err = database.Client.From("relationships").
-		Update(update, "", "").
-		Eq("id", id).
-		ExecuteTo(&result)
+	id := c.Param("id")
 
+	// Get relationship to verify ownership
+	relationship, err := h.store.GetRelationship(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(http.StatusNotFound, gin.H{"error": "relationship not found"})
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"error": "relationship not found"})
+	if _, ok := authorizeCampaign(c, h.store, relationship.CampaignID, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	if err := h.relationships.Delete(c.Request.Context(), relationship); err != nil {
+		writeRelationshipError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, result[0])
+	c.JSON(http.StatusNoContent, nil)
 }
 
-func (h *RelationshipHandler) DeleteRelationship(c *gin.Context) {
+func (h *RelationshipHandler) GetRelationTypes(c *gin.Context) {
 	userID, exists := utils.GetUserID(c)
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	id := c.Param("id")
+	campaignID := c.Param("id")
 
-	// Get relationship to verify ownership
-	var relationship models.Relationship
-	_, err := database.Client.From("relationships").
-		Select("campaign_id", "", false).
-		Eq("id", id).
-		Single().
-		ExecuteTo(&relationship)
+	if _, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleViewer); !ok {
+		return
+	}
 
+	relationTypes, err := h.store.ListRelationTypes(c.Request.Context(), campaignID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "relationship not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relationTypes)
+}
+
+func (h *RelationshipHandler) CreateRelationType(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	// Verify campaign belongs to user
-	var campaign models.Campaign
-	_, err = database.Client.From("campaigns").
-		Select("id", "", false).
-		Eq("id", relationship.CampaignID).
-		Eq("user_id", userID).
-		Single().
-		ExecuteTo(&campaign)
+	campaignID := c.Param("id")
+
+	var req models.CreateRelationTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleEditor); !ok {
+		return
+	}
 
+	relationType, err := h.relationships.CreateRelationType(c.Request.Context(), &models.RelationType{
+		CampaignID:  campaignID,
+		Name:        req.Name,
+		InverseName: req.InverseName,
+		Symmetric:   req.Symmetric,
+	})
 	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "access denied"})
+		writeRelationshipError(c, err)
 		return
 	}
 
-	_, _, err = database.Client.From("relationships").
-		Delete("", "").
-		Eq("id", id).
-		Execute()
+	c.JSON(http.StatusCreated, relationType)
+}
+
+func (h *RelationshipHandler) UpdateRelationType(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateRelationTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
+	relationType, err := h.store.GetRelationType(c.Request.Context(), c.Param("typeId"))
+	if err != nil || relationType.CampaignID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "relation type not found"})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, relationType.CampaignID, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	relationType.Name = req.Name
+	relationType.InverseName = req.InverseName
+	relationType.Symmetric = req.Symmetric
+
+	result, err := h.relationships.UpdateRelationType(c.Request.Context(), relationType)
 	if err != nil {
+		writeRelationshipError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+func (h *RelationshipHandler) DeleteRelationType(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	relationType, err := h.store.GetRelationType(c.Request.Context(), c.Param("typeId"))
+	if err != nil || relationType.CampaignID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "relation type not found"})
+		return
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, relationType.CampaignID, userID, models.RoleEditor); !ok {
+		return
+	}
+
+	if err := h.store.DeleteRelationType(c.Request.Context(), relationType.ID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
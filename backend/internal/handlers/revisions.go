@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+// RevisionHandler serves the history of characters and lore entries.
+// Revisions keep every past version, GM-only content included, so only the
+// GMs may read or restore them.
+type RevisionHandler struct {
+	store     store.Store
+	revisions *services.RevisionService
+}
+
+func NewRevisionHandler(s store.Store, embedder services.Embedder) *RevisionHandler {
+	return &RevisionHandler{store: s, revisions: services.NewRevisionService(s, embedder)}
+}
+
+// writeRevisionError maps errors from RevisionService to responses.
+func writeRevisionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrRevisionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// authorizeCharacter loads the character in :id for an editor of its campaign.
+func (h *RevisionHandler) authorizeCharacter(c *gin.Context) (*models.Character, string, bool) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, "", false
+	}
+
+	character, err := h.store.GetCharacter(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
+		return nil, "", false
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, character.CampaignID, userID, models.RoleEditor); !ok {
+		return nil, "", false
+	}
+	return character, userID, true
+}
+
+// authorizeLoreEntry loads the lore entry in :id for an editor of its campaign.
+func (h *RevisionHandler) authorizeLoreEntry(c *gin.Context) (*models.LoreEntry, string, bool) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, "", false
+	}
+
+	loreEntry, err := h.store.GetLoreEntry(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
+		return nil, "", false
+	}
+
+	if _, ok := authorizeCampaign(c, h.store, loreEntry.CampaignID, userID, models.RoleEditor); !ok {
+		return nil, "", false
+	}
+	return loreEntry, userID, true
+}
+
+func (h *RevisionHandler) listRevisions(c *gin.Context, entityType, entityID string) {
+	revisions, err := h.revisions.List(c.Request.Context(), entityType, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, revisions)
+}
+
+// diffRevisions compares the revisions in ?from= and ?to=.
+func (h *RevisionHandler) diffRevisions(c *gin.Context, entityType, entityID string) {
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" || to == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to are required"})
+		return
+	}
+
+	diff, err := h.revisions.Diff(c.Request.Context(), entityType, entityID, from, to)
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+func (h *RevisionHandler) GetCharacterRevisions(c *gin.Context) {
+	character, _, ok := h.authorizeCharacter(c)
+	if !ok {
+		return
+	}
+	h.listRevisions(c, models.EntityCharacter, character.ID)
+}
+
+func (h *RevisionHandler) DiffCharacterRevisions(c *gin.Context) {
+	character, _, ok := h.authorizeCharacter(c)
+	if !ok {
+		return
+	}
+	h.diffRevisions(c, models.EntityCharacter, character.ID)
+}
+
+// RestoreCharacterRevision rolls the character back to :revisionId.
+func (h *RevisionHandler) RestoreCharacterRevision(c *gin.Context) {
+	character, userID, ok := h.authorizeCharacter(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.revisions.RestoreCharacter(c.Request.Context(), character, c.Param("revisionId"), userID)
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restored)
+}
+
+func (h *RevisionHandler) GetLoreEntryRevisions(c *gin.Context) {
+	loreEntry, _, ok := h.authorizeLoreEntry(c)
+	if !ok {
+		return
+	}
+	h.listRevisions(c, models.EntityLoreEntry, loreEntry.ID)
+}
+
+func (h *RevisionHandler) DiffLoreEntryRevisions(c *gin.Context) {
+	loreEntry, _, ok := h.authorizeLoreEntry(c)
+	if !ok {
+		return
+	}
+	h.diffRevisions(c, models.EntityLoreEntry, loreEntry.ID)
+}
+
+// RestoreLoreEntryRevision rolls the lore entry back to :revisionId.
+func (h *RevisionHandler) RestoreLoreEntryRevision(c *gin.Context) {
+	loreEntry, userID, ok := h.authorizeLoreEntry(c)
+	if !ok {
+		return
+	}
+
+	restored, err := h.revisions.RestoreLoreEntry(c.Request.Context(), loreEntry, c.Param("revisionId"), userID)
+	if err != nil {
+		writeRevisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, restored)
+}
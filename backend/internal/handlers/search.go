@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+type SearchHandler struct {
+	store  store.Store
+	search *services.SearchService
+}
+
+func NewSearchHandler(s store.Store, embedder services.Embedder) *SearchHandler {
+	return &SearchHandler{store: s, search: services.NewSearchService(s, embedder)}
+}
+
+func (h *SearchHandler) Search(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q is required"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		limit = n
+	}
+
+	var types []string
+	if raw := c.Query("type"); raw != "" {
+		for _, t := range strings.Split(raw, ",") {
+			t = strings.TrimSpace(t)
+			if t != services.SearchHitCharacter && t != services.SearchHitLoreEntry {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "type must be character or lore_entry"})
+				return
+			}
+			types = append(types, t)
+		}
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, id, userID, models.RoleViewer)
+	if !ok {
+		return
+	}
+
+	visibility := services.NewVisibility(campaign.Role, nil)
+	hits, err := h.search.Search(c.Request.Context(), id, query, types, limit, visibility)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if hits == nil {
+		hits = []services.SearchHit{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"query": query, "results": hits})
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+type ShareLinkHandler struct {
+	store      store.Store
+	shareLinks *services.ShareLinkService
+}
+
+func NewShareLinkHandler(s store.Store, shareLinks *services.ShareLinkService) *ShareLinkHandler {
+	return &ShareLinkHandler{store: s, shareLinks: shareLinks}
+}
+
+func (h *ShareLinkHandler) GetShareLinks(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	links, err := h.shareLinks.List(c.Request.Context(), campaign)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, links)
+}
+
+func (h *ShareLinkHandler) CreateShareLink(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.CreateShareLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleOwner)
+	if !ok {
+		return
+	}
+
+	link, err := h.shareLinks.Create(c.Request.Context(), campaign, userID, &req)
+	switch {
+	case errors.Is(err, services.ErrInvalidShareLink):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, link)
+}
+
+// DeleteShareLink revokes a link. Anyone still holding its token loses
+// access immediately.
+func (h *ShareLinkHandler) DeleteShareLink(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaignID := c.Param("id")
+
+	if _, ok := authorizeCampaign(c, h.store, campaignID, userID, models.RoleOwner); !ok {
+		return
+	}
+
+	link, err := h.store.GetShareLink(c.Request.Context(), c.Param("linkId"))
+	if err != nil || link.CampaignID != campaignID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "share link not found"})
+		return
+	}
+
+	if err := h.store.DeleteShareLink(c.Request.Context(), link.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// resolveShareLink looks up the link in the :token parameter, writing the
+// error response if it does not work. The shared endpoints are public, so
+// this is their only check.
+func (h *ShareLinkHandler) resolveShareLink(c *gin.Context) (*models.ShareLink, bool) {
+	link, err := h.shareLinks.Resolve(c.Request.Context(), c.Param("token"))
+	switch {
+	case err == nil:
+		return link, true
+	case errors.Is(err, services.ErrShareLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return nil, false
+}
+
+func (h *ShareLinkHandler) GetSharedCampaign(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	campaign, err := h.shareLinks.Campaign(c.Request.Context(), link)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+func (h *ShareLinkHandler) GetSharedCharacters(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	characters, err := h.shareLinks.Characters(c.Request.Context(), link)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, characters)
+}
+
+func (h *ShareLinkHandler) GetSharedCharacter(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	character, err := h.shareLinks.Character(c.Request.Context(), link, c.Param("characterId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "character not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, character)
+}
+
+func (h *ShareLinkHandler) GetSharedRelationships(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	relationships, err := h.shareLinks.Relationships(c.Request.Context(), link)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, relationships)
+}
+
+func (h *ShareLinkHandler) GetSharedLoreEntries(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	loreEntries, err := h.shareLinks.LoreEntries(c.Request.Context(), link)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, loreEntries)
+}
+
+func (h *ShareLinkHandler) GetSharedLoreEntry(c *gin.Context) {
+	link, ok := h.resolveShareLink(c)
+	if !ok {
+		return
+	}
+
+	loreEntry, err := h.shareLinks.LoreEntry(c.Request.Context(), link, c.Param("entryId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "lore entry not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, loreEntry)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/services"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+	"github.com/minato-wing/lore-keeper/backend/pkg/utils"
+)
+
+// TrashHandler serves a campaign's trash to its editors, and the trashed
+// campaigns to their owners.
+type TrashHandler struct {
+	store store.Store
+	trash *services.TrashService
+}
+
+func NewTrashHandler(s store.Store, trash *services.TrashService) *TrashHandler {
+	return &TrashHandler{store: s, trash: trash}
+}
+
+// trashEntityTypes maps the :type of trash routes to entity types.
+var trashEntityTypes = map[string]string{
+	"characters":    models.EntityCharacter,
+	"relationships": models.EntityRelationship,
+	"lore-entries":  models.EntityLoreEntry,
+}
+
+// writeTrashError maps errors from TrashService to responses.
+func writeTrashError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNotInTrash):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, store.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// authorizeTrash checks that the user is an editor of the campaign in :id.
+func (h *TrashHandler) authorizeTrash(c *gin.Context) (string, bool) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return "", false
+	}
+
+	campaign, ok := authorizeCampaign(c, h.store, c.Param("id"), userID, models.RoleEditor)
+	if !ok {
+		return "", false
+	}
+	return campaign.ID, true
+}
+
+// trashItem reads the :type and :itemId of a trashed row.
+func trashItem(c *gin.Context) (string, string, bool) {
+	entityType, ok := trashEntityTypes[c.Param("type")]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown trash type"})
+		return "", "", false
+	}
+	return entityType, c.Param("itemId"), true
+}
+
+func (h *TrashHandler) GetTrash(c *gin.Context) {
+	campaignID, ok := h.authorizeTrash(c)
+	if !ok {
+		return
+	}
+
+	trash, err := h.trash.List(c.Request.Context(), campaignID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trash)
+}
+
+func (h *TrashHandler) RestoreTrashItem(c *gin.Context) {
+	campaignID, ok := h.authorizeTrash(c)
+	if !ok {
+		return
+	}
+	entityType, id, ok := trashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trash.Restore(c.Request.Context(), campaignID, entityType, id); err != nil {
+		writeTrashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// PurgeTrashItem deletes a trashed row for good.
+func (h *TrashHandler) PurgeTrashItem(c *gin.Context) {
+	campaignID, ok := h.authorizeTrash(c)
+	if !ok {
+		return
+	}
+	entityType, id, ok := trashItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trash.Purge(c.Request.Context(), campaignID, entityType, id); err != nil {
+		writeTrashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// EmptyTrash deletes everything in the campaign's trash for good.
+func (h *TrashHandler) EmptyTrash(c *gin.Context) {
+	campaignID, ok := h.authorizeTrash(c)
+	if !ok {
+		return
+	}
+
+	if err := h.trash.Empty(c.Request.Context(), campaignID); err != nil {
+		writeTrashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+// GetTrashedCampaigns lists the user's own trashed campaigns.
+func (h *TrashHandler) GetTrashedCampaigns(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaigns, err := h.trash.ListCampaigns(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, campaigns)
+}
+
+func (h *TrashHandler) RestoreCampaign(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	campaign, err := h.trash.RestoreCampaign(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		writeTrashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, campaign)
+}
+
+// PurgeCampaign deletes a trashed campaign and everything in it for good.
+func (h *TrashHandler) PurgeCampaign(c *gin.Context) {
+	userID, exists := utils.GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := h.trash.PurgeCampaign(c.Request.Context(), userID, c.Param("id")); err != nil {
+		writeTrashError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
@@ -1,15 +1,94 @@
 package middleware
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/minato-wing/lore-keeper/backend/internal/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/supabase-community/supabase-go"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// Identity is the user a token was issued to. Email is empty when the token
+// does not carry one.
+type Identity struct {
+	UserID string
+	Email  string
+}
+
+// Authenticator resolves a bearer token to the user it was issued to.
+type Authenticator interface {
+	Authenticate(token string) (*Identity, error)
+}
+
+// SupabaseAuthenticator asks Supabase Auth who the token belongs to.
+type SupabaseAuthenticator struct {
+	client *supabase.Client
+}
+
+func NewSupabaseAuthenticator(client *supabase.Client) *SupabaseAuthenticator {
+	return &SupabaseAuthenticator{client: client}
+}
+
+func (a *SupabaseAuthenticator) Authenticate(token string) (*Identity, error) {
+	// Use WithToken to authenticate the request
+	user, err := a.client.Auth.WithToken(token).GetUser()
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{UserID: user.ID.String(), Email: user.Email}, nil
+}
+
+// JWTAuthenticator verifies HS256 tokens locally with a shared secret, so a
+// self-hosted deployment does not need to reach Supabase Auth. The user ID is
+// taken from the "sub" claim and the email from "email", which is what
+// Supabase/GoTrue tokens carry too.
+type JWTAuthenticator struct {
+	secret []byte
+}
+
+func NewJWTAuthenticator(secret string) *JWTAuthenticator {
+	return &JWTAuthenticator{secret: []byte(secret)}
+}
+
+func (a *JWTAuthenticator) Authenticate(token string) (*Identity, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return a.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, err
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil {
+		return nil, err
+	}
+	if subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	email, _ := claims["email"].(string)
+	return &Identity{UserID: subject, Email: email}, nil
+}
+
+// LocalAuthenticator is the offline single-user mode: every request is treated
+// as coming from UserID and no token is required.
+type LocalAuthenticator struct {
+	UserID string
+	Email  string
+}
+
+func NewLocalAuthenticator(userID, email string) *LocalAuthenticator {
+	return &LocalAuthenticator{UserID: userID, Email: email}
+}
+
+func (a *LocalAuthenticator) Authenticate(token string) (*Identity, error) {
+	return &Identity{UserID: a.UserID, Email: a.Email}, nil
+}
+
+func AuthMiddleware(auth Authenticator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Skip authentication for OPTIONS requests (CORS preflight)
 		if c.Request.Method == "OPTIONS" {
@@ -17,6 +96,13 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Local mode bypasses the Authorization header entirely
+		if local, ok := auth.(*LocalAuthenticator); ok {
+			setIdentity(c, &Identity{UserID: local.UserID, Email: local.Email})
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "missing authorization header"})
@@ -31,9 +117,7 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Use WithToken to authenticate the request
-		authClient := database.Client.Auth.WithToken(token)
-		user, err := authClient.GetUser()
+		identity, err := auth.Authenticate(token)
 		if err != nil {
 			log.Printf("Auth error: %v", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
@@ -41,8 +125,15 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		log.Printf("Authenticated user: %s", user.ID.String())
-		c.Set("user_id", user.ID.String())
+		log.Printf("Authenticated user: %s", identity.UserID)
+		setIdentity(c, identity)
 		c.Next()
 	}
 }
+
+func setIdentity(c *gin.Context, identity *Identity) {
+	c.Set("user_id", identity.UserID)
+	if identity.Email != "" {
+		c.Set("user_email", identity.Email)
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Campaign struct {
 	ID          string    `json:"id"`
@@ -9,8 +12,80 @@ type Campaign struct {
 	Description string    `json:"description,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// DeletedAt is set while the campaign is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Role is the requesting user's role in the campaign. It is not stored.
+	Role string `json:"role,omitempty"`
+}
+
+// Campaign roles, from most to least privileged. The owner is the campaign's
+// user; everyone else the campaign is shared with is a member with one of the
+// other roles.
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RolePlayer = "player"
+	RoleViewer = "viewer"
+)
+
+type CampaignMember struct {
+	CampaignID string    `json:"campaign_id"`
+	UserID     string    `json:"user_id"`
+	Email      string    `json:"email,omitempty"`
+	Role       string    `json:"role"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
+// Invitation lets someone join a campaign with Role by entering Code. One
+// with an Email can only be accepted by the user signed in with that address.
+// An invitation is used up when accepted.
+type Invitation struct {
+	ID         string    `json:"id"`
+	CampaignID string    `json:"campaign_id"`
+	Email      string    `json:"email,omitempty"`
+	Role       string    `json:"role"`
+	Code       string    `json:"code"`
+	CreatedBy  string    `json:"created_by"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Filled in for invitees, who cannot read the campaign yet
+	CampaignTitle string `json:"campaign_title,omitempty"`
+}
+
+// ShareLink gives anyone holding its token read-only access to a campaign's
+// public characters, relationships and lore entries, without an account.
+// Categories, when set, limit the lore entries to those categories. A link
+// stops working when it expires or is deleted.
+type ShareLink struct {
+	ID         string     `json:"id"`
+	CampaignID string     `json:"campaign_id"`
+	Label      string     `json:"label,omitempty"`
+	Categories []string   `json:"categories"`
+	CreatedBy  string     `json:"created_by"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	// Token is the random secret put in the link.
+	Token string `json:"token,omitempty"`
+}
+
+// SharedCampaign is what a share link shows of its campaign.
+type SharedCampaign struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	Categories  []string   `json:"categories"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// Visibility levels of characters, relationships, lore entries and character
+// attributes. GM content is only shown to the owner and editors; players and
+// viewers only see public content. An empty visibility is public.
+const (
+	VisibilityPublic = "public"
+	VisibilityGM     = "gm"
+)
+
 type Character struct {
 	ID         string                 `json:"id"`
 	CampaignID string                 `json:"campaign_id"`
@@ -18,30 +93,238 @@ type Character struct {
 	Role       string                 `json:"role"`
 	Attributes map[string]interface{} `json:"attributes"`
 	Background string                 `json:"background,omitempty"`
-	Embedding  []float32              `json:"-"`
-	CreatedAt  time.Time              `json:"created_at"`
-	UpdatedAt  time.Time              `json:"updated_at"`
+	Visibility string                 `json:"visibility"`
+	// AttributeVisibility sets the visibility of individual attribute keys.
+	// Keys that are not listed are public.
+	AttributeVisibility map[string]string `json:"attribute_visibility,omitempty"`
+	Embedding           []float32         `json:"-"`
+	CreatedAt           time.Time         `json:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at"`
+	DeletedAt           *time.Time        `json:"deleted_at,omitempty"`
 }
 
 type Relationship struct {
-	ID                string    `json:"id"`
-	CampaignID        string    `json:"campaign_id"`
-	SourceCharacterID string    `json:"source_character_id"`
-	TargetCharacterID string    `json:"target_character_id"`
-	RelationType      string    `json:"relation_type"`
-	Description       string    `json:"description,omitempty"`
-	CreatedAt         time.Time `json:"created_at"`
+	ID                string     `json:"id"`
+	CampaignID        string     `json:"campaign_id"`
+	SourceCharacterID string     `json:"source_character_id"`
+	TargetCharacterID string     `json:"target_character_id"`
+	RelationType      string     `json:"relation_type"`
+	Description       string     `json:"description,omitempty"`
+	Visibility        string     `json:"visibility"`
+	CreatedAt         time.Time  `json:"created_at"`
+	DeletedAt         *time.Time `json:"deleted_at,omitempty"`
+}
+
+// RelationType registers how a relation type pairs up: a symmetric type
+// ("sibling") holds in both directions, and one with an inverse ("mentor" and
+// "apprentice") holds as the inverse in the other direction.
+type RelationType struct {
+	ID          string    `json:"id"`
+	CampaignID  string    `json:"campaign_id"`
+	Name        string    `json:"name"`
+	InverseName string    `json:"inverse_name,omitempty"`
+	Symmetric   bool      `json:"symmetric"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type LoreEntry struct {
+	ID         string     `json:"id"`
+	CampaignID string     `json:"campaign_id"`
+	Title      string     `json:"title"`
+	Category   string     `json:"category,omitempty"`
+	Content    string     `json:"content"`
+	Visibility string     `json:"visibility"`
+	Embedding  []float32  `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Trash holds a campaign's trashed characters, relationships and lore
+// entries. Deleting one moves it here; it can be restored until it is purged,
+// by hand or RetentionDays after it was deleted.
+type Trash struct {
+	Characters    []Character    `json:"characters"`
+	Relationships []Relationship `json:"relationships"`
+	LoreEntries   []LoreEntry    `json:"lore_entries"`
+	// RetentionDays is 0 when trashed content is never purged automatically
+	RetentionDays int `json:"retention_days"`
+}
+
+const (
+	ProposalBackground      = "background"
+	ProposalAttribute       = "attribute"
+	ProposalRelationship    = "relationship"
+	ProposalLoreEntry       = "lore_entry"
+	ProposalCharacter       = "character"
+	ProposalLoreEntryUpdate = "lore_entry_update"
+)
+
+// Proposal is an AI-suggested change that is only written once a user accepts
+// it. Which fields are set depends on Kind: background appends Background to
+// the character, attribute sets AttributeKey, lore_entry_update appends
+// LoreEntry.Content to the entry LoreEntryID, and relationship, lore_entry and
+// character create the attached record.
+type Proposal struct {
+	ID             string                `json:"id"`
+	Kind           string                `json:"kind" binding:"required"`
+	Summary        string                `json:"summary"`
+	CharacterID    string                `json:"character_id,omitempty"`
+	LoreEntryID    string                `json:"lore_entry_id,omitempty"`
+	Background     string                `json:"background,omitempty"`
+	AttributeKey   string                `json:"attribute_key,omitempty"`
+	AttributeValue interface{}           `json:"attribute_value,omitempty"`
+	Character      *ProposedCharacter    `json:"character,omitempty"`
+	Relationship   *ProposedRelationship `json:"relationship,omitempty"`
+	LoreEntry      *ProposedLoreEntry    `json:"lore_entry,omitempty"`
+	// Evidence quotes the text the proposal was extracted from, if any
+	Evidence string `json:"evidence,omitempty"`
+	// MatchedBy tells how an update found its existing record: "name" or
+	// "embedding"
+	MatchedBy string `json:"matched_by,omitempty"`
+}
+
+// ProposedCharacter, ProposedRelationship and ProposedLoreEntry are the
+// records a proposal creates. Their Visibility defaults to gm, so AI-written
+// content stays hidden from players until a GM publishes it.
+type ProposedCharacter struct {
+	Name       string                 `json:"name"`
+	Role       string                 `json:"role,omitempty"`
+	Background string                 `json:"background,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Visibility string                 `json:"visibility,omitempty"`
+}
+
+type ProposedRelationship struct {
+	SourceCharacterID string `json:"source_character_id"`
+	TargetCharacterID string `json:"target_character_id"`
+	RelationType      string `json:"relation_type"`
+	Description       string `json:"description,omitempty"`
+	Visibility        string `json:"visibility,omitempty"`
+}
+
+type ProposedLoreEntry struct {
+	Title      string `json:"title"`
+	Category   string `json:"category,omitempty"`
+	Content    string `json:"content"`
+	Visibility string `json:"visibility,omitempty"`
+}
+
+type ApplyProposalsRequest struct {
+	Source    string     `json:"source"`
+	Proposals []Proposal `json:"proposals" binding:"required,min=1,dive"`
+}
+
+type ApplyProposalsResponse struct {
+	Characters    []Character    `json:"characters"`
+	Relationships []Relationship `json:"relationships"`
+	LoreEntries   []LoreEntry    `json:"lore_entries"`
+	Provenance    []Provenance   `json:"provenance"`
+}
+
+// Entity types, as used in provenance records and warnings.
+const (
+	EntityCharacter    = "character"
+	EntityRelationship = "relationship"
+	EntityLoreEntry    = "lore_entry"
+)
+
+// Provenance records that AI-generated content was accepted into a campaign.
+// Field is "background", "attributes.<key>", "content" for text appended to a
+// lore entry, or "record" when the whole row was created from a proposal.
+type Provenance struct {
 	ID         string    `json:"id"`
 	CampaignID string    `json:"campaign_id"`
-	Title      string    `json:"title"`
-	Category   string    `json:"category,omitempty"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	Field      string    `json:"field"`
 	Content    string    `json:"content"`
-	Embedding  []float32 `json:"-"`
+	Source     string    `json:"source"`
+	ProposalID string    `json:"proposal_id,omitempty"`
+	CreatedBy  string    `json:"created_by,omitempty"`
 	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Revision is a saved version of a character or lore entry. Every update that
+// changes one records the state it wrote, so a bad edit can be compared with
+// earlier versions and rolled back. The first recorded change also keeps the
+// state before it. Number counts an entity's revisions from 1.
+type Revision struct {
+	ID         string          `json:"id"`
+	CampaignID string          `json:"campaign_id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id"`
+	Number     int             `json:"number"`
+	Summary    string          `json:"summary"`
+	Snapshot   json.RawMessage `json:"snapshot"`
+	CreatedBy  string          `json:"created_by,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// RevisionDiff is a line diff between two revisions of an entity, in unified
+// diff format.
+type RevisionDiff struct {
+	From Revision `json:"from"`
+	To   Revision `json:"to"`
+	Diff string   `json:"diff"`
+}
+
+// CampaignArchiveVersion is the archive format written by export. Import
+// rejects any other version.
+const CampaignArchiveVersion = 1
+
+// CampaignArchive is a portable copy of a campaign. IDs are the ones the
+// records had when exported; they only link records within the archive and
+// are replaced on import.
+type CampaignArchive struct {
+	Version       int                    `json:"version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Campaign      ArchivedCampaign       `json:"campaign"`
+	Characters    []ArchivedCharacter    `json:"characters"`
+	Relationships []ArchivedRelationship `json:"relationships"`
+	LoreEntries   []ArchivedLoreEntry    `json:"lore_entries"`
+	RelationTypes []ArchivedRelationType `json:"relation_types,omitempty"`
+}
+
+type ArchivedCampaign struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+}
+
+type ArchivedCharacter struct {
+	ID         string                 `json:"id"`
+	Name       string                 `json:"name"`
+	Role       string                 `json:"role"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+	Background string                 `json:"background,omitempty"`
+	Visibility string                 `json:"visibility,omitempty"`
+	// AttributeVisibility is omitted when every attribute is public
+	AttributeVisibility map[string]string `json:"attribute_visibility,omitempty"`
+}
+
+type ArchivedRelationship struct {
+	ID                string `json:"id"`
+	SourceCharacterID string `json:"source_character_id"`
+	TargetCharacterID string `json:"target_character_id"`
+	RelationType      string `json:"relation_type"`
+	Description       string `json:"description,omitempty"`
+	Visibility        string `json:"visibility,omitempty"`
+}
+
+type ArchivedRelationType struct {
+	Name        string `json:"name"`
+	InverseName string `json:"inverse_name,omitempty"`
+	Symmetric   bool   `json:"symmetric,omitempty"`
+}
+
+type ArchivedLoreEntry struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Category   string `json:"category,omitempty"`
+	Content    string `json:"content"`
+	Visibility string `json:"visibility,omitempty"`
 }
 
 type CreateCampaignRequest struct {
@@ -55,6 +338,9 @@ type CreateCharacterRequest struct {
 	Role       string                 `json:"role"`
 	Attributes map[string]interface{} `json:"attributes"`
 	Background string                 `json:"background"`
+	Visibility string                 `json:"visibility" binding:"omitempty,oneof=public gm"`
+	// AttributeVisibility maps attribute keys to their visibility
+	AttributeVisibility map[string]string `json:"attribute_visibility" binding:"omitempty,dive,oneof=public gm"`
 }
 
 type CreateRelationshipRequest struct {
@@ -63,6 +349,32 @@ type CreateRelationshipRequest struct {
 	TargetCharacterID string `json:"target_character_id" binding:"required"`
 	RelationType      string `json:"relation_type" binding:"required"`
 	Description       string `json:"description"`
+	Visibility        string `json:"visibility" binding:"omitempty,oneof=public gm"`
+}
+
+type UpdateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+type CreateInvitationRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+type AcceptInvitationRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type CreateShareLinkRequest struct {
+	Label      string     `json:"label"`
+	Categories []string   `json:"categories"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+type CreateRelationTypeRequest struct {
+	Name        string `json:"name" binding:"required"`
+	InverseName string `json:"inverse_name"`
+	Symmetric   bool   `json:"symmetric"`
 }
 
 type CreateLoreEntryRequest struct {
@@ -70,15 +382,137 @@ type CreateLoreEntryRequest struct {
 	Title      string `json:"title" binding:"required"`
 	Category   string `json:"category"`
 	Content    string `json:"content" binding:"required"`
+	Visibility string `json:"visibility" binding:"omitempty,oneof=public gm"`
 }
 
+// DeepDiveRequest needs Input, CharacterID or both. With CharacterID the
+// suggestions expand that existing character.
 type DeepDiveRequest struct {
-	CampaignID string                 `json:"campaign_id" binding:"required"`
-	Input      map[string]interface{} `json:"input" binding:"required"`
+	CampaignID  string                 `json:"campaign_id" binding:"required"`
+	CharacterID string                 `json:"character_id"`
+	Input       map[string]interface{} `json:"input"`
 }
 
+// DeepDiveResponse lists every suggestion as text. Those that map onto a
+// concrete change also appear in Proposals, which can be passed to the apply
+// endpoint as they are.
 type DeepDiveResponse struct {
-	Suggestions []string `json:"suggestions"`
+	Suggestions []string   `json:"suggestions"`
+	Proposals   []Proposal `json:"proposals"`
+}
+
+// ExtractRelationshipsRequest reads relationships from Text, or from the
+// campaign's character backgrounds and lore entries when Text is empty.
+type ExtractRelationshipsRequest struct {
+	Text string `json:"text"`
+}
+
+// ExtractRelationshipsResponse holds the relationships whose characters were
+// found in the campaign as proposals for the apply endpoint, and those naming
+// a character that could not be matched as Unresolved.
+type ExtractRelationshipsResponse struct {
+	Proposals  []Proposal               `json:"proposals"`
+	Unresolved []UnresolvedRelationship `json:"unresolved"`
+}
+
+type UnresolvedRelationship struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	RelationType string `json:"relation_type"`
+	Description  string `json:"description,omitempty"`
+	Evidence     string `json:"evidence,omitempty"`
+}
+
+type IngestSessionNotesRequest struct {
+	Notes string `json:"notes" binding:"required"`
+}
+
+// SessionNotesChangeset is the reviewable result of ingesting session notes:
+// creates for new characters and lore entries and updates for known ones, all
+// as proposals for the apply endpoint.
+type SessionNotesChangeset struct {
+	Proposals []Proposal `json:"proposals"`
+}
+
+// CytoscapeGraph is the relationship map in Cytoscape.js elements JSON.
+type CytoscapeGraph struct {
+	Elements CytoscapeElements `json:"elements"`
+}
+
+type CytoscapeElements struct {
+	Nodes []CytoscapeNode `json:"nodes"`
+	Edges []CytoscapeEdge `json:"edges"`
+}
+
+type CytoscapeNode struct {
+	Data CytoscapeNodeData `json:"data"`
+}
+
+type CytoscapeNodeData struct {
+	ID         string                 `json:"id"`
+	Label      string                 `json:"label"`
+	Role       string                 `json:"role"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+type CytoscapeEdge struct {
+	Data CytoscapeEdgeData `json:"data"`
+}
+
+type CytoscapeEdgeData struct {
+	ID           string `json:"id"`
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	Label        string `json:"label"`
+	RelationType string `json:"relation_type"`
+	Description  string `json:"description,omitempty"`
+}
+
+type GraphCharacter struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// CharacterCentrality measures how connected a character is. Degree counts
+// distinct characters related in either direction; Betweenness is the share
+// of shortest paths between other characters that pass through this one.
+type CharacterCentrality struct {
+	CharacterID      string  `json:"character_id"`
+	Name             string  `json:"name"`
+	Degree           int     `json:"degree"`
+	InDegree         int     `json:"in_degree"`
+	OutDegree        int     `json:"out_degree"`
+	DegreeCentrality float64 `json:"degree_centrality"`
+	Betweenness      float64 `json:"betweenness"`
+}
+
+type GraphGroup struct {
+	Size       int              `json:"size"`
+	Characters []GraphCharacter `json:"characters"`
+}
+
+// GraphAnalysis is the result of analysing a campaign's relationship map.
+// Centrality is ordered from the most to the least central character,
+// components and communities from the largest.
+type GraphAnalysis struct {
+	CharacterCount    int                   `json:"character_count"`
+	RelationshipCount int                   `json:"relationship_count"`
+	Centrality        []CharacterCentrality `json:"centrality"`
+	Components        []GraphGroup          `json:"components"`
+	Isolated          []GraphCharacter      `json:"isolated"`
+	Communities       []GraphGroup          `json:"communities"`
+	Modularity        float64               `json:"modularity"`
+}
+
+// GraphPath is the shortest chain of relationships between two characters.
+// Relationships[i] connects Characters[i] and Characters[i+1], in either
+// direction.
+type GraphPath struct {
+	Found         bool             `json:"found"`
+	Length        int              `json:"length"`
+	Characters    []GraphCharacter `json:"characters"`
+	Relationships []Relationship   `json:"relationships"`
 }
 
 type ConsistencyCheckRequest struct {
@@ -87,6 +521,35 @@ type ConsistencyCheckRequest struct {
 }
 
 type ConsistencyCheckResponse struct {
-	IsConsistent bool     `json:"is_consistent"`
-	Warnings     []string `json:"warnings,omitempty"`
+	IsConsistent bool                 `json:"is_consistent"`
+	Warnings     []ConsistencyWarning `json:"warnings"`
+	Sources      []ConsistencySource  `json:"sources"`
+}
+
+// ConsistencySource is an existing character or lore entry the check was run against.
+type ConsistencySource struct {
+	Type  string  `json:"type"`
+	ID    string  `json:"id"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+}
+
+const (
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+// ConsistencyWarning is one contradiction found by the consistency check. The
+// entity fields are only set when the model cited an entry that exists in the
+// campaign.
+type ConsistencyWarning struct {
+	Severity            string `json:"severity"`
+	Message             string `json:"message"`
+	EntityType          string `json:"entity_type,omitempty"`
+	EntityID            string `json:"entity_id,omitempty"`
+	EntityTitle         string `json:"entity_title,omitempty"`
+	ExistingExcerpt     string `json:"existing_excerpt,omitempty"`
+	NewExcerpt          string `json:"new_excerpt,omitempty"`
+	SuggestedResolution string `json:"suggested_resolution,omitempty"`
 }
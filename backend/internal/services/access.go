@@ -0,0 +1,224 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var (
+	ErrAccessDenied       = errors.New("access denied")
+	ErrInvalidRole        = errors.New("role must be editor, player or viewer")
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrInvitationEmail    = errors.New("invitation is for another email address")
+)
+
+// InvitationTTL is how long an invitation can be accepted for.
+const InvitationTTL = 7 * 24 * time.Hour
+
+// invitationCodeLength keeps codes short enough to read out at the table
+// while leaving 60 bits of randomness.
+const invitationCodeLength = 12
+
+// roleRank orders the campaign roles: each role may do everything the roles
+// below it may.
+var roleRank = map[string]int{
+	models.RoleViewer: 1,
+	models.RolePlayer: 2,
+	models.RoleEditor: 3,
+	models.RoleOwner:  4,
+}
+
+// RoleAtLeast reports whether role grants what minimum does.
+func RoleAtLeast(role, minimum string) bool {
+	return roleRank[role] > 0 && roleRank[role] >= roleRank[minimum]
+}
+
+// validMemberRole is a role a campaign can be shared with. There is one owner,
+// the campaign's user, so owner is not among them.
+func validMemberRole(role string) bool {
+	return role == models.RoleEditor || role == models.RolePlayer || role == models.RoleViewer
+}
+
+// AccessService decides who may do what in a campaign and manages who it is
+// shared with.
+type AccessService struct {
+	store store.Store
+}
+
+func NewAccessService(s store.Store) *AccessService {
+	return &AccessService{store: s}
+}
+
+// Role returns userID's role in campaign, or "" if it is not shared with them.
+func (a *AccessService) Role(ctx context.Context, campaign *models.Campaign, userID string) (string, error) {
+	if campaign.UserID == userID {
+		return models.RoleOwner, nil
+	}
+	member, err := a.store.GetCampaignMember(ctx, campaign.ID, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return member.Role, nil
+}
+
+// Authorize loads a campaign and checks that userID has at least minimum in it.
+// The campaign is returned with Role set to the user's role. A campaign that
+// does not exist or is not shared with the user is store.ErrNotFound, and one
+// where the role falls short is ErrAccessDenied.
+func (a *AccessService) Authorize(ctx context.Context, campaignID, userID, minimum string) (*models.Campaign, error) {
+	campaign, err := a.store.GetCampaign(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := a.Role(ctx, campaign, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role == "" {
+		return nil, store.ErrNotFound
+	}
+	if !RoleAtLeast(role, minimum) {
+		return nil, fmt.Errorf("%w: requires the %s role", ErrAccessDenied, minimum)
+	}
+	campaign.Role = role
+	return campaign, nil
+}
+
+// Members lists everyone a campaign is shared with, starting with the owner.
+func (a *AccessService) Members(ctx context.Context, campaign *models.Campaign) ([]models.CampaignMember, error) {
+	members, err := a.store.ListCampaignMembers(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	owner := models.CampaignMember{
+		CampaignID: campaign.ID,
+		UserID:     campaign.UserID,
+		Role:       models.RoleOwner,
+		CreatedAt:  campaign.CreatedAt,
+		UpdatedAt:  campaign.CreatedAt,
+	}
+	return append([]models.CampaignMember{owner}, members...), nil
+}
+
+// UpdateMember changes a member's role. The owner's role cannot be changed.
+func (a *AccessService) UpdateMember(ctx context.Context, campaign *models.Campaign, userID, role string) (*models.CampaignMember, error) {
+	if !validMemberRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if userID == campaign.UserID {
+		return nil, fmt.Errorf("%w: the owner's role cannot be changed", ErrAccessDenied)
+	}
+	return a.store.UpdateCampaignMember(ctx, &models.CampaignMember{CampaignID: campaign.ID, UserID: userID, Role: role})
+}
+
+// RemoveMember stops sharing a campaign with userID. The owner cannot be
+// removed.
+func (a *AccessService) RemoveMember(ctx context.Context, campaign *models.Campaign, userID string) error {
+	if userID == campaign.UserID {
+		return fmt.Errorf("%w: the owner cannot be removed", ErrAccessDenied)
+	}
+	if _, err := a.store.GetCampaignMember(ctx, campaign.ID, userID); err != nil {
+		return err
+	}
+	return a.store.DeleteCampaignMember(ctx, campaign.ID, userID)
+}
+
+// Invite creates an invitation to join campaign with role. With an email, only
+// the user signed in with that address can accept it.
+func (a *AccessService) Invite(ctx context.Context, campaign *models.Campaign, createdBy, email, role string) (*models.Invitation, error) {
+	if !validMemberRole(role) {
+		return nil, ErrInvalidRole
+	}
+	return a.store.CreateInvitation(ctx, &models.Invitation{
+		CampaignID: campaign.ID,
+		Email:      strings.TrimSpace(email),
+		Role:       role,
+		Code:       rand.Text()[:invitationCodeLength],
+		CreatedBy:  createdBy,
+		ExpiresAt:  time.Now().Add(InvitationTTL),
+	})
+}
+
+// PendingInvitations lists the unexpired invitations addressed to email.
+func (a *AccessService) PendingInvitations(ctx context.Context, email string) ([]models.Invitation, error) {
+	invitations, err := a.store.ListInvitationsForEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	pending := []models.Invitation{}
+	now := time.Now()
+	for _, invitation := range invitations {
+		if !strings.EqualFold(invitation.Email, email) || !now.Before(invitation.ExpiresAt) {
+			continue
+		}
+		campaign, err := a.store.GetCampaign(ctx, invitation.CampaignID)
+		if errors.Is(err, store.ErrNotFound) {
+			// The campaign is in the trash
+			continue
+		}
+		if err == nil {
+			invitation.CampaignTitle = campaign.Title
+		}
+		pending = append(pending, invitation)
+	}
+	return pending, nil
+}
+
+// Accept uses up the invitation with code, making userID a member of its
+// campaign. Joining a campaign the user already belongs to is
+// store.ErrConflict.
+func (a *AccessService) Accept(ctx context.Context, code, userID, email string) (*models.Campaign, error) {
+	invitation, err := a.store.GetInvitationByCode(ctx, strings.ToUpper(strings.TrimSpace(code)))
+	if errors.Is(err, store.ErrNotFound) || (err == nil && !time.Now().Before(invitation.ExpiresAt)) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, email) {
+		return nil, ErrInvitationEmail
+	}
+
+	campaign, err := a.store.GetCampaign(ctx, invitation.CampaignID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvitationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	role, err := a.Role(ctx, campaign, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != "" {
+		return nil, fmt.Errorf("%w: already a member of this campaign as %s", store.ErrConflict, role)
+	}
+
+	err = a.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.CreateCampaignMember(ctx, &models.CampaignMember{
+			CampaignID: campaign.ID,
+			UserID:     userID,
+			Email:      email,
+			Role:       invitation.Role,
+		}); err != nil {
+			return err
+		}
+		return tx.DeleteInvitation(ctx, invitation.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	campaign.Role = invitation.Role
+	return campaign, nil
+}
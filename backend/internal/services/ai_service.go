@@ -1,147 +1,155 @@
 package services
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
 )
 
 type AIService struct {
-	apiKey string
-	client *http.Client
+	deepDive    LLMProvider
+	consistency LLMProvider
+	extraction  LLMProvider
 }
 
-func NewAIService() *AIService {
-	return &AIService{
-		apiKey: os.Getenv("ANTHROPIC_API_KEY"),
-		client: &http.Client{},
+// NewAIService configures a provider per feature from the environment.
+func NewAIService() (*AIService, error) {
+	deepDive, err := NewLLMProvider(LLMConfigFromEnv(FeatureDeepDive))
+	if err != nil {
+		return nil, err
+	}
+	consistency, err := NewLLMProvider(LLMConfigFromEnv(FeatureConsistency))
+	if err != nil {
+		return nil, err
+	}
+	extraction, err := NewLLMProvider(LLMConfigFromEnv(FeatureExtraction))
+	if err != nil {
+		return nil, err
 	}
-}
-
-type ClaudeRequest struct {
-	Model     string          `json:"model"`
-	MaxTokens int             `json:"max_tokens"`
-	Messages  []ClaudeMessage `json:"messages"`
-}
 
-type ClaudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	return NewAIServiceWithProviders(deepDive, consistency, extraction), nil
 }
 
-type ClaudeResponse struct {
-	Content []struct {
-		Text string `json:"text"`
-	} `json:"content"`
+func NewAIServiceWithProviders(deepDive, consistency, extraction LLMProvider) *AIService {
+	return &AIService{deepDive: deepDive, consistency: consistency, extraction: extraction}
 }
 
-func (s *AIService) GenerateDeepDive(input map[string]interface{}) ([]string, error) {
-	prompt := fmt.Sprintf(`You are a creative assistant for TRPG GMs and fiction writers. 
-Given the following character information, generate 3-5 detailed suggestions to expand their background, personality, and story hooks.
-
-Input: %v
-
-Provide suggestions in JSON array format: ["suggestion1", "suggestion2", ...]`, input)
-
-	response, err := s.callClaude(prompt)
+// GenerateDeepDive suggests ways to expand a character. grounding may be nil,
+// in which case the suggestions are based on input alone and only lore entries
+// are proposed.
+func (s *AIService) GenerateDeepDive(ctx context.Context, input map[string]interface{}, grounding *DeepDiveContext) (*models.DeepDiveResponse, error) {
+	response, err := s.deepDive.Complete(ctx, deepDivePrompt(input, grounding))
 	if err != nil {
 		return nil, err
 	}
 
-	var suggestions []string
-	if err := json.Unmarshal([]byte(response), &suggestions); err != nil {
-		return []string{response}, nil
-	}
-
-	return suggestions, nil
+	return parseDeepDive(response, grounding), nil
 }
 
-func (s *AIService) CheckConsistency(newContent string, existingLore []string) (bool, []string, error) {
-	existingText := ""
-	for _, lore := range existingLore {
-		existingText += lore + "\n\n"
-	}
-
-	prompt := fmt.Sprintf(`You are a consistency checker for world-building. 
-Compare the new content against existing lore and identify any contradictions.
-
-Existing Lore:
-%s
-
-New Content:
-%s
-
-Respond in JSON format: {"is_consistent": true/false, "warnings": ["warning1", "warning2"]}`, existingText, newContent)
-
-	response, err := s.callClaude(prompt)
+// StreamDeepDive is GenerateDeepDive for streaming clients. onText receives the
+// raw model output as it arrives and onSuggestion each suggestion, with its
+// proposal if it has one, as soon as it is complete. The result collects what
+// was streamed, so proposal IDs match the ones already sent.
+func (s *AIService) StreamDeepDive(ctx context.Context, input map[string]interface{}, grounding *DeepDiveContext, onText func(text string) error, onSuggestion func(suggestion string, proposal *models.Proposal) error) (*models.DeepDiveResponse, error) {
+	var parser suggestionStream
+	result := &models.DeepDiveResponse{Suggestions: []string{}, Proposals: []models.Proposal{}}
+	response, err := streamOrComplete(ctx, s.deepDive, deepDivePrompt(input, grounding), func(text string) error {
+		if err := onText(text); err != nil {
+			return err
+		}
+		for _, element := range parser.Write(text) {
+			suggestion, proposal, ok := deepDiveSuggestion(element, grounding)
+			if !ok {
+				continue
+			}
+			addSuggestion(result, suggestion, proposal)
+			if err := onSuggestion(suggestion, proposal); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		return false, nil, err
-	}
-
-	var result struct {
-		IsConsistent bool     `json:"is_consistent"`
-		Warnings     []string `json:"warnings"`
+		return nil, err
 	}
 
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
-		return false, []string{response}, nil
+	if len(result.Suggestions) == 0 {
+		// The reply was not an array; fall back to the lenient parse
+		return parseDeepDive(response, grounding), nil
 	}
-
-	return result.IsConsistent, result.Warnings, nil
+	return result, nil
 }
 
-func (s *AIService) callClaude(prompt string) (string, error) {
-	reqBody := ClaudeRequest{
-		Model:     "claude-3-5-sonnet-20241022",
-		MaxTokens: 2048,
-		Messages: []ClaudeMessage{
-			{
-				Role:    "user",
-				Content: prompt,
-			},
-		},
+// parseDeepDive reads the JSON array of suggestions, skipping any text around
+// it and any elements that are neither strings nor objects. A reply that is not
+// an array is returned as a single suggestion.
+func parseDeepDive(response string, grounding *DeepDiveContext) *models.DeepDiveResponse {
+	result := &models.DeepDiveResponse{Suggestions: []string{}, Proposals: []models.Proposal{}}
+
+	var elements []json.RawMessage
+	start := strings.Index(response, "[")
+	end := strings.LastIndex(response, "]")
+	if start < 0 || end < start || json.Unmarshal([]byte(response[start:end+1]), &elements) != nil {
+		result.Suggestions = append(result.Suggestions, response)
+		return result
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", err
+	for _, element := range elements {
+		if suggestion, proposal, ok := deepDiveSuggestion(element, grounding); ok {
+			addSuggestion(result, suggestion, proposal)
+		}
 	}
+	return result
+}
 
-	req, err := http.NewRequest("POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
+func addSuggestion(result *models.DeepDiveResponse, suggestion string, proposal *models.Proposal) {
+	result.Suggestions = append(result.Suggestions, suggestion)
+	if proposal != nil {
+		result.Proposals = append(result.Proposals, *proposal)
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", s.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return "", err
+// CheckConsistency compares newContent against the retrieved campaign material.
+// Cited entity IDs are returned as the model wrote them; ConsistencyChecker
+// validates them against the campaign.
+func (s *AIService) CheckConsistency(ctx context.Context, newContent string, items []ContextItem) (bool, []models.ConsistencyWarning, error) {
+	if len(items) == 0 {
+		// Nothing related exists yet, so nothing can be contradicted
+		return true, []models.ConsistencyWarning{}, nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+	var existing strings.Builder
+	for _, item := range items {
+		fmt.Fprintf(&existing, "[id: %s] (%s) %s\n%s\n\n", item.ID, item.Type, item.Title, item.Text)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("API error: %s", string(body))
-	}
+	prompt := fmt.Sprintf(`You are a consistency checker for world-building. 
+Compare the new content against the existing characters and lore below and identify any contradictions.
+Each existing entry starts with its id in square brackets and its type in parentheses.
 
-	var claudeResp ClaudeResponse
-	if err := json.Unmarshal(body, &claudeResp); err != nil {
-		return "", err
-	}
+Existing Entries:
+%s
+New Content:
+%s
 
-	if len(claudeResp.Content) == 0 {
-		return "", fmt.Errorf("empty response from Claude")
+Respond in JSON format:
+{"is_consistent": true/false, "warnings": [{
+  "severity": "low" | "medium" | "high",
+  "message": "what contradicts what",
+  "entity_type": "character" | "lore_entry",
+  "entity_id": "id of the conflicting existing entry",
+  "existing_excerpt": "exact quote from the existing entry",
+  "new_excerpt": "exact quote from the new content",
+  "suggested_resolution": "how to reconcile the two"
+}]}`, existing.String(), newContent)
+
+	response, err := s.consistency.Complete(ctx, prompt)
+	if err != nil {
+		return false, nil, err
 	}
 
-	return claudeResp.Content[0].Text, nil
+	return ParseConsistencyResponse(response)
 }
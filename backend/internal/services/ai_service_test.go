@@ -0,0 +1,273 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// testGrounding is a deep-dive about the innkeeper, who knows the smuggler.
+func testGrounding() *DeepDiveContext {
+	innkeeper := models.Character{ID: "c1", CampaignID: "camp", Name: "Innkeeper", Role: "NPC"}
+	smuggler := models.Character{ID: "c2", CampaignID: "camp", Name: "Smuggler", Role: "NPC"}
+	return &DeepDiveContext{
+		Campaign:   &models.Campaign{ID: "camp", Title: "Harbour Town"},
+		Character:  &innkeeper,
+		Characters: []models.Character{innkeeper, smuggler},
+	}
+}
+
+const deepDiveReply = "```json\n" + `[
+  {"summary": "Owes the smuggler", "target": "relationship", "relationship": {"character": "smuggler", "relation_type": "debtor", "direction": "outgoing"}},
+  {"summary": "Hides a \"cellar\"", "target": "background", "background": "There is a hidden cellar."},
+  {"summary": "The Drowned Rat", "target": "lore_entry", "lore_entry": {"title": "The Drowned Rat", "content": "A harbour tavern."}},
+  "Hums old sea shanties",
+  {"summary": "Befriends a stranger", "target": "relationship", "relationship": {"character": "Nobody Known", "relation_type": "friend"}}
+]` + "\n```"
+
+func checkDeepDive(t *testing.T, result *models.DeepDiveResponse) {
+	t.Helper()
+
+	wantSuggestions := []string{"Owes the smuggler", `Hides a "cellar"`, "The Drowned Rat", "Hums old sea shanties", "Befriends a stranger"}
+	if strings.Join(result.Suggestions, "|") != strings.Join(wantSuggestions, "|") {
+		t.Fatalf("suggestions = %q, want %q", result.Suggestions, wantSuggestions)
+	}
+
+	// Plain strings and relationships to unknown characters have no proposal
+	if len(result.Proposals) != 3 {
+		t.Fatalf("got %d proposals, want 3: %+v", len(result.Proposals), result.Proposals)
+	}
+	relationship, background, loreEntry := result.Proposals[0], result.Proposals[1], result.Proposals[2]
+	if relationship.Kind != models.ProposalRelationship || relationship.Relationship.SourceCharacterID != "c1" ||
+		relationship.Relationship.TargetCharacterID != "c2" || relationship.Relationship.RelationType != "debtor" {
+		t.Errorf("relationship proposal = %+v", relationship)
+	}
+	if background.Kind != models.ProposalBackground || background.CharacterID != "c1" || background.Background != "There is a hidden cellar." {
+		t.Errorf("background proposal = %+v", background)
+	}
+	if loreEntry.Kind != models.ProposalLoreEntry || loreEntry.LoreEntry.Title != "The Drowned Rat" {
+		t.Errorf("lore entry proposal = %+v", loreEntry)
+	}
+	for _, proposal := range result.Proposals {
+		if proposal.ID == "" {
+			t.Errorf("proposal %q has no ID", proposal.Summary)
+		}
+	}
+}
+
+func TestGenerateDeepDive(t *testing.T) {
+	provider := NewFakeProvider(deepDiveReply)
+	ai := NewAIServiceWithProviders(provider, nil, nil)
+
+	result, err := ai.GenerateDeepDive(context.Background(), map[string]interface{}{"name": "Innkeeper"}, testGrounding())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDeepDive(t, result)
+
+	prompts := provider.Prompts()
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Harbour Town") || !strings.Contains(prompts[0], "Innkeeper") {
+		t.Errorf("prompt does not describe the campaign and character: %q", prompts)
+	}
+}
+
+func TestGenerateDeepDiveNotAnArray(t *testing.T) {
+	ai := NewAIServiceWithProviders(NewFakeProvider("Give the innkeeper a secret."), nil, nil)
+
+	result, err := ai.GenerateDeepDive(context.Background(), map[string]interface{}{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Suggestions) != 1 || result.Suggestions[0] != "Give the innkeeper a secret." || len(result.Proposals) != 0 {
+		t.Errorf("result = %+v, want the reply as a single suggestion", result)
+	}
+}
+
+func TestStreamDeepDive(t *testing.T) {
+	// Split mid-string, mid-escape and between multibyte characters
+	chunks := []string{
+		"Sure:\n[",
+		`{"summary": "Owes the smug`,
+		`gler", "target": "relationship", "relationship": {"character": "Smuggler", "relation_type": "debtor"}},`,
+		` {"summary": "Hides a \`,
+		`"cellar\`,
+		`"", "target": "background", "background": "地下に隠し`,
+		`部屋がある。"}, "Hums old sea shanties"]`,
+	}
+	provider := NewFakeStreamProvider(chunks)
+	ai := NewAIServiceWithProviders(provider, nil, nil)
+
+	var text strings.Builder
+	var streamed []string
+	var proposalIDs []string
+	textAtSuggestion := map[string]int{}
+	result, err := ai.StreamDeepDive(context.Background(), map[string]interface{}{}, testGrounding(),
+		func(delta string) error {
+			text.WriteString(delta)
+			return nil
+		},
+		func(suggestion string, proposal *models.Proposal) error {
+			streamed = append(streamed, suggestion)
+			textAtSuggestion[suggestion] = text.Len()
+			if proposal != nil {
+				proposalIDs = append(proposalIDs, proposal.ID)
+			}
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text.String() != strings.Join(chunks, "") {
+		t.Errorf("streamed text = %q, want the whole reply", text.String())
+	}
+
+	want := []string{"Owes the smuggler", `Hides a "cellar"`, "Hums old sea shanties"}
+	if strings.Join(streamed, "|") != strings.Join(want, "|") {
+		t.Fatalf("streamed suggestions = %q, want %q", streamed, want)
+	}
+	// Each suggestion is sent as soon as the chunk completing it arrives
+	if got, want := textAtSuggestion["Owes the smuggler"], len(strings.Join(chunks[:3], "")); got != want {
+		t.Errorf("first suggestion sent after %d bytes, want %d", got, want)
+	}
+	if got, want := textAtSuggestion[`Hides a "cellar"`], len(strings.Join(chunks[:7], "")); got != want {
+		t.Errorf("second suggestion sent after %d bytes, want %d", got, want)
+	}
+
+	if strings.Join(result.Suggestions, "|") != strings.Join(want, "|") {
+		t.Errorf("result suggestions = %q, want %q", result.Suggestions, want)
+	}
+	if len(result.Proposals) != 2 || result.Proposals[0].ID != proposalIDs[0] || result.Proposals[1].ID != proposalIDs[1] {
+		t.Errorf("result proposals %+v do not match the streamed IDs %q", result.Proposals, proposalIDs)
+	}
+	if result.Proposals[1].Background != "地下に隠し部屋がある。" {
+		t.Errorf("background = %q", result.Proposals[1].Background)
+	}
+}
+
+func TestStreamDeepDiveStopsOnCallbackError(t *testing.T) {
+	stop := errors.New("client went away")
+	ai := NewAIServiceWithProviders(NewFakeStreamProvider([]string{`["one", `, `"two"]`}), nil, nil)
+
+	var streamed []string
+	_, err := ai.StreamDeepDive(context.Background(), map[string]interface{}{}, nil,
+		func(string) error { return nil },
+		func(suggestion string, _ *models.Proposal) error {
+			streamed = append(streamed, suggestion)
+			return stop
+		})
+	if !errors.Is(err, stop) {
+		t.Errorf("err = %v, want %v", err, stop)
+	}
+	if len(streamed) != 1 {
+		t.Errorf("streamed %q after the callback failed", streamed)
+	}
+}
+
+func TestCheckConsistency(t *testing.T) {
+	items := []ContextItem{{Type: "character", ID: "c1", Title: "Innkeeper", Text: "The innkeeper has one eye."}}
+
+	tests := []struct {
+		name           string
+		reply          string
+		wantConsistent bool
+		wantWarnings   []models.ConsistencyWarning
+		wantErr        error
+	}{
+		{
+			name:           "consistent",
+			reply:          `{"is_consistent": true, "warnings": []}`,
+			wantConsistent: true,
+			wantWarnings:   []models.ConsistencyWarning{},
+		},
+		{
+			name: "warnings in a code fence",
+			reply: "```json\n" + `{"is_consistent": false, "warnings": [{"severity": "Critical", "message": "Eye count differs",
+				"entity_type": "character", "entity_id": "[id: c1]", "existing_excerpt": "one eye", "new_excerpt": "both eyes"}]}` + "\n```",
+			wantWarnings: []models.ConsistencyWarning{{
+				Severity: models.SeverityHigh, Message: "Eye count differs", EntityType: "character", EntityID: "c1",
+				ExistingExcerpt: "one eye", NewExcerpt: "both eyes",
+			}},
+		},
+		{
+			name:         "plain string warnings",
+			reply:        `{"warnings": ["The innkeeper is described differently"]}`,
+			wantWarnings: []models.ConsistencyWarning{{Severity: models.SeverityMedium, Message: "The innkeeper is described differently"}},
+		},
+		{
+			name:    "not JSON",
+			reply:   "Looks fine to me.",
+			wantErr: ErrUnparseableResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewFakeProvider(tt.reply)
+			ai := NewAIServiceWithProviders(nil, provider, nil)
+
+			consistent, warnings, err := ai.CheckConsistency(context.Background(), "The innkeeper looks at you with both eyes.", items)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if consistent != tt.wantConsistent {
+				t.Errorf("consistent = %v, want %v", consistent, tt.wantConsistent)
+			}
+			if len(warnings) != len(tt.wantWarnings) {
+				t.Fatalf("warnings = %+v, want %+v", warnings, tt.wantWarnings)
+			}
+			for i := range warnings {
+				if warnings[i] != tt.wantWarnings[i] {
+					t.Errorf("warning %d = %+v, want %+v", i, warnings[i], tt.wantWarnings[i])
+				}
+			}
+
+			if prompts := provider.Prompts(); len(prompts) != 1 || !strings.Contains(prompts[0], "[id: c1]") {
+				t.Errorf("prompt does not cite the existing entry: %q", prompts)
+			}
+		})
+	}
+}
+
+func TestCheckConsistencyWithoutRelatedEntries(t *testing.T) {
+	provider := NewFakeProvider(`{"is_consistent": false}`)
+	ai := NewAIServiceWithProviders(nil, provider, nil)
+
+	consistent, warnings, err := ai.CheckConsistency(context.Background(), "Anything at all.", nil)
+	if err != nil || !consistent || len(warnings) != 0 {
+		t.Errorf("got %v, %+v, %v; want consistent with no warnings", consistent, warnings, err)
+	}
+	if len(provider.Prompts()) != 0 {
+		t.Error("the model was asked although there is nothing to contradict")
+	}
+}
+
+func TestFakeProviderScript(t *testing.T) {
+	provider := NewFakeStreamProvider([]string{"a", "b"}, []string{"c"})
+
+	var deltas []string
+	first, err := provider.Stream(context.Background(), "p1", func(text string) error {
+		deltas = append(deltas, text)
+		return nil
+	})
+	if err != nil || first != "ab" || strings.Join(deltas, "|") != "a|b" {
+		t.Errorf("Stream = %q, %v with deltas %q", first, err, deltas)
+	}
+
+	// The last reply repeats once the script runs out
+	for range 2 {
+		if reply, err := provider.Complete(context.Background(), "p"); err != nil || reply != "c" {
+			t.Errorf("Complete = %q, %v; want %q", reply, err, "c")
+		}
+	}
+
+	if _, err := NewFakeProvider().Complete(context.Background(), "p"); err == nil {
+		t.Error("a provider without a script replied")
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrInvalidArchive = errors.New("invalid archive")
+
+// ArchiveService exports campaigns to models.CampaignArchive and imports them
+// back as new campaigns.
+type ArchiveService struct {
+	store    store.Store
+	embedder Embedder
+}
+
+func NewArchiveService(s store.Store, embedder Embedder) *ArchiveService {
+	return &ArchiveService{store: s, embedder: embedder}
+}
+
+// Export builds the archive of a campaign.
+func (a *ArchiveService) Export(ctx context.Context, campaign *models.Campaign) (*models.CampaignArchive, error) {
+	characters, err := a.store.ListCharacters(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := a.store.ListRelationships(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	loreEntries, err := a.store.ListLoreEntries(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Players export only what they can see
+	visibility := NewVisibility(campaign.Role, characters)
+	characters = visibility.Characters(characters)
+	relationships = visibility.Relationships(relationships)
+	loreEntries = visibility.LoreEntries(loreEntries)
+	relationTypes, err := a.store.ListRelationTypes(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &models.CampaignArchive{
+		Version:    models.CampaignArchiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Campaign: models.ArchivedCampaign{
+			ID:          campaign.ID,
+			Title:       campaign.Title,
+			Description: campaign.Description,
+		},
+		Characters:    make([]models.ArchivedCharacter, len(characters)),
+		Relationships: make([]models.ArchivedRelationship, len(relationships)),
+		LoreEntries:   make([]models.ArchivedLoreEntry, len(loreEntries)),
+	}
+	for i, c := range characters {
+		archive.Characters[i] = models.ArchivedCharacter{
+			ID:                  c.ID,
+			Name:                c.Name,
+			Role:                c.Role,
+			Attributes:          c.Attributes,
+			Background:          c.Background,
+			Visibility:          c.Visibility,
+			AttributeVisibility: c.AttributeVisibility,
+		}
+	}
+	for i, r := range relationships {
+		archive.Relationships[i] = models.ArchivedRelationship{
+			ID:                r.ID,
+			SourceCharacterID: r.SourceCharacterID,
+			TargetCharacterID: r.TargetCharacterID,
+			RelationType:      r.RelationType,
+			Description:       r.Description,
+			Visibility:        r.Visibility,
+		}
+	}
+	for i, l := range loreEntries {
+		archive.LoreEntries[i] = models.ArchivedLoreEntry{
+			ID:         l.ID,
+			Title:      l.Title,
+			Category:   l.Category,
+			Content:    l.Content,
+			Visibility: l.Visibility,
+		}
+	}
+	for _, t := range relationTypes {
+		archive.RelationTypes = append(archive.RelationTypes, models.ArchivedRelationType{
+			Name:        t.Name,
+			InverseName: t.InverseName,
+			Symmetric:   t.Symmetric,
+		})
+	}
+
+	return archive, nil
+}
+
+// Import recreates an archived campaign for userID in one transaction. Every
+// record gets a new ID and relationships are pointed at the new characters.
+// Validation errors wrap ErrInvalidArchive.
+func (a *ArchiveService) Import(ctx context.Context, userID string, archive *models.CampaignArchive) (*models.Campaign, error) {
+	if err := validateArchive(archive); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+
+	var campaign *models.Campaign
+	var characters []models.Character
+	var loreEntries []models.LoreEntry
+
+	err := a.store.WithTx(ctx, func(tx store.Store) error {
+		characters, loreEntries = nil, nil
+
+		var err error
+		campaign, err = tx.CreateCampaign(ctx, &models.Campaign{
+			UserID:      userID,
+			Title:       archive.Campaign.Title,
+			Description: archive.Campaign.Description,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Archived relationships already include their paired edges
+		for _, t := range archive.RelationTypes {
+			_, err := tx.CreateRelationType(ctx, &models.RelationType{
+				CampaignID:  campaign.ID,
+				Name:        t.Name,
+				InverseName: t.InverseName,
+				Symmetric:   t.Symmetric,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		characterIDs := make(map[string]string, len(archive.Characters))
+		for _, c := range archive.Characters {
+			created, err := tx.CreateCharacter(ctx, &models.Character{
+				CampaignID: campaign.ID,
+				Name:       c.Name,
+				Role:       c.Role,
+				Attributes: c.Attributes,
+				Background: c.Background,
+				Visibility: c.Visibility,
+
+				AttributeVisibility: c.AttributeVisibility,
+			})
+			if err != nil {
+				return err
+			}
+			characterIDs[c.ID] = created.ID
+			characters = append(characters, *created)
+		}
+
+		for _, r := range archive.Relationships {
+			_, err := tx.CreateRelationship(ctx, &models.Relationship{
+				CampaignID:        campaign.ID,
+				SourceCharacterID: characterIDs[r.SourceCharacterID],
+				TargetCharacterID: characterIDs[r.TargetCharacterID],
+				RelationType:      r.RelationType,
+				Description:       r.Description,
+				Visibility:        r.Visibility,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, l := range archive.LoreEntries {
+			created, err := tx.CreateLoreEntry(ctx, &models.LoreEntry{
+				CampaignID: campaign.ID,
+				Title:      l.Title,
+				Category:   l.Category,
+				Content:    l.Content,
+				Visibility: l.Visibility,
+			})
+			if err != nil {
+				return err
+			}
+			loreEntries = append(loreEntries, *created)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Archives carry no embeddings, as they depend on the configured model
+	for i := range characters {
+		setEmbedding(ctx, a.embedder, CharacterEmbeddingText(&characters[i]), func(embedding []float32) error {
+			return a.store.SetCharacterEmbedding(ctx, characters[i].ID, embedding)
+		})
+	}
+	for i := range loreEntries {
+		setEmbedding(ctx, a.embedder, LoreEntryEmbeddingText(&loreEntries[i]), func(embedding []float32) error {
+			return a.store.SetLoreEntryEmbedding(ctx, loreEntries[i].ID, embedding)
+		})
+	}
+
+	return campaign, nil
+}
+
+// validateArchive checks everything the database would otherwise reject
+// halfway through the import.
+func validateArchive(archive *models.CampaignArchive) error {
+	if archive.Version != models.CampaignArchiveVersion {
+		return fmt.Errorf("unsupported version %d, expected %d", archive.Version, models.CampaignArchiveVersion)
+	}
+	if strings.TrimSpace(archive.Campaign.Title) == "" {
+		return errors.New("campaign.title is required")
+	}
+
+	characterIDs := make(map[string]bool, len(archive.Characters))
+	for i, c := range archive.Characters {
+		if c.ID == "" {
+			return fmt.Errorf("characters[%d].id is required", i)
+		}
+		if characterIDs[c.ID] {
+			return fmt.Errorf("characters[%d].id %q is used twice", i, c.ID)
+		}
+		if strings.TrimSpace(c.Name) == "" {
+			return fmt.Errorf("characters[%d].name is required", i)
+		}
+		if !validVisibility(c.Visibility) {
+			return fmt.Errorf("characters[%d].visibility must be public or gm", i)
+		}
+		for key, v := range c.AttributeVisibility {
+			if !validVisibility(v) {
+				return fmt.Errorf("characters[%d].attribute_visibility[%q] must be public or gm", i, key)
+			}
+		}
+		characterIDs[c.ID] = true
+	}
+
+	pairs := make(map[[2]string]bool, len(archive.Relationships))
+	for i, r := range archive.Relationships {
+		if !characterIDs[r.SourceCharacterID] {
+			return fmt.Errorf("relationships[%d].source_character_id %q is not an archived character", i, r.SourceCharacterID)
+		}
+		if !characterIDs[r.TargetCharacterID] {
+			return fmt.Errorf("relationships[%d].target_character_id %q is not an archived character", i, r.TargetCharacterID)
+		}
+		if strings.TrimSpace(r.RelationType) == "" {
+			return fmt.Errorf("relationships[%d].relation_type is required", i)
+		}
+		if !validVisibility(r.Visibility) {
+			return fmt.Errorf("relationships[%d].visibility must be public or gm", i)
+		}
+		pair := [2]string{r.SourceCharacterID, r.TargetCharacterID}
+		if pairs[pair] {
+			return fmt.Errorf("relationships[%d] duplicates an earlier relationship", i)
+		}
+		pairs[pair] = true
+	}
+
+	relationTypes := make(map[string]bool, len(archive.RelationTypes))
+	for i, t := range archive.RelationTypes {
+		if strings.TrimSpace(t.Name) == "" {
+			return fmt.Errorf("relation_types[%d].name is required", i)
+		}
+		if t.Symmetric && t.InverseName != "" {
+			return fmt.Errorf("relation_types[%d] is symmetric and cannot have an inverse", i)
+		}
+		names := []string{t.Name}
+		if !strings.EqualFold(strings.TrimSpace(t.InverseName), strings.TrimSpace(t.Name)) {
+			names = append(names, t.InverseName)
+		}
+		for _, name := range names {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if key == "" {
+				continue
+			}
+			if relationTypes[key] {
+				return fmt.Errorf("relation_types[%d] registers %q twice", i, name)
+			}
+			relationTypes[key] = true
+		}
+	}
+
+	for i, l := range archive.LoreEntries {
+		if strings.TrimSpace(l.Title) == "" {
+			return fmt.Errorf("lore_entries[%d].title is required", i)
+		}
+		if strings.TrimSpace(l.Content) == "" {
+			return fmt.Errorf("lore_entries[%d].content is required", i)
+		}
+		if !validVisibility(l.Visibility) {
+			return fmt.Errorf("lore_entries[%d].visibility must be public or gm", i)
+		}
+	}
+
+	return nil
+}
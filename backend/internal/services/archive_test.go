@@ -0,0 +1,250 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// seedArchiveCampaign fills a campaign with a bit of everything an archive
+// carries, GM-only content included.
+func seedArchiveCampaign(t *testing.T, s store.Store) *models.Campaign {
+	t.Helper()
+	ctx := context.Background()
+	campaignID, ids := seedCampaign(t, s, "Cora")
+
+	characters := []models.Character{
+		{Name: "Aldo", Role: "Innkeeper", Background: "Runs the Drowned Rat.\nOwes Bea.",
+			Attributes:          map[string]interface{}{"age": 52.0, "debt": "300 crowns"},
+			AttributeVisibility: map[string]string{"debt": models.VisibilityGM}},
+		{Name: "Bea", Role: "Smuggler", Visibility: models.VisibilityGM},
+	}
+	for _, c := range characters {
+		c.CampaignID = campaignID
+		created, err := s.CreateCharacter(ctx, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[c.Name] = created.ID
+	}
+
+	if _, err := s.CreateRelationType(ctx, &models.RelationType{CampaignID: campaignID, Name: "mentor", InverseName: "apprentice"}); err != nil {
+		t.Fatal(err)
+	}
+	relationships := []models.Relationship{
+		{SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Cora"], RelationType: "mentor", Description: "Taught her to brew."},
+		{SourceCharacterID: ids["Cora"], TargetCharacterID: ids["Aldo"], RelationType: "apprentice"},
+		{SourceCharacterID: ids["Bea"], TargetCharacterID: ids["Aldo"], RelationType: "creditor", Visibility: models.VisibilityGM},
+	}
+	for _, r := range relationships {
+		r.CampaignID = campaignID
+		if _, err := s.CreateRelationship(ctx, &r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loreEntries := []models.LoreEntry{
+		{Title: "Drowned Rat", Category: "Places", Content: "Aldo's inn by the docks."},
+		{Title: "Smuggling Ring", Content: "Bea runs it from the cellar.", Visibility: models.VisibilityGM},
+	}
+	for _, l := range loreEntries {
+		l.CampaignID = campaignID
+		if _, err := s.CreateLoreEntry(ctx, &l); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	campaign, err := s.GetCampaign(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	campaign.Role = models.RoleOwner
+	return campaign
+}
+
+// campaignSummary describes a campaign's records by name rather than ID,
+// sorted, so that a campaign and its imported copy describe the same.
+func campaignSummary(t *testing.T, s store.Store, campaignID string) []string {
+	t.Helper()
+	ctx := context.Background()
+	characters, err := s.ListCharacters(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relationships, err := s.ListRelationships(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loreEntries, err := s.ListLoreEntries(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relationTypes, err := s.ListRelationTypes(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]string{}
+	var summary []string
+	for _, c := range characters {
+		names[c.ID] = c.Name
+		summary = append(summary, fmt.Sprintf("character %s (%s, %s) %v %v %q", c.Name, c.Role, c.Visibility, c.Attributes, c.AttributeVisibility, c.Background))
+	}
+	for _, r := range relationships {
+		summary = append(summary, fmt.Sprintf("relationship %s %s %s (%s) %q", names[r.SourceCharacterID], r.RelationType, names[r.TargetCharacterID], r.Visibility, r.Description))
+	}
+	for _, l := range loreEntries {
+		summary = append(summary, fmt.Sprintf("lore %s/%s (%s) %q", l.Category, l.Title, l.Visibility, l.Content))
+	}
+	for _, rt := range relationTypes {
+		summary = append(summary, fmt.Sprintf("relation type %s/%s %v", rt.Name, rt.InverseName, rt.Symmetric))
+	}
+	sort.Strings(summary)
+	return summary
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		campaign := seedArchiveCampaign(t, s)
+		archives := NewArchiveService(s, nil)
+
+		exported, err := archives.Export(ctx, campaign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Through JSON, as the archive is downloaded and uploaded again
+		data, err := json.Marshal(exported)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var archive models.CampaignArchive
+		if err := json.Unmarshal(data, &archive); err != nil {
+			t.Fatal(err)
+		}
+
+		imported, err := archives.Import(ctx, stranger, &archive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported.ID == campaign.ID || imported.UserID != stranger || imported.Title != campaign.Title {
+			t.Errorf("imported campaign = %+v", imported)
+		}
+
+		want := campaignSummary(t, s, campaign.ID)
+		if len(want) != 9 {
+			t.Fatalf("seeded campaign:\n%s", strings.Join(want, "\n"))
+		}
+		got := campaignSummary(t, s, imported.ID)
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("imported campaign:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+
+		// Every record is new, and relationships join the new characters
+		oldIDs := map[string]bool{}
+		for _, c := range archive.Characters {
+			oldIDs[c.ID] = true
+		}
+		for _, r := range archive.Relationships {
+			oldIDs[r.ID] = true
+		}
+		characters, err := s.ListCharacters(ctx, imported.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		newIDs := map[string]bool{}
+		for _, c := range characters {
+			newIDs[c.ID] = true
+			if oldIDs[c.ID] {
+				t.Errorf("character %s kept its ID", c.Name)
+			}
+		}
+		relationships, err := s.ListRelationships(ctx, imported.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range relationships {
+			if oldIDs[r.ID] || !newIDs[r.SourceCharacterID] || !newIDs[r.TargetCharacterID] {
+				t.Errorf("relationship %+v is not remapped", r)
+			}
+		}
+	})
+}
+
+// stranger imports archives into a campaign of their own.
+const stranger = "00000000-0000-0000-0000-00000000000f"
+
+func TestImportRejectsMalformedArchive(t *testing.T) {
+	valid := func() *models.CampaignArchive {
+		return &models.CampaignArchive{
+			Version:  models.CampaignArchiveVersion,
+			Campaign: models.ArchivedCampaign{Title: "Harbour Town"},
+			Characters: []models.ArchivedCharacter{
+				{ID: "c1", Name: "Aldo"},
+				{ID: "c2", Name: "Bea"},
+			},
+			Relationships: []models.ArchivedRelationship{
+				{ID: "r1", SourceCharacterID: "c1", TargetCharacterID: "c2", RelationType: "friend"},
+			},
+			LoreEntries:   []models.ArchivedLoreEntry{{ID: "l1", Title: "Docks", Content: "Busy."}},
+			RelationTypes: []models.ArchivedRelationType{{Name: "mentor", InverseName: "apprentice"}},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		modify func(a *models.CampaignArchive)
+	}{
+		{"newer version", func(a *models.CampaignArchive) { a.Version = models.CampaignArchiveVersion + 1 }},
+		{"no title", func(a *models.CampaignArchive) { a.Campaign.Title = " " }},
+		{"character without ID", func(a *models.CampaignArchive) { a.Characters[0].ID = "" }},
+		{"character ID used twice", func(a *models.CampaignArchive) { a.Characters[1].ID = "c1" }},
+		{"character without name", func(a *models.CampaignArchive) { a.Characters[1].Name = "" }},
+		{"unknown visibility", func(a *models.CampaignArchive) { a.Characters[0].Visibility = "secret" }},
+		{"unknown attribute visibility", func(a *models.CampaignArchive) {
+			a.Characters[0].AttributeVisibility = map[string]string{"age": "secret"}
+		}},
+		{"relationship to a character not in the archive", func(a *models.CampaignArchive) { a.Relationships[0].TargetCharacterID = "c9" }},
+		{"relationship without type", func(a *models.CampaignArchive) { a.Relationships[0].RelationType = "" }},
+		{"relationship twice", func(a *models.CampaignArchive) {
+			a.Relationships = append(a.Relationships, models.ArchivedRelationship{ID: "r2", SourceCharacterID: "c1", TargetCharacterID: "c2", RelationType: "friend"})
+		}},
+		{"symmetric type with an inverse", func(a *models.CampaignArchive) { a.RelationTypes[0].Symmetric = true }},
+		{"relation type named twice", func(a *models.CampaignArchive) {
+			a.RelationTypes = append(a.RelationTypes, models.ArchivedRelationType{Name: "apprentice"})
+		}},
+		{"lore entry without content", func(a *models.CampaignArchive) { a.LoreEntries[0].Content = "" }},
+	}
+
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	archives := NewArchiveService(s, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			archive := valid()
+			tt.modify(archive)
+			if _, err := archives.Import(ctx, stranger, archive); !errors.Is(err, ErrInvalidArchive) {
+				t.Errorf("err = %v, want %v", err, ErrInvalidArchive)
+			}
+		})
+	}
+
+	// Nothing is created from a rejected archive
+	campaigns, err := s.ListCampaigns(ctx, stranger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campaigns) != 0 {
+		t.Errorf("rejected archives created %d campaigns", len(campaigns))
+	}
+	if _, err := archives.Import(ctx, stranger, valid()); err != nil {
+		t.Errorf("valid archive: %v", err)
+	}
+}
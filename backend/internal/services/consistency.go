@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrUnparseableResponse = errors.New("could not parse AI response")
+
+// ConsistencyChecker runs the RAG consistency check for a campaign: retrieve
+// related entries, ask the model, then drop citations that do not resolve to an
+// entry of the campaign.
+type ConsistencyChecker struct {
+	store     store.Store
+	ai        *AIService
+	search    *SearchService
+	retrieval RetrievalOptions
+}
+
+func NewConsistencyChecker(s store.Store, ai *AIService, embedder Embedder) *ConsistencyChecker {
+	return &ConsistencyChecker{
+		store:     s,
+		ai:        ai,
+		search:    NewSearchService(s, embedder),
+		retrieval: RetrievalOptionsFromEnv(),
+	}
+}
+
+// Check compares newContent with the campaign. IDs in exclude are left out of
+// the comparison, so an edited entry is not checked against its old version.
+func (c *ConsistencyChecker) Check(ctx context.Context, campaignID, newContent string, exclude ...string) (*models.ConsistencyCheckResponse, error) {
+	items, err := c.search.Retrieve(ctx, campaignID, newContent, c.retrieval, exclude...)
+	if err != nil {
+		return nil, err
+	}
+
+	isConsistent, warnings, err := c.ai.CheckConsistency(ctx, newContent, items)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]ContextItem, len(items))
+	sources := make([]models.ConsistencySource, len(items))
+	for i, item := range items {
+		byID[item.ID] = item
+		sources[i] = item.Source()
+	}
+
+	for i := range warnings {
+		c.resolveEntity(ctx, campaignID, byID, &warnings[i])
+	}
+
+	return &models.ConsistencyCheckResponse{
+		IsConsistent: isConsistent,
+		Warnings:     warnings,
+		Sources:      sources,
+	}, nil
+}
+
+// resolveEntity fills in the title of the cited entity, or clears the citation
+// when the ID is not a character or lore entry of this campaign.
+func (c *ConsistencyChecker) resolveEntity(ctx context.Context, campaignID string, retrieved map[string]ContextItem, warning *models.ConsistencyWarning) {
+	id := warning.EntityID
+	entityType := warning.EntityType
+	warning.EntityType, warning.EntityID, warning.EntityTitle = "", "", ""
+	if id == "" {
+		return
+	}
+
+	if item, ok := retrieved[id]; ok {
+		warning.EntityType, warning.EntityID, warning.EntityTitle = item.Type, item.ID, item.Title
+		return
+	}
+
+	// The model may cite an entry it was not shown, or mislabel its type, so
+	// both tables are tried with the stated type first
+	lookups := []func() bool{
+		func() bool {
+			character, err := c.store.GetCharacter(ctx, id)
+			if err != nil || character.CampaignID != campaignID {
+				return false
+			}
+			warning.EntityType, warning.EntityID, warning.EntityTitle = SearchHitCharacter, character.ID, character.Name
+			return true
+		},
+		func() bool {
+			loreEntry, err := c.store.GetLoreEntry(ctx, id)
+			if err != nil || loreEntry.CampaignID != campaignID {
+				return false
+			}
+			warning.EntityType, warning.EntityID, warning.EntityTitle = SearchHitLoreEntry, loreEntry.ID, loreEntry.Title
+			return true
+		},
+	}
+	if entityType == SearchHitLoreEntry {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+	for _, lookup := range lookups {
+		if lookup() {
+			return
+		}
+	}
+}
+
+// ParseConsistencyResponse reads the model's JSON answer. It tolerates code
+// fences and surrounding prose, warnings given as plain strings, and unknown
+// severities. is_consistent defaults to whether any warnings were returned.
+func ParseConsistencyResponse(response string) (bool, []models.ConsistencyWarning, error) {
+	object := extractJSONObject(response)
+	if object == "" {
+		return false, nil, ErrUnparseableResponse
+	}
+
+	var reply struct {
+		IsConsistent *bool             `json:"is_consistent"`
+		Warnings     []json.RawMessage `json:"warnings"`
+	}
+	if err := json.Unmarshal([]byte(object), &reply); err != nil {
+		return false, nil, ErrUnparseableResponse
+	}
+
+	warnings := make([]models.ConsistencyWarning, 0, len(reply.Warnings))
+	for _, raw := range reply.Warnings {
+		var message string
+		if json.Unmarshal(raw, &message) == nil {
+			if message = strings.TrimSpace(message); message != "" {
+				warnings = append(warnings, models.ConsistencyWarning{Severity: models.SeverityMedium, Message: message})
+			}
+			continue
+		}
+
+		var w struct {
+			Severity            string `json:"severity"`
+			Message             string `json:"message"`
+			EntityType          string `json:"entity_type"`
+			EntityID            string `json:"entity_id"`
+			ExistingExcerpt     string `json:"existing_excerpt"`
+			NewExcerpt          string `json:"new_excerpt"`
+			SuggestedResolution string `json:"suggested_resolution"`
+		}
+		if json.Unmarshal(raw, &w) != nil || strings.TrimSpace(w.Message) == "" {
+			continue
+		}
+
+		warnings = append(warnings, models.ConsistencyWarning{
+			Severity:            normalizeSeverity(w.Severity),
+			Message:             strings.TrimSpace(w.Message),
+			EntityType:          strings.TrimSpace(w.EntityType),
+			EntityID:            normalizeEntityID(w.EntityID),
+			ExistingExcerpt:     strings.TrimSpace(w.ExistingExcerpt),
+			NewExcerpt:          strings.TrimSpace(w.NewExcerpt),
+			SuggestedResolution: strings.TrimSpace(w.SuggestedResolution),
+		})
+	}
+
+	isConsistent := len(warnings) == 0
+	if reply.IsConsistent != nil {
+		isConsistent = *reply.IsConsistent
+	}
+
+	return isConsistent, warnings, nil
+}
+
+// extractJSONObject returns the outermost {...} in text, which skips code
+// fences and any prose the model wraps around its answer.
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return ""
+	}
+	return text[start : end+1]
+}
+
+func normalizeSeverity(severity string) string {
+	switch strings.ToLower(strings.TrimSpace(severity)) {
+	case "high", "critical", "major", "error":
+		return models.SeverityHigh
+	case "low", "minor", "info":
+		return models.SeverityLow
+	default:
+		return models.SeverityMedium
+	}
+}
+
+// normalizeEntityID strips the "[id: ...]" decoration the prompt uses, in case
+// the model copies it.
+func normalizeEntityID(id string) string {
+	id = strings.Trim(strings.TrimSpace(id), "[]")
+	id = strings.TrimPrefix(id, "id:")
+	return strings.TrimSpace(id)
+}
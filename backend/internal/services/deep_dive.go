@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// DeepDiveContext is the existing campaign material a deep-dive is grounded in.
+type DeepDiveContext struct {
+	Campaign      *models.Campaign
+	Character     *models.Character
+	Relationships []RelatedCharacter
+	Related       []ContextItem
+	// Characters lists the whole campaign cast when a character is in focus,
+	// so proposed relationships can be matched by name
+	Characters []models.Character
+}
+
+// RelatedCharacter is the other side of one of the focus character's
+// relationships. Outgoing is true when the focus character is the source.
+type RelatedCharacter struct {
+	Character    models.Character
+	RelationType string
+	Description  string
+	Outgoing     bool
+}
+
+// DeepDiveGrounder collects the DeepDiveContext for a deep-dive request.
+type DeepDiveGrounder struct {
+	store     store.Store
+	search    *SearchService
+	retrieval RetrievalOptions
+}
+
+func NewDeepDiveGrounder(s store.Store, embedder Embedder) *DeepDiveGrounder {
+	return &DeepDiveGrounder{
+		store:     s,
+		search:    NewSearchService(s, embedder),
+		retrieval: RetrievalOptionsFromEnv(),
+	}
+}
+
+// Ground loads the focus character (when characterID is set) with its
+// relationships, and retrieves the lore and characters most related to it and
+// to input. It returns store.ErrNotFound if the character is not in the campaign.
+func (g *DeepDiveGrounder) Ground(ctx context.Context, campaign *models.Campaign, characterID string, input map[string]interface{}) (*DeepDiveContext, error) {
+	grounding := &DeepDiveContext{Campaign: campaign}
+	query := formatInput(input)
+	var exclude []string
+
+	if characterID != "" {
+		character, err := g.store.GetCharacter(ctx, characterID)
+		if err != nil {
+			return nil, err
+		}
+		if character.CampaignID != campaign.ID {
+			return nil, store.ErrNotFound
+		}
+		grounding.Character = character
+		query = CharacterEmbeddingText(character) + "\n" + query
+		exclude = append(exclude, character.ID)
+
+		relationships, err := g.store.ListRelationships(ctx, campaign.ID)
+		if err != nil {
+			return nil, err
+		}
+		characters, err := g.store.ListCharacters(ctx, campaign.ID)
+		if err != nil {
+			return nil, err
+		}
+		grounding.Characters = characters
+		byID := make(map[string]models.Character, len(characters))
+		for _, c := range characters {
+			byID[c.ID] = c
+		}
+
+		for _, r := range relationships {
+			var otherID string
+			switch character.ID {
+			case r.SourceCharacterID:
+				otherID = r.TargetCharacterID
+			case r.TargetCharacterID:
+				otherID = r.SourceCharacterID
+			default:
+				continue
+			}
+
+			other, ok := byID[otherID]
+			if !ok {
+				continue
+			}
+			grounding.Relationships = append(grounding.Relationships, RelatedCharacter{
+				Character:    other,
+				RelationType: r.RelationType,
+				Description:  r.Description,
+				Outgoing:     r.SourceCharacterID == character.ID,
+			})
+			exclude = append(exclude, other.ID)
+		}
+	}
+
+	related, err := g.search.Retrieve(ctx, campaign.ID, query, g.retrieval, exclude...)
+	if err != nil {
+		return nil, err
+	}
+	grounding.Related = related
+
+	return grounding, nil
+}
+
+// formatInput renders the free-form input as sorted "key: value" lines.
+func formatInput(input map[string]interface{}) string {
+	keys := make([]string, 0, len(input))
+	for key := range input {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %v\n", key, input[key])
+	}
+	return strings.TrimSpace(b.String())
+}
+
+func deepDivePrompt(input map[string]interface{}, grounding *DeepDiveContext) string {
+	var world strings.Builder
+	if grounding != nil {
+		fmt.Fprintf(&world, "Campaign: %s\n", grounding.Campaign.Title)
+		if grounding.Campaign.Description != "" {
+			fmt.Fprintf(&world, "%s\n", grounding.Campaign.Description)
+		}
+
+		if grounding.Character != nil {
+			fmt.Fprintf(&world, "\nCharacter to expand:\n%s\n", CharacterEmbeddingText(grounding.Character))
+		}
+
+		if len(grounding.Relationships) > 0 {
+			world.WriteString("\nRelationships:\n")
+			for _, r := range grounding.Relationships {
+				from, to := grounding.Character.Name, r.Character.Name
+				if !r.Outgoing {
+					from, to = to, from
+				}
+				fmt.Fprintf(&world, "- %s → %s: %s", from, to, r.RelationType)
+				if r.Description != "" {
+					fmt.Fprintf(&world, " (%s)", r.Description)
+				}
+				fmt.Fprintf(&world, "\n  %s\n", strings.ReplaceAll(TruncateTokens(CharacterEmbeddingText(&r.Character), relatedCharacterTokens), "\n", " "))
+			}
+		}
+
+		if len(grounding.Related) > 0 {
+			world.WriteString("\nRelated characters and lore:\n")
+			for _, item := range grounding.Related {
+				fmt.Fprintf(&world, "- (%s) %s\n%s\n", item.Type, item.Title, item.Text)
+			}
+		}
+	}
+
+	return fmt.Sprintf(`You are a creative assistant for TRPG GMs and fiction writers. 
+Given the following character information, generate 3-5 detailed suggestions to expand their background, personality, and story hooks.
+Ground the suggestions in the existing world below: refer to its characters, places and events by their names, and do not contradict them or invent replacements for things that already exist.
+
+Existing World:
+%s
+Input:
+%s
+
+Provide the suggestions as a JSON array of objects. Each object has a one-sentence "summary" and a "target" saying where the suggestion belongs, with the matching field:
+- "background": {"summary": "...", "target": "background", "background": "paragraph to add to the character's background"}
+- "attribute": {"summary": "...", "target": "attribute", "attribute_key": "personality", "attribute_value": "..."}
+- "relationship": {"summary": "...", "target": "relationship", "relationship": {"character": "exact name of an existing character", "relation_type": "rival", "description": "...", "direction": "outgoing" | "incoming"}}
+- "lore_entry": {"summary": "...", "target": "lore_entry", "lore_entry": {"title": "...", "category": "location", "content": "..."}}
+%s`, world.String(), formatInput(input), targetNote(grounding))
+}
+
+func targetNote(grounding *DeepDiveContext) string {
+	if grounding == nil || grounding.Character == nil {
+		return `There is no existing character record yet, so use "lore_entry", or give only a "summary" for other ideas.`
+	}
+	return `Background, attribute and relationship suggestions apply to the character to expand.`
+}
+
+// Relationship partners are summarised, not quoted in full.
+const relatedCharacterTokens = 120
+
+// deepDiveSuggestion converts one element of the model's reply into its
+// suggestion text and, when it maps onto a concrete change, a proposal. Plain
+// strings are suggestions without a proposal. ok is false for elements with no
+// usable text.
+func deepDiveSuggestion(element json.RawMessage, grounding *DeepDiveContext) (suggestion string, proposal *models.Proposal, ok bool) {
+	if json.Unmarshal(element, &suggestion) == nil {
+		suggestion = strings.TrimSpace(suggestion)
+		return suggestion, nil, suggestion != ""
+	}
+
+	var reply struct {
+		Summary        string      `json:"summary"`
+		Suggestion     string      `json:"suggestion"`
+		Target         string      `json:"target"`
+		Background     string      `json:"background"`
+		AttributeKey   string      `json:"attribute_key"`
+		AttributeValue interface{} `json:"attribute_value"`
+		Relationship   *struct {
+			Character    string `json:"character"`
+			RelationType string `json:"relation_type"`
+			Description  string `json:"description"`
+			Direction    string `json:"direction"`
+		} `json:"relationship"`
+		LoreEntry *models.ProposedLoreEntry `json:"lore_entry"`
+	}
+	if json.Unmarshal(element, &reply) != nil {
+		return "", nil, false
+	}
+
+	suggestion = strings.TrimSpace(reply.Summary)
+	if suggestion == "" {
+		suggestion = strings.TrimSpace(reply.Suggestion)
+	}
+
+	var character *models.Character
+	if grounding != nil {
+		character = grounding.Character
+	}
+
+	p := &models.Proposal{Kind: strings.ToLower(strings.TrimSpace(reply.Target))}
+	switch p.Kind {
+	case models.ProposalBackground:
+		p.Background = strings.TrimSpace(reply.Background)
+		if character == nil || p.Background == "" {
+			p = nil
+			break
+		}
+		p.CharacterID = character.ID
+
+	case models.ProposalAttribute:
+		p.AttributeKey = strings.TrimSpace(reply.AttributeKey)
+		if character == nil || p.AttributeKey == "" || reply.AttributeValue == nil {
+			p = nil
+			break
+		}
+		p.CharacterID = character.ID
+		p.AttributeValue = reply.AttributeValue
+
+	case models.ProposalRelationship:
+		r := reply.Relationship
+		if character == nil || r == nil || strings.TrimSpace(r.RelationType) == "" {
+			p = nil
+			break
+		}
+		other := grounding.characterNamed(r.Character)
+		if other == nil || other.ID == character.ID {
+			// Relationships to characters that do not exist yet stay as text
+			p = nil
+			break
+		}
+		p.CharacterID = character.ID
+		p.Relationship = &models.ProposedRelationship{
+			SourceCharacterID: character.ID,
+			TargetCharacterID: other.ID,
+			RelationType:      strings.TrimSpace(r.RelationType),
+			Description:       strings.TrimSpace(r.Description),
+		}
+		if strings.EqualFold(strings.TrimSpace(r.Direction), "incoming") {
+			p.Relationship.SourceCharacterID, p.Relationship.TargetCharacterID = other.ID, character.ID
+		}
+
+	case models.ProposalLoreEntry:
+		l := reply.LoreEntry
+		if l == nil || strings.TrimSpace(l.Title) == "" || strings.TrimSpace(l.Content) == "" {
+			p = nil
+			break
+		}
+		p.LoreEntry = &models.ProposedLoreEntry{
+			Title:    strings.TrimSpace(l.Title),
+			Category: strings.TrimSpace(l.Category),
+			Content:  strings.TrimSpace(l.Content),
+		}
+		if suggestion == "" {
+			suggestion = p.LoreEntry.Title
+		}
+
+	default:
+		p = nil
+	}
+
+	if suggestion == "" && p != nil && p.Kind == models.ProposalBackground {
+		suggestion = p.Background
+	}
+	if suggestion == "" {
+		return "", nil, false
+	}
+	if p != nil {
+		p.ID = uuid.NewString()
+		p.Summary = suggestion
+	}
+	return suggestion, p, true
+}
+
+// characterNamed finds a campaign character by name, ignoring case and
+// surrounding whitespace.
+func (g *DeepDiveContext) characterNamed(name string) *models.Character {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil
+	}
+	for i := range g.Characters {
+		if strings.EqualFold(strings.TrimSpace(g.Characters[i].Name), name) {
+			return &g.Characters[i]
+		}
+	}
+	return nil
+}
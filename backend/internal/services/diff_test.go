@@ -0,0 +1,84 @@
+package services
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(text string) []string {
+		if text == "" {
+			return nil
+		}
+		return strings.Split(text, " ")
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{
+			name: "identical",
+			a:    "one two three",
+			b:    "one two three",
+			want: "",
+		},
+		{
+			name: "change with context",
+			a:    "one two three four five six seven",
+			b:    "one two three FOUR five six seven",
+			want: "@@ -1,7 +1,7 @@\n one\n two\n three\n-four\n+FOUR\n five\n six\n seven\n",
+		},
+		{
+			name: "distant changes get a hunk each",
+			a:    "1 2 3 4 5 6 7 8 9 10 11 12",
+			b:    "X 2 3 4 5 6 7 8 9 10 11 Y",
+			want: "@@ -1,4 +1,4 @@\n-1\n+X\n 2\n 3\n 4\n" +
+				"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+Y\n",
+		},
+		{
+			name: "close changes share a hunk",
+			a:    "1 2 3 4 5 6 7 8",
+			b:    "1 A 3 4 5 6 B 8",
+			want: "@@ -1,8 +1,8 @@\n 1\n-2\n+A\n 3\n 4\n 5\n 6\n-7\n+B\n 8\n",
+		},
+		{
+			name: "append",
+			a:    "1 2 3 4 5",
+			b:    "1 2 3 4 5 6",
+			want: "@@ -3,3 +3,4 @@\n 3\n 4\n 5\n+6\n",
+		},
+		{
+			name: "from nothing",
+			a:    "",
+			b:    "x y",
+			want: "@@ -0,0 +1,2 @@\n+x\n+y\n",
+		},
+		{
+			name: "to nothing",
+			a:    "x",
+			b:    "",
+			want: "@@ -1 +0,0 @@\n-x\n",
+		},
+		{
+			// The longest common subsequence keeps b and d, so only a, c and e move
+			name: "common subsequence",
+			a:    "a b c d",
+			b:    "b d e",
+			want: "@@ -1,4 +1,3 @@\n-a\n b\n-c\n d\n+e\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if want != "" {
+				want = "--- from\n+++ to\n" + want
+			}
+			if got := unifiedDiff("from", "to", lines(tt.a), lines(tt.b)); got != want {
+				t.Errorf("diff =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
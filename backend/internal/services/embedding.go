@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// EmbeddingDimensions matches the vector(1536) columns in database/init.sql.
+const EmbeddingDimensions = 1536
+
+// Embedder turns text into a vector for similarity search.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+}
+
+// NewEmbedderFromEnv picks the embedder from EMBEDDING_PROVIDER ("http" or
+// "hashing"). When unset, the HTTP provider is used if EMBEDDING_API_KEY is set
+// and the local hashing embedder otherwise.
+func NewEmbedderFromEnv() Embedder {
+	provider := os.Getenv("EMBEDDING_PROVIDER")
+	if provider == "" {
+		provider = "hashing"
+		if os.Getenv("EMBEDDING_API_KEY") != "" {
+			provider = "http"
+		}
+	}
+
+	switch provider {
+	case "http":
+		return NewHTTPEmbedder(os.Getenv("EMBEDDING_API_URL"), os.Getenv("EMBEDDING_API_KEY"), os.Getenv("EMBEDDING_MODEL"))
+	case "hashing":
+		return NewHashingEmbedder(EmbeddingDimensions)
+	default:
+		log.Printf("Unknown EMBEDDING_PROVIDER %q, falling back to hashing embedder", provider)
+		return NewHashingEmbedder(EmbeddingDimensions)
+	}
+}
+
+// HTTPEmbedder calls an OpenAI-compatible /embeddings endpoint.
+type HTTPEmbedder struct {
+	baseURL string
+	apiKey  string
+	model   string
+	client  *http.Client
+}
+
+func NewHTTPEmbedder(baseURL, apiKey, model string) *HTTPEmbedder {
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+	return &HTTPEmbedder{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		apiKey:  apiKey,
+		model:   model,
+		client:  &http.Client{},
+	}
+}
+
+type embeddingRequest struct {
+	Model      string `json:"model"`
+	Input      string `json:"input"`
+	Dimensions int    `json:"dimensions,omitempty"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+func (e *HTTPEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	jsonData, err := json.Marshal(embeddingRequest{
+		Model:      e.model,
+		Input:      text,
+		Dimensions: EmbeddingDimensions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding API error: %s", string(body))
+	}
+
+	var embeddingResp embeddingResponse
+	if err := json.Unmarshal(body, &embeddingResp); err != nil {
+		return nil, err
+	}
+
+	if len(embeddingResp.Data) == 0 {
+		return nil, fmt.Errorf("empty response from embedding API")
+	}
+
+	return embeddingResp.Data[0].Embedding, nil
+}
+
+// HashingEmbedder is a deterministic bag-of-words embedder using the hashing
+// trick. It needs no network access, so it is used offline and in tests; the
+// vectors only capture shared vocabulary, not meaning.
+type HashingEmbedder struct {
+	dimensions int
+}
+
+func NewHashingEmbedder(dimensions int) *HashingEmbedder {
+	return &HashingEmbedder{dimensions: dimensions}
+}
+
+func (e *HashingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	vector := make([]float32, e.dimensions)
+	for _, token := range Tokenize(text) {
+		h := fnv.New64a()
+		h.Write([]byte(token))
+		sum := h.Sum64()
+
+		sign := float32(1)
+		if sum&(1<<63) != 0 {
+			sign = -1
+		}
+		vector[sum%uint64(e.dimensions)] += sign
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm > 0 {
+		scale := float32(1 / math.Sqrt(norm))
+		for i := range vector {
+			vector[i] *= scale
+		}
+	}
+
+	return vector, nil
+}
+
+// Tokenize lowercases text and splits it into words. Han, Hiragana and
+// Katakana runs have no spaces, so they are split into overlapping character
+// bigrams instead.
+func Tokenize(text string) []string {
+	var tokens []string
+	var word []rune
+	var cjk []rune
+
+	flushWord := func() {
+		if len(word) > 0 {
+			tokens = append(tokens, string(word))
+			word = word[:0]
+		}
+	}
+	flushCJK := func() {
+		switch {
+		case len(cjk) == 1:
+			tokens = append(tokens, string(cjk))
+		case len(cjk) > 1:
+			for i := 0; i+1 < len(cjk); i++ {
+				tokens = append(tokens, string(cjk[i:i+2]))
+			}
+		}
+		cjk = cjk[:0]
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+
+	return tokens
+}
+
+// CharacterEmbeddingText is the text a character's embedding is computed from.
+func CharacterEmbeddingText(character *models.Character) string {
+	var b strings.Builder
+	b.WriteString(character.Name)
+	if character.Role != "" {
+		b.WriteString(" (" + character.Role + ")")
+	}
+	b.WriteString("\n")
+
+	keys := make([]string, 0, len(character.Attributes))
+	for key := range character.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "%s: %v\n", key, character.Attributes[key])
+	}
+
+	b.WriteString(character.Background)
+	return strings.TrimSpace(b.String())
+}
+
+// LoreEntryEmbeddingText is the text a lore entry's embedding is computed from.
+func LoreEntryEmbeddingText(loreEntry *models.LoreEntry) string {
+	var b strings.Builder
+	b.WriteString(loreEntry.Title)
+	if loreEntry.Category != "" {
+		b.WriteString(" [" + loreEntry.Category + "]")
+	}
+	b.WriteString("\n")
+	b.WriteString(loreEntry.Content)
+	return strings.TrimSpace(b.String())
+}
+
+// setEmbedding embeds text and saves it with set, for rows written outside the
+// CRUD handlers. Failures are logged only; cmd/backfill-embeddings fills in
+// what is missed here.
+func setEmbedding(ctx context.Context, embedder Embedder, text string, set func(embedding []float32) error) {
+	if embedder == nil {
+		return
+	}
+
+	embedding, err := embedder.Embed(ctx, text)
+	if err == nil {
+		err = set(embedding)
+	}
+	if err != nil {
+		log.Printf("Embedding error: %v", err)
+	}
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// Formats the relationship graph can be exported in.
+const (
+	GraphFormatGraphML   = "graphml"
+	GraphFormatDOT       = "dot"
+	GraphFormatCytoscape = "cytoscape"
+)
+
+// graphAttributePrefix namespaces character attributes in the flat formats
+// (GraphML and DOT), where an attribute such as "label" or "shape" would
+// otherwise clash with what the format or tool gives meaning to.
+const graphAttributePrefix = "attr_"
+
+// Graph is a campaign's relationship map: characters are nodes and
+// relationships directed edges from source to target.
+type Graph struct {
+	Campaign      *models.Campaign
+	Characters    []models.Character
+	Relationships []models.Relationship
+}
+
+type GraphService struct {
+	store store.Store
+}
+
+func NewGraphService(s store.Store) *GraphService {
+	return &GraphService{store: s}
+}
+
+// Load reads the characters and relationships of a campaign that its role
+// can see. Relationships whose characters are missing are left out.
+func (g *GraphService) Load(ctx context.Context, campaign *models.Campaign) (*Graph, error) {
+	characters, err := g.store.ListCharacters(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := g.store.ListRelationships(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	visibility := NewVisibility(campaign.Role, characters)
+	characters = visibility.Characters(characters)
+	relationships = visibility.Relationships(relationships)
+
+	known := make(map[string]bool, len(characters))
+	for _, c := range characters {
+		known[c.ID] = true
+	}
+	graph := &Graph{Campaign: campaign, Characters: characters, Relationships: []models.Relationship{}}
+	for _, r := range relationships {
+		if known[r.SourceCharacterID] && known[r.TargetCharacterID] {
+			graph.Relationships = append(graph.Relationships, r)
+		}
+	}
+	return graph, nil
+}
+
+// Cytoscape renders the graph as Cytoscape.js elements JSON.
+func (g *Graph) Cytoscape() *models.CytoscapeGraph {
+	result := &models.CytoscapeGraph{
+		Elements: models.CytoscapeElements{
+			Nodes: make([]models.CytoscapeNode, len(g.Characters)),
+			Edges: make([]models.CytoscapeEdge, len(g.Relationships)),
+		},
+	}
+	for i, c := range g.Characters {
+		attributes := c.Attributes
+		if attributes == nil {
+			attributes = map[string]interface{}{}
+		}
+		result.Elements.Nodes[i].Data = models.CytoscapeNodeData{
+			ID:         c.ID,
+			Label:      c.Name,
+			Role:       c.Role,
+			Attributes: attributes,
+		}
+	}
+	for i, r := range g.Relationships {
+		result.Elements.Edges[i].Data = models.CytoscapeEdgeData{
+			ID:           r.ID,
+			Source:       r.SourceCharacterID,
+			Target:       r.TargetCharacterID,
+			Label:        r.RelationType,
+			RelationType: r.RelationType,
+			Description:  r.Description,
+		}
+	}
+	return result
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// GraphML renders the graph as GraphML, which Gephi, yEd and most graph
+// libraries read. Each character attribute gets its own key, typed as a
+// number or boolean when every character's value is one.
+func (g *Graph) GraphML() ([]byte, error) {
+	doc := graphMLDocument{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", Name: "label", Type: "string"},
+			{ID: "role", For: "node", Name: "role", Type: "string"},
+			{ID: "relation_type", For: "edge", Name: "relation_type", Type: "string"},
+			{ID: "description", For: "edge", Name: "description", Type: "string"},
+		},
+		Graph: graphMLGraph{ID: g.Campaign.ID, EdgeDefault: "directed"},
+	}
+
+	keys := g.attributeKeys()
+	keyIDs := make(map[string]string, len(keys))
+	for i, key := range keys {
+		keyIDs[key] = fmt.Sprintf("a%d", i)
+		doc.Keys = append(doc.Keys, graphMLKey{
+			ID:   keyIDs[key],
+			For:  "node",
+			Name: graphAttributePrefix + key,
+			Type: g.attributeType(key),
+		})
+	}
+
+	for _, c := range g.Characters {
+		node := graphMLNode{ID: c.ID, Data: []graphMLData{{Key: "label", Value: c.Name}, {Key: "role", Value: c.Role}}}
+		for _, key := range sortedKeys(c.Attributes) {
+			// A null is left out like a missing value, as "" is no valid double or boolean
+			if c.Attributes[key] == nil {
+				continue
+			}
+			node.Data = append(node.Data, graphMLData{Key: keyIDs[key], Value: graphValue(c.Attributes[key])})
+		}
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node)
+	}
+	for _, r := range g.Relationships {
+		edge := graphMLEdge{ID: r.ID, Source: r.SourceCharacterID, Target: r.TargetCharacterID, Data: []graphMLData{{Key: "relation_type", Value: r.RelationType}}}
+		if r.Description != "" {
+			edge.Data = append(edge.Data, graphMLData{Key: "description", Value: r.Description})
+		}
+		doc.Graph.Edges = append(doc.Graph.Edges, edge)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// DOT renders the graph in Graphviz's DOT language, labelling nodes with the
+// character's name and edges with the relation type.
+func (g *Graph) DOT() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotID(g.Campaign.Title))
+	for _, c := range g.Characters {
+		fmt.Fprintf(&b, "  %s [label=%s, role=%s", dotID(c.ID), dotID(c.Name), dotID(c.Role))
+		for _, key := range sortedKeys(c.Attributes) {
+			fmt.Fprintf(&b, ", %s=%s", dotID(graphAttributePrefix+key), dotID(graphValue(c.Attributes[key])))
+		}
+		b.WriteString("];\n")
+	}
+	for _, r := range g.Relationships {
+		fmt.Fprintf(&b, "  %s -> %s [label=%s, relation_type=%s", dotID(r.SourceCharacterID), dotID(r.TargetCharacterID), dotID(r.RelationType), dotID(r.RelationType))
+		if r.Description != "" {
+			fmt.Fprintf(&b, ", description=%s", dotID(r.Description))
+		}
+		b.WriteString("];\n")
+	}
+	b.WriteString("}\n")
+	return []byte(b.String())
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
+
+func (g *Graph) attributeKeys() []string {
+	seen := map[string]interface{}{}
+	for _, c := range g.Characters {
+		for key := range c.Attributes {
+			seen[key] = nil
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// attributeType is the GraphML type of an attribute across all characters
+// that have it set to something other than null.
+func (g *Graph) attributeType(key string) string {
+	numbers, booleans, set := true, true, false
+	for _, c := range g.Characters {
+		value := c.Attributes[key]
+		if value == nil {
+			continue
+		}
+		set = true
+		switch value.(type) {
+		case float64:
+			booleans = false
+		case bool:
+			numbers = false
+		default:
+			numbers, booleans = false, false
+		}
+	}
+	switch {
+	case !set:
+		return "string"
+	case numbers:
+		return "double"
+	case booleans:
+		return "boolean"
+	default:
+		return "string"
+	}
+}
+
+// graphValue writes an attribute value as text: strings as they are, numbers
+// without a trailing ".0", and lists and objects as JSON.
+func graphValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(encoded)
+	}
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+var ErrCharacterNotInGraph = errors.New("character not found in campaign")
+
+// graphIndex is the undirected view of a Graph used by the analyses: who is
+// related to whom, regardless of which side the relationship was recorded on.
+type graphIndex struct {
+	graph     *Graph
+	index     map[string]int
+	neighbors [][]int           // sorted, without duplicates
+	weights   []map[int]float64 // relationships between each pair
+	in, out   []int
+}
+
+func newGraphIndex(g *Graph) *graphIndex {
+	n := len(g.Characters)
+	x := &graphIndex{
+		graph:     g,
+		index:     make(map[string]int, n),
+		neighbors: make([][]int, n),
+		weights:   make([]map[int]float64, n),
+		in:        make([]int, n),
+		out:       make([]int, n),
+	}
+	for i, c := range g.Characters {
+		x.index[c.ID] = i
+		x.weights[i] = map[int]float64{}
+	}
+	for _, r := range g.Relationships {
+		s, t := x.index[r.SourceCharacterID], x.index[r.TargetCharacterID]
+		if s == t {
+			continue
+		}
+		x.out[s]++
+		x.in[t]++
+		x.weights[s][t]++
+		x.weights[t][s]++
+	}
+	for i := range x.neighbors {
+		for j := range x.weights[i] {
+			x.neighbors[i] = append(x.neighbors[i], j)
+		}
+		sort.Ints(x.neighbors[i])
+	}
+	return x
+}
+
+func (x *graphIndex) character(i int) models.GraphCharacter {
+	c := x.graph.Characters[i]
+	return models.GraphCharacter{ID: c.ID, Name: c.Name, Role: c.Role}
+}
+
+// group lists characters by name.
+func (x *graphIndex) group(members []int) models.GraphGroup {
+	group := models.GraphGroup{Size: len(members), Characters: make([]models.GraphCharacter, len(members))}
+	for i, m := range members {
+		group.Characters[i] = x.character(m)
+	}
+	sort.SliceStable(group.Characters, func(i, j int) bool { return group.Characters[i].Name < group.Characters[j].Name })
+	return group
+}
+
+// Analyze computes who is central to the relationship map and how it falls
+// apart into components and communities. Relationships count in both
+// directions.
+func (g *Graph) Analyze() *models.GraphAnalysis {
+	x := newGraphIndex(g)
+	n := len(g.Characters)
+
+	analysis := &models.GraphAnalysis{
+		CharacterCount:    n,
+		RelationshipCount: len(g.Relationships),
+		Centrality:        make([]models.CharacterCentrality, n),
+		Components:        []models.GraphGroup{},
+		Isolated:          []models.GraphCharacter{},
+		Communities:       []models.GraphGroup{},
+	}
+
+	betweenness := x.betweenness()
+	for i, c := range g.Characters {
+		centrality := models.CharacterCentrality{
+			CharacterID: c.ID,
+			Name:        c.Name,
+			Degree:      len(x.neighbors[i]),
+			InDegree:    x.in[i],
+			OutDegree:   x.out[i],
+			Betweenness: betweenness[i],
+		}
+		if n > 1 {
+			centrality.DegreeCentrality = float64(len(x.neighbors[i])) / float64(n-1)
+		}
+		analysis.Centrality[i] = centrality
+		if len(x.neighbors[i]) == 0 {
+			analysis.Isolated = append(analysis.Isolated, x.character(i))
+		}
+	}
+	sort.SliceStable(analysis.Centrality, func(i, j int) bool {
+		a, b := analysis.Centrality[i], analysis.Centrality[j]
+		if a.Betweenness != b.Betweenness {
+			return a.Betweenness > b.Betweenness
+		}
+		if a.Degree != b.Degree {
+			return a.Degree > b.Degree
+		}
+		return a.Name < b.Name
+	})
+
+	for _, members := range x.components() {
+		analysis.Components = append(analysis.Components, x.group(members))
+	}
+
+	// Isolated characters are communities of one, already listed above
+	communities, modularity := x.communities()
+	for _, members := range communities {
+		if len(members) > 1 {
+			analysis.Communities = append(analysis.Communities, x.group(members))
+		}
+	}
+	analysis.Modularity = modularity
+
+	return analysis
+}
+
+// betweenness is Brandes' algorithm on the undirected graph, normalised to
+// 0-1 by the number of pairs of other characters.
+func (x *graphIndex) betweenness() []float64 {
+	n := len(x.neighbors)
+	result := make([]float64, n)
+	for s := 0; s < n; s++ {
+		var stack []int
+		predecessors := make([][]int, n)
+		paths := make([]float64, n)
+		distance := make([]int, n)
+		for i := range distance {
+			distance[i] = -1
+		}
+		paths[s], distance[s] = 1, 0
+
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			stack = append(stack, v)
+			for _, w := range x.neighbors[v] {
+				if distance[w] < 0 {
+					distance[w] = distance[v] + 1
+					queue = append(queue, w)
+				}
+				if distance[w] == distance[v]+1 {
+					paths[w] += paths[v]
+					predecessors[w] = append(predecessors[w], v)
+				}
+			}
+		}
+
+		dependency := make([]float64, n)
+		for i := len(stack) - 1; i >= 0; i-- {
+			w := stack[i]
+			for _, v := range predecessors[w] {
+				dependency[v] += paths[v] / paths[w] * (1 + dependency[w])
+			}
+			if w != s {
+				result[w] += dependency[w]
+			}
+		}
+	}
+
+	// Every pair was counted from both ends
+	if n > 2 {
+		for i := range result {
+			result[i] /= float64((n - 1) * (n - 2))
+		}
+	} else {
+		for i := range result {
+			result[i] = 0
+		}
+	}
+	return result
+}
+
+// components returns the connected components, largest first.
+func (x *graphIndex) components() [][]int {
+	seen := make([]bool, len(x.neighbors))
+	var components [][]int
+	for start := range x.neighbors {
+		if seen[start] {
+			continue
+		}
+		seen[start] = true
+		component := []int{start}
+		for i := 0; i < len(component); i++ {
+			for _, w := range x.neighbors[component[i]] {
+				if !seen[w] {
+					seen[w] = true
+					component = append(component, w)
+				}
+			}
+		}
+		components = append(components, component)
+	}
+	sort.SliceStable(components, func(i, j int) bool { return len(components[i]) > len(components[j]) })
+	return components
+}
+
+// communities groups characters with the Louvain method: characters move to
+// the neighbouring community that most improves modularity, communities are
+// merged into single nodes, and this repeats until nothing moves. Nodes are
+// visited in a fixed order so the result is stable between requests. Returns
+// the communities, largest first, and their modularity.
+func (x *graphIndex) communities() ([][]int, float64) {
+	n := len(x.neighbors)
+	membership := make([]int, n)
+	for i := range membership {
+		membership[i] = i
+	}
+
+	// Adjacency with internal weight on the diagonal counted twice, so a
+	// node's degree is always the sum of its row
+	adjacency := make([]map[int]float64, n)
+	for i := range adjacency {
+		adjacency[i] = make(map[int]float64, len(x.weights[i]))
+		for j, w := range x.weights[i] {
+			adjacency[i][j] = w
+		}
+	}
+
+	for {
+		community, moved := louvainMoves(adjacency)
+		if !moved {
+			break
+		}
+
+		// Renumber communities and collapse each into one node
+		renumber := map[int]int{}
+		for _, c := range community {
+			if _, ok := renumber[c]; !ok {
+				renumber[c] = len(renumber)
+			}
+		}
+		for i := range membership {
+			membership[i] = renumber[community[membership[i]]]
+		}
+		aggregated := make([]map[int]float64, len(renumber))
+		for i := range aggregated {
+			aggregated[i] = map[int]float64{}
+		}
+		for i, row := range adjacency {
+			for j, w := range row {
+				aggregated[renumber[community[i]]][renumber[community[j]]] += w
+			}
+		}
+		adjacency = aggregated
+	}
+
+	groups := map[int][]int{}
+	var order []int
+	for i, c := range membership {
+		if _, ok := groups[c]; !ok {
+			order = append(order, c)
+		}
+		groups[c] = append(groups[c], i)
+	}
+	communities := make([][]int, len(order))
+	for i, c := range order {
+		communities[i] = groups[c]
+	}
+	sort.SliceStable(communities, func(i, j int) bool { return len(communities[i]) > len(communities[j]) })
+
+	return communities, x.modularity(membership)
+}
+
+// louvainMoves runs the first phase of Louvain on a weighted adjacency and
+// returns each node's community and whether any node changed community.
+func louvainMoves(adjacency []map[int]float64) ([]int, bool) {
+	n := len(adjacency)
+	community := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n)
+	var m2 float64
+	for i, row := range adjacency {
+		community[i] = i
+		for _, w := range row {
+			degree[i] += w
+		}
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return community, false
+	}
+
+	movedAny := false
+	for moved := true; moved; {
+		moved = false
+		for i := 0; i < n; i++ {
+			// Weight from i into each neighbouring community
+			links := map[int]float64{}
+			for j, w := range adjacency[i] {
+				if j != i {
+					links[community[j]] += w
+				}
+			}
+
+			current := community[i]
+			total[current] -= degree[i]
+			best, bestGain := current, links[current]-total[current]*degree[i]/m2
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			for _, c := range candidates {
+				if gain := links[c] - total[c]*degree[i]/m2; gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			total[best] += degree[i]
+			if best != current {
+				community[i] = best
+				moved, movedAny = true, true
+			}
+		}
+	}
+	return community, movedAny
+}
+
+// modularity of a division of the original graph into communities.
+func (x *graphIndex) modularity(membership []int) float64 {
+	var m2 float64
+	internal := map[int]float64{}
+	total := map[int]float64{}
+	for i, row := range x.weights {
+		for j, w := range row {
+			m2 += w
+			total[membership[i]] += w
+			if membership[i] == membership[j] {
+				internal[membership[i]] += w
+			}
+		}
+	}
+	if m2 == 0 {
+		return 0
+	}
+	var q float64
+	for c, t := range total {
+		q += internal[c]/m2 - (t/m2)*(t/m2)
+	}
+	return q
+}
+
+// ShortestPath finds the fewest relationships connecting two characters,
+// following relationships in either direction. Found is false when they are
+// not connected; an unknown character is ErrCharacterNotInGraph.
+func (g *Graph) ShortestPath(fromID, toID string) (*models.GraphPath, error) {
+	x := newGraphIndex(g)
+	from, ok := x.index[fromID]
+	if !ok {
+		return nil, ErrCharacterNotInGraph
+	}
+	to, ok := x.index[toID]
+	if !ok {
+		return nil, ErrCharacterNotInGraph
+	}
+
+	path := &models.GraphPath{Characters: []models.GraphCharacter{}, Relationships: []models.Relationship{}}
+
+	previous := make([]int, len(x.neighbors))
+	for i := range previous {
+		previous[i] = -1
+	}
+	previous[from] = from
+	queue := []int{from}
+	for len(queue) > 0 && previous[to] < 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, w := range x.neighbors[v] {
+			if previous[w] < 0 {
+				previous[w] = v
+				queue = append(queue, w)
+			}
+		}
+	}
+	if previous[to] < 0 {
+		return path, nil
+	}
+
+	var nodes []int
+	for v := to; v != from; v = previous[v] {
+		nodes = append(nodes, v)
+	}
+	nodes = append(nodes, from)
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+
+	path.Found = true
+	path.Length = len(nodes) - 1
+	for i, v := range nodes {
+		path.Characters = append(path.Characters, x.character(v))
+		if i > 0 {
+			path.Relationships = append(path.Relationships, g.relationshipBetween(g.Characters[nodes[i-1]].ID, g.Characters[v].ID))
+		}
+	}
+	return path, nil
+}
+
+// relationshipBetween returns the relationship from a to b, or else from b to
+// a.
+func (g *Graph) relationshipBetween(a, b string) models.Relationship {
+	var reverse models.Relationship
+	for _, r := range g.Relationships {
+		if r.SourceCharacterID == a && r.TargetCharacterID == b {
+			return r
+		}
+		if r.SourceCharacterID == b && r.TargetCharacterID == a {
+			reverse = r
+		}
+	}
+	return reverse
+}
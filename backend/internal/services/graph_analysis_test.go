@@ -0,0 +1,211 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// testGraph builds a graph of characters named by their IDs, related by
+// "source>target" edges.
+func testGraph(names string, edges ...string) *Graph {
+	g := &Graph{}
+	for _, name := range strings.Fields(names) {
+		g.Characters = append(g.Characters, models.Character{ID: name, Name: name})
+	}
+	for i, edge := range edges {
+		source, target, _ := strings.Cut(edge, ">")
+		g.Relationships = append(g.Relationships, models.Relationship{
+			ID: "r" + string(rune('a'+i)), SourceCharacterID: source, TargetCharacterID: target, RelationType: "knows",
+		})
+	}
+	return g
+}
+
+// groupNames lists each group's character names, joined by spaces.
+func groupNames(groups []models.GraphGroup) []string {
+	var result []string
+	for _, group := range groups {
+		var names []string
+		for _, c := range group.Characters {
+			names = append(names, c.Name)
+		}
+		result = append(result, strings.Join(names, " "))
+	}
+	return result
+}
+
+func TestAnalyzeBetweenness(t *testing.T) {
+	tests := []struct {
+		name  string
+		graph *Graph
+		want  map[string]float64
+	}{
+		{
+			// B and D each carry 3 of the 6 pairs of others, C carries 4
+			name:  "path",
+			graph: testGraph("A B C D E", "A>B", "B>C", "C>D", "D>E"),
+			want:  map[string]float64{"A": 0, "B": 0.5, "C": 4.0 / 6, "D": 0.5, "E": 0},
+		},
+		{
+			name:  "star",
+			graph: testGraph("Hub L1 L2 L3 L4", "Hub>L1", "Hub>L2", "L3>Hub", "L4>Hub"),
+			want:  map[string]float64{"Hub": 1, "L1": 0, "L2": 0, "L3": 0, "L4": 0},
+		},
+		{
+			// Two shortest paths between opposite corners, so each corner gets
+			// half of one pair out of three
+			name:  "cycle",
+			graph: testGraph("A B C D", "A>B", "B>C", "C>D", "D>A"),
+			want:  map[string]float64{"A": 1.0 / 6, "B": 1.0 / 6, "C": 1.0 / 6, "D": 1.0 / 6},
+		},
+		{
+			// The bridge ends carry the 3x4 pairs between the cliques, out of
+			// the 28 pairs of other characters including the hermit
+			name:  "two cliques joined by a bridge",
+			graph: bridgedCliques(),
+			want:  map[string]float64{"A1": 12.0 / 28, "B1": 12.0 / 28, "A2": 0, "B4": 0, "Hermit": 0},
+		},
+		{
+			name:  "two characters",
+			graph: testGraph("A B", "A>B"),
+			want:  map[string]float64{"A": 0, "B": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := tt.graph.Analyze()
+			got := map[string]float64{}
+			for _, c := range analysis.Centrality {
+				got[c.Name] = c.Betweenness
+			}
+			for name, want := range tt.want {
+				if math.Abs(got[name]-want) > 1e-9 {
+					t.Errorf("betweenness of %s = %v, want %v", name, got[name], want)
+				}
+			}
+		})
+	}
+}
+
+// bridgedCliques is two cliques of four joined by A1-B1, and a hermit who
+// knows nobody.
+func bridgedCliques() *Graph {
+	var edges []string
+	for _, clique := range []string{"A", "B"} {
+		for i := 1; i <= 4; i++ {
+			for j := i + 1; j <= 4; j++ {
+				edges = append(edges, clique+string(rune('0'+i))+">"+clique+string(rune('0'+j)))
+			}
+		}
+	}
+	edges = append(edges, "A1>B1")
+	return testGraph("A1 A2 A3 A4 B1 B2 B3 B4 Hermit", edges...)
+}
+
+func TestAnalyzeStar(t *testing.T) {
+	analysis := testGraph("Hub L1 L2 L3 L4", "Hub>L1", "Hub>L2", "L3>Hub", "L4>Hub").Analyze()
+
+	hub := analysis.Centrality[0]
+	if hub.Name != "Hub" || hub.Degree != 4 || hub.OutDegree != 2 || hub.InDegree != 2 || hub.DegreeCentrality != 1 {
+		t.Errorf("most central = %+v, want the hub with degree 4", hub)
+	}
+	// Equally central leaves are ordered by name
+	var rest []string
+	for _, c := range analysis.Centrality[1:] {
+		rest = append(rest, c.Name)
+		if c.Degree != 1 || c.DegreeCentrality != 0.25 {
+			t.Errorf("leaf %+v", c)
+		}
+	}
+	if strings.Join(rest, " ") != "L1 L2 L3 L4" {
+		t.Errorf("leaves ordered %q", rest)
+	}
+}
+
+func TestAnalyzeComponentsAndCommunities(t *testing.T) {
+	analysis := bridgedCliques().Analyze()
+
+	if analysis.CharacterCount != 9 || analysis.RelationshipCount != 13 {
+		t.Errorf("counts = %d characters, %d relationships", analysis.CharacterCount, analysis.RelationshipCount)
+	}
+	if got := groupNames(analysis.Components); strings.Join(got, "|") != "A1 A2 A3 A4 B1 B2 B3 B4|Hermit" {
+		t.Errorf("components = %q", got)
+	}
+	if len(analysis.Isolated) != 1 || analysis.Isolated[0].Name != "Hermit" {
+		t.Errorf("isolated = %+v", analysis.Isolated)
+	}
+
+	// Each clique has 6 of the 13 relationships and half the degree:
+	// 2 * (6/13 - (13/26)^2)
+	if got := groupNames(analysis.Communities); strings.Join(got, "|") != "A1 A2 A3 A4|B1 B2 B3 B4" {
+		t.Errorf("communities = %q", got)
+	}
+	if want := 2 * (6.0/13 - 0.25); math.Abs(analysis.Modularity-want) > 1e-9 {
+		t.Errorf("modularity = %v, want %v", analysis.Modularity, want)
+	}
+}
+
+func TestAnalyzeWithoutRelationships(t *testing.T) {
+	analysis := testGraph("A B").Analyze()
+	if len(analysis.Components) != 2 || len(analysis.Isolated) != 2 || len(analysis.Communities) != 0 || analysis.Modularity != 0 {
+		t.Errorf("analysis = %+v", analysis)
+	}
+
+	empty := testGraph("").Analyze()
+	if empty.CharacterCount != 0 || len(empty.Centrality) != 0 || empty.Components == nil || empty.Communities == nil {
+		t.Errorf("empty analysis = %+v", empty)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	g := testGraph("A B C D E Hermit", "A>B", "C>B", "C>D", "D>E", "A>E")
+
+	tests := []struct {
+		name         string
+		from, to     string
+		wantFound    bool
+		wantNames    string
+		wantRelation []string
+	}{
+		{name: "direct", from: "A", to: "B", wantFound: true, wantNames: "A B", wantRelation: []string{"ra"}},
+		// Relationships are followed against their direction too
+		{name: "against the direction", from: "B", to: "D", wantFound: true, wantNames: "B C D", wantRelation: []string{"rb", "rc"}},
+		{name: "shorter way round", from: "B", to: "E", wantFound: true, wantNames: "B A E", wantRelation: []string{"ra", "re"}},
+		{name: "to itself", from: "C", to: "C", wantFound: true, wantNames: "C"},
+		{name: "not connected", from: "A", to: "Hermit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := g.ShortestPath(tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if path.Found != tt.wantFound {
+				t.Fatalf("found = %v, want %v", path.Found, tt.wantFound)
+			}
+			var names, relationships []string
+			for _, c := range path.Characters {
+				names = append(names, c.Name)
+			}
+			for _, r := range path.Relationships {
+				relationships = append(relationships, r.ID)
+			}
+			if strings.Join(names, " ") != tt.wantNames || strings.Join(relationships, " ") != strings.Join(tt.wantRelation, " ") {
+				t.Errorf("path = %q via %q, want %q via %q", names, relationships, tt.wantNames, tt.wantRelation)
+			}
+			if tt.wantFound && path.Length != len(path.Characters)-1 {
+				t.Errorf("length = %d for %d characters", path.Length, len(path.Characters))
+			}
+		})
+	}
+
+	if _, err := g.ShortestPath("A", "Nobody"); !errors.Is(err, ErrCharacterNotInGraph) {
+		t.Errorf("unknown character: err = %v, want %v", err, ErrCharacterNotInGraph)
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Features with their own LLM configuration. Each one reads <FEATURE>_LLM_*
+// variables before falling back to the shared LLM_* ones.
+const (
+	FeatureDeepDive    = "deep_dive"
+	FeatureConsistency = "consistency"
+	FeatureExtraction  = "extraction"
+)
+
+// LLMProvider sends a single-turn prompt to a language model and returns the
+// text of its reply.
+type LLMProvider interface {
+	Complete(ctx context.Context, prompt string) (string, error)
+}
+
+// StreamingProvider is implemented by providers that can return a reply
+// incrementally. onDelta receives each piece of text as it arrives; the full
+// reply is returned at the end.
+type StreamingProvider interface {
+	LLMProvider
+	Stream(ctx context.Context, prompt string, onDelta func(text string) error) (string, error)
+}
+
+// streamOrComplete streams when the provider supports it and otherwise delivers
+// the whole reply as a single delta.
+func streamOrComplete(ctx context.Context, provider LLMProvider, prompt string, onDelta func(text string) error) (string, error) {
+	if streaming, ok := provider.(StreamingProvider); ok {
+		return streaming.Stream(ctx, prompt, onDelta)
+	}
+
+	response, err := provider.Complete(ctx, prompt)
+	if err != nil {
+		return "", err
+	}
+	if err := onDelta(response); err != nil {
+		return "", err
+	}
+	return response, nil
+}
+
+// LLMConfig configures one provider instance.
+type LLMConfig struct {
+	Provider    string // anthropic, openai or fake
+	BaseURL     string
+	APIKey      string
+	Model       string
+	Temperature *float64
+	MaxTokens   int
+	// Timeout bounds a whole call, streamed or not
+	Timeout time.Duration
+}
+
+// LLMConfigFromEnv reads the configuration for feature. For a key such as
+// MODEL it checks DEEP_DIVE_LLM_MODEL, then LLM_MODEL. ANTHROPIC_API_KEY is
+// still honoured for the Anthropic provider.
+func LLMConfigFromEnv(feature string) LLMConfig {
+	prefix := strings.ToUpper(feature) + "_"
+	get := func(key string) string {
+		if value := os.Getenv(prefix + "LLM_" + key); value != "" {
+			return value
+		}
+		return os.Getenv("LLM_" + key)
+	}
+
+	config := LLMConfig{
+		Provider: get("PROVIDER"),
+		BaseURL:  get("BASE_URL"),
+		APIKey:   get("API_KEY"),
+		Model:    get("MODEL"),
+	}
+	if config.Provider == "" {
+		config.Provider = "anthropic"
+	}
+	if config.APIKey == "" && config.Provider == "anthropic" {
+		config.APIKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if value := get("TEMPERATURE"); value != "" {
+		temperature, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			log.Printf("Ignoring invalid %sLLM_TEMPERATURE %q", prefix, value)
+		} else {
+			config.Temperature = &temperature
+		}
+	}
+	if value := get("MAX_TOKENS"); value != "" {
+		maxTokens, err := strconv.Atoi(value)
+		if err != nil || maxTokens <= 0 {
+			log.Printf("Ignoring invalid %sLLM_MAX_TOKENS %q", prefix, value)
+		} else {
+			config.MaxTokens = maxTokens
+		}
+	}
+
+	if value := get("TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			log.Printf("Ignoring invalid %sLLM_TIMEOUT %q", prefix, value)
+		} else {
+			config.Timeout = timeout
+		}
+	}
+
+	return config
+}
+
+// NewLLMProvider builds the provider described by config.
+func NewLLMProvider(config LLMConfig) (LLMProvider, error) {
+	switch config.Provider {
+	case "anthropic":
+		return NewAnthropicProvider(config), nil
+	case "openai":
+		return NewOpenAIProvider(config), nil
+	case "fake":
+		return NewFakeProvider(os.Getenv("LLM_FAKE_RESPONSE")), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q", config.Provider)
+	}
+}
+
+// FakeProvider replays scripted replies in order, repeating the last one once
+// the script runs out. It records every prompt it receives.
+type FakeProvider struct {
+	mu sync.Mutex
+	// replies holds each reply as the pieces Stream delivers it in
+	replies [][]string
+	prompts []string
+}
+
+// NewFakeProvider scripts whole replies. Stream delivers them a few
+// characters at a time.
+func NewFakeProvider(responses ...string) *FakeProvider {
+	replies := make([][]string, len(responses))
+	for i, response := range responses {
+		replies[i] = splitRunes(response, fakeChunkRunes)
+	}
+	return &FakeProvider{replies: replies}
+}
+
+// NewFakeStreamProvider scripts replies as the exact pieces Stream delivers,
+// so a reply can be split wherever a test needs. Complete returns the pieces
+// joined.
+func NewFakeStreamProvider(replies ...[]string) *FakeProvider {
+	return &FakeProvider{replies: replies}
+}
+
+func (p *FakeProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	chunks, err := p.next(prompt)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(chunks, ""), nil
+}
+
+// Stream replays the next scripted reply piece by piece.
+func (p *FakeProvider) Stream(ctx context.Context, prompt string, onDelta func(text string) error) (string, error) {
+	chunks, err := p.next(prompt)
+	if err != nil {
+		return "", err
+	}
+
+	for _, chunk := range chunks {
+		if err := onDelta(chunk); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(chunks, ""), nil
+}
+
+func (p *FakeProvider) next(prompt string) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.prompts = append(p.prompts, prompt)
+	if len(p.replies) == 0 {
+		return nil, fmt.Errorf("fake provider has no scripted response")
+	}
+
+	reply := p.replies[0]
+	if len(p.replies) > 1 {
+		p.replies = p.replies[1:]
+	}
+	return reply, nil
+}
+
+const fakeChunkRunes = 8
+
+// splitRunes cuts s into pieces of at most n runes.
+func splitRunes(s string, n int) []string {
+	runes := []rune(s)
+	var pieces []string
+	for start := 0; start < len(runes); start += n {
+		pieces = append(pieces, string(runes[start:min(start+n, len(runes))]))
+	}
+	return pieces
+}
+
+// Prompts returns the prompts received so far.
+func (p *FakeProvider) Prompts() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]string(nil), p.prompts...)
+}
+
+// readEventStream calls onData with the payload of each "data:" line of a
+// server-sent event stream until the stream ends or onData returns done.
+func readEventStream(body io.Reader, onData func(data string) (done bool, err error)) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		done, err := onData(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// defaultLLMTimeout bounds a model call when LLM_TIMEOUT is not set.
+const defaultLLMTimeout = 5 * time.Minute
+
+// newLLMHTTPClient returns the client for non-streaming calls, which times out
+// after timeout.
+func newLLMHTTPClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultLLMTimeout
+	}
+	return &http.Client{Timeout: timeout}
+}
+
+// llmStreamHTTPClient is shared by streaming calls. A client timeout would cut
+// off the body while it is still being read, so Stream bounds the call with a
+// context deadline instead.
+var llmStreamHTTPClient = &http.Client{}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AnthropicProvider calls the Anthropic Messages API.
+type AnthropicProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature *float64
+	maxTokens   int
+	timeout     time.Duration
+	client      *http.Client
+}
+
+func NewAnthropicProvider(config LLMConfig) *AnthropicProvider {
+	p := &AnthropicProvider{
+		baseURL:     strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:      config.APIKey,
+		model:       config.Model,
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+		timeout:     config.Timeout,
+		client:      newLLMHTTPClient(config.Timeout),
+	}
+	if p.baseURL == "" {
+		p.baseURL = "https://api.anthropic.com/v1"
+	}
+	if p.model == "" {
+		p.model = "claude-3-5-sonnet-20241022"
+	}
+	if p.maxTokens == 0 {
+		p.maxTokens = 2048
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultLLMTimeout
+	}
+	return p
+}
+
+type ClaudeRequest struct {
+	Model       string          `json:"model"`
+	MaxTokens   int             `json:"max_tokens"`
+	Temperature *float64        `json:"temperature,omitempty"`
+	Messages    []ClaudeMessage `json:"messages"`
+	Stream      bool            `json:"stream,omitempty"`
+}
+
+type ClaudeMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ClaudeResponse struct {
+	Content []struct {
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func (p *AnthropicProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := p.send(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var claudeResp ClaudeResponse
+	if err := json.Unmarshal(body, &claudeResp); err != nil {
+		return "", err
+	}
+
+	if len(claudeResp.Content) == 0 {
+		return "", fmt.Errorf("empty response from Claude")
+	}
+
+	return claudeResp.Content[0].Text, nil
+}
+
+// claudeStreamEvent covers the fields used from the Messages streaming events.
+type claudeStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) Stream(ctx context.Context, prompt string, onDelta func(text string) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	resp, err := p.send(ctx, prompt, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	err = readEventStream(resp.Body, func(data string) (bool, error) {
+		var event claudeStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return false, err
+		}
+
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return false, nil
+			}
+			full.WriteString(event.Delta.Text)
+			return false, onDelta(event.Delta.Text)
+		case "error":
+			return false, fmt.Errorf("API error: %s", event.Error.Message)
+		case "message_stop":
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return full.String(), nil
+}
+
+// send posts the prompt and returns the response once it has a 200 status.
+func (p *AnthropicProvider) send(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	reqBody := ClaudeRequest{
+		Model:       p.model,
+		MaxTokens:   p.maxTokens,
+		Temperature: p.temperature,
+		Messages: []ClaudeMessage{
+			{
+				Role:    "user",
+				Content: prompt,
+			},
+		},
+		Stream: stream,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/messages", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	client := p.client
+	if stream {
+		client = llmStreamHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s", string(body))
+	}
+
+	return resp, nil
+}
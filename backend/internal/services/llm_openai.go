@@ -0,0 +1,178 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider calls an OpenAI-compatible /chat/completions endpoint. Local
+// servers such as llama.cpp and Ollama expose the same API, so pointing
+// BaseURL at them keeps all text on the machine.
+type OpenAIProvider struct {
+	baseURL     string
+	apiKey      string
+	model       string
+	temperature *float64
+	maxTokens   int
+	timeout     time.Duration
+	client      *http.Client
+}
+
+func NewOpenAIProvider(config LLMConfig) *OpenAIProvider {
+	p := &OpenAIProvider{
+		baseURL:     strings.TrimSuffix(config.BaseURL, "/"),
+		apiKey:      config.APIKey,
+		model:       config.Model,
+		temperature: config.Temperature,
+		maxTokens:   config.MaxTokens,
+		timeout:     config.Timeout,
+		client:      newLLMHTTPClient(config.Timeout),
+	}
+	if p.baseURL == "" {
+		p.baseURL = "https://api.openai.com/v1"
+	}
+	if p.model == "" {
+		p.model = "gpt-4o-mini"
+	}
+	if p.maxTokens == 0 {
+		p.maxTokens = 2048
+	}
+	if p.timeout <= 0 {
+		p.timeout = defaultLLMTimeout
+	}
+	return p
+}
+
+type chatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []chatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+func (p *OpenAIProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	resp, err := p.send(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var completion chatCompletionResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return "", err
+	}
+
+	if len(completion.Choices) == 0 {
+		return "", fmt.Errorf("empty response from LLM")
+	}
+
+	return completion.Choices[0].Message.Content, nil
+}
+
+func (p *OpenAIProvider) Stream(ctx context.Context, prompt string, onDelta func(text string) error) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	resp, err := p.send(ctx, prompt, true)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var full strings.Builder
+	err = readEventStream(resp.Body, func(data string) (bool, error) {
+		if data == "[DONE]" {
+			return true, nil
+		}
+
+		var chunk chatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return false, err
+		}
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			return false, nil
+		}
+
+		full.WriteString(chunk.Choices[0].Delta.Content)
+		return false, onDelta(chunk.Choices[0].Delta.Content)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return full.String(), nil
+}
+
+// send posts the prompt and returns the response once it has a 200 status.
+func (p *OpenAIProvider) send(ctx context.Context, prompt string, stream bool) (*http.Response, error) {
+	jsonData, err := json.Marshal(chatCompletionRequest{
+		Model:       p.model,
+		Messages:    []chatMessage{{Role: "user", Content: prompt}},
+		MaxTokens:   p.maxTokens,
+		Temperature: p.temperature,
+		Stream:      stream,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+
+	client := p.client
+	if stream {
+		client = llmStreamHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s", string(body))
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// minNameSimilarity is the edit-distance similarity (0-1) above which a name
+// with a typo or variant spelling still counts as a match.
+const minNameSimilarity = 0.75
+
+// Titles dropped from the front of a name and honorifics dropped from the end,
+// so "Sir Aldo" and "アルドさん" both match "Aldo" / "アルド".
+var (
+	nameTitles     = []string{"the", "sir", "lady", "lord", "dame", "master", "mr", "mrs", "ms", "miss", "dr", "captain", "king", "queen", "prince", "princess"}
+	nameHonorifics = []string{"さん", "さま", "様", "殿", "どの", "くん", "君", "ちゃん", "氏", "卿"}
+)
+
+// nameMatcher resolves names written in free text to known names, such as the
+// campaign's characters or lore entry titles.
+type nameMatcher struct {
+	full  []string   // normalised full name per known name
+	parts [][]string // normalised name parts per known name
+}
+
+func newNameMatcher(names []string) *nameMatcher {
+	m := &nameMatcher{}
+	for _, name := range names {
+		parts := nameParts(name)
+		m.full = append(m.full, strings.Join(parts, ""))
+		m.parts = append(m.parts, parts)
+	}
+	return m
+}
+
+// Match returns the index of the known name that name refers to, or -1 when
+// none is close enough or several are equally close. An exact match wins over
+// a match on one part of the name ("Aria" for "Aria Stormwind"), which wins
+// over a fuzzy match.
+func (m *nameMatcher) Match(name string) int {
+	queryParts := nameParts(name)
+	query := strings.Join(queryParts, "")
+	if query == "" {
+		return -1
+	}
+
+	best, bestScore, tied := -1, 0.0, false
+	for i := range m.full {
+		score := m.score(i, query, queryParts)
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = i, score, false
+		case score == bestScore && score > 0:
+			tied = true
+		}
+	}
+	if tied {
+		return -1
+	}
+	return best
+}
+
+func (m *nameMatcher) score(i int, query string, queryParts []string) float64 {
+	full := m.full[i]
+	if full == "" {
+		return 0
+	}
+	if full == query {
+		return 1
+	}
+
+	for _, part := range m.parts[i] {
+		if len(m.parts[i]) > 1 && part == query && len([]rune(part)) >= 2 {
+			return 0.9
+		}
+	}
+	for _, part := range queryParts {
+		if len(queryParts) > 1 && part == full {
+			return 0.9
+		}
+	}
+
+	similarity := nameSimilarity(full, query)
+	if similarity < minNameSimilarity {
+		return 0
+	}
+	// Kept below the part matches above
+	return 0.8 * similarity
+}
+
+// nameParts splits a name into lower-cased words with titles and honorifics
+// removed. Names written without spaces (as Japanese names usually are) are a
+// single part.
+func nameParts(name string) []string {
+	parts := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+
+	for len(parts) > 1 && isNameTitle(parts[0]) {
+		parts = parts[1:]
+	}
+	if len(parts) > 0 {
+		last := parts[len(parts)-1]
+		for _, honorific := range nameHonorifics {
+			if trimmed := strings.TrimSuffix(last, honorific); trimmed != last && trimmed != "" {
+				parts[len(parts)-1] = trimmed
+				break
+			}
+		}
+	}
+	return parts
+}
+
+func isNameTitle(word string) bool {
+	for _, title := range nameTitles {
+		if word == title {
+			return true
+		}
+	}
+	return false
+}
+
+// nameSimilarity is 1 minus the edit distance between a and b, relative to the
+// longer of the two.
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return 1 - float64(previous[len(rb)])/float64(longest)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNameMatcher(t *testing.T) {
+	known := []string{
+		"Aldo",
+		"Aria Stormwind",
+		"Aria Brightwater",
+		"Captain Bea Harlow",
+		"Bea",
+		"アルド",
+		"Marcus",
+		"Marco",
+		"J Doe",
+	}
+	m := newNameMatcher(known)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Aldo", "Aldo"},
+		{"aldo.", "Aldo"},
+		{"Sir Aldo", "Aldo"},
+		{"the Lord Aldo", "Aldo"},
+		{"アルドさん", "アルド"},
+		{"アルド様", "アルド"},
+		{"アルド殿", "アルド"},
+
+		// One part of a longer name
+		{"Stormwind", "Aria Stormwind"},
+		{"Harlow", "Captain Bea Harlow"},
+		{"Marcus Blackwood", "Marcus"},
+		// An exact match wins over a part of another name
+		{"Bea", "Bea"},
+		// Equally good matches are ambiguous
+		{"Aria", ""},
+		// Parts of one letter are too short to go by
+		{"J", ""},
+
+		// Fuzzy matches from minNameSimilarity up
+		{"Aldoo", "Aldo"},
+		{"Alda", "Aldo"},
+		{"Elda", ""},
+		{"Marc", "Marco"},
+		{"Marcu", "Marcus"},
+		{"アルト", ""},
+
+		{"", ""},
+		{"さん", ""},
+		{"The", ""},
+		{"Nobody Known", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if i := m.Match(tt.name); i >= 0 {
+				got = known[i]
+			}
+			if got != tt.want {
+				t.Errorf("Match(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNameSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"aldo", "aldo", 1},
+		{"aldo", "alda", 0.75},
+		{"aldo", "aldoo", 0.8},
+		{"", "", 1},
+		{"aldo", "", 0},
+		// Runes, not bytes, so one kana counts once
+		{"アルド", "アルト", 2.0 / 3},
+	}
+
+	for _, tt := range tests {
+		if got := nameSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nameSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
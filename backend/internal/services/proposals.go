@@ -0,0 +1,343 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrInvalidProposal = errors.New("invalid proposal")
+
+// Provenance sources, i.e. which AI feature produced the applied content.
+const (
+	SourceDeepDive               = "deep_dive"
+	SourceRelationshipExtraction = "relationship_extraction"
+	SourceSessionNotes           = "session_notes"
+)
+
+var proposalSources = map[string]bool{
+	SourceDeepDive:               true,
+	SourceRelationshipExtraction: true,
+	SourceSessionNotes:           true,
+}
+
+// ProposalApplier writes accepted proposals to the campaign in one transaction
+// and records their provenance.
+type ProposalApplier struct {
+	store    store.Store
+	embedder Embedder
+}
+
+func NewProposalApplier(s store.Store, embedder Embedder) *ProposalApplier {
+	return &ProposalApplier{store: s, embedder: embedder}
+}
+
+// Apply validates and writes proposals. Either all of them are applied or, if
+// any is invalid or a write fails, none are. Errors for bad input wrap
+// ErrInvalidProposal; a duplicate relationship wraps store.ErrConflict.
+func (a *ProposalApplier) Apply(ctx context.Context, campaignID, userID, source string, proposals []models.Proposal) (*models.ApplyProposalsResponse, error) {
+	if source == "" {
+		source = SourceDeepDive
+	}
+	if !proposalSources[source] {
+		return nil, fmt.Errorf("%w: unknown source %q", ErrInvalidProposal, source)
+	}
+
+	result := &models.ApplyProposalsResponse{
+		Characters:    []models.Character{},
+		Relationships: []models.Relationship{},
+		LoreEntries:   []models.LoreEntry{},
+		Provenance:    []models.Provenance{},
+	}
+
+	err := a.store.WithTx(ctx, func(tx store.Store) error {
+		result.Characters = result.Characters[:0]
+		result.Relationships = result.Relationships[:0]
+		result.LoreEntries = result.LoreEntries[:0]
+		result.Provenance = result.Provenance[:0]
+
+		applied := &appliedRecords{
+			result:      result,
+			characters:  map[string]int{},
+			loreEntries: map[string]int{},
+		}
+
+		for _, proposal := range proposals {
+			provenance, err := a.apply(ctx, tx, campaignID, userID, proposal, applied)
+			if err != nil {
+				return err
+			}
+
+			provenance.CampaignID = campaignID
+			provenance.Source = source
+			provenance.ProposalID = proposal.ID
+			provenance.CreatedBy = userID
+			created, err := tx.CreateProvenance(ctx, provenance)
+			if err != nil {
+				return err
+			}
+			result.Provenance = append(result.Provenance, *created)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Embeddings are computed after the commit so the transaction is not held
+	// open during provider calls
+	for i := range result.Characters {
+		setEmbedding(ctx, a.embedder, CharacterEmbeddingText(&result.Characters[i]), func(embedding []float32) error {
+			return a.store.SetCharacterEmbedding(ctx, result.Characters[i].ID, embedding)
+		})
+	}
+	for i := range result.LoreEntries {
+		setEmbedding(ctx, a.embedder, LoreEntryEmbeddingText(&result.LoreEntries[i]), func(embedding []float32) error {
+			return a.store.SetLoreEntryEmbedding(ctx, result.LoreEntries[i].ID, embedding)
+		})
+	}
+
+	return result, nil
+}
+
+// appliedRecords collects written records into the response. Records touched
+// by several proposals are updated in turn and reported once, in their final
+// state.
+type appliedRecords struct {
+	result      *models.ApplyProposalsResponse
+	characters  map[string]int
+	loreEntries map[string]int
+}
+
+func (r *appliedRecords) character(character *models.Character) {
+	if i, ok := r.characters[character.ID]; ok {
+		r.result.Characters[i] = *character
+		return
+	}
+	r.characters[character.ID] = len(r.result.Characters)
+	r.result.Characters = append(r.result.Characters, *character)
+}
+
+func (r *appliedRecords) loreEntry(loreEntry *models.LoreEntry) {
+	if i, ok := r.loreEntries[loreEntry.ID]; ok {
+		r.result.LoreEntries[i] = *loreEntry
+		return
+	}
+	r.loreEntries[loreEntry.ID] = len(r.result.LoreEntries)
+	r.result.LoreEntries = append(r.result.LoreEntries, *loreEntry)
+}
+
+func (a *ProposalApplier) apply(ctx context.Context, tx store.Store, campaignID, userID string, proposal models.Proposal, applied *appliedRecords) (*models.Provenance, error) {
+	switch proposal.Kind {
+	case models.ProposalBackground, models.ProposalAttribute:
+		character, err := campaignCharacter(ctx, tx, campaignID, proposal.CharacterID)
+		if err != nil {
+			return nil, invalidProposal(proposal, err)
+		}
+
+		provenance := &models.Provenance{EntityType: models.EntityCharacter, EntityID: character.ID}
+		if proposal.Kind == models.ProposalBackground {
+			text := strings.TrimSpace(proposal.Background)
+			if text == "" {
+				return nil, invalidProposal(proposal, errors.New("background is empty"))
+			}
+			if character.Background != "" {
+				character.Background += "\n\n"
+			}
+			character.Background += text
+			provenance.Field = "background"
+			provenance.Content = text
+		} else {
+			if proposal.AttributeKey == "" {
+				return nil, invalidProposal(proposal, errors.New("attribute_key is empty"))
+			}
+			if character.Attributes == nil {
+				character.Attributes = map[string]interface{}{}
+			}
+			character.Attributes[proposal.AttributeKey] = proposal.AttributeValue
+			value, err := json.Marshal(proposal.AttributeValue)
+			if err != nil {
+				return nil, invalidProposal(proposal, err)
+			}
+			provenance.Field = "attributes." + proposal.AttributeKey
+			provenance.Content = string(value)
+		}
+
+		updated, err := SaveCharacter(ctx, tx, character, userID)
+		if err != nil {
+			return nil, err
+		}
+		applied.character(updated)
+		return provenance, nil
+
+	case models.ProposalCharacter:
+		c := proposal.Character
+		if c == nil || strings.TrimSpace(c.Name) == "" {
+			return nil, invalidProposal(proposal, errors.New("character with name is required"))
+		}
+		role := c.Role
+		if role == "" {
+			role = "NPC"
+		}
+		visibility, err := proposedVisibility(c.Visibility)
+		if err != nil {
+			return nil, invalidProposal(proposal, err)
+		}
+
+		created, err := tx.CreateCharacter(ctx, &models.Character{
+			CampaignID: campaignID,
+			Name:       strings.TrimSpace(c.Name),
+			Role:       role,
+			Attributes: c.Attributes,
+			Background: c.Background,
+			Visibility: visibility,
+		})
+		if err != nil {
+			return nil, err
+		}
+		applied.character(created)
+		return &models.Provenance{EntityType: models.EntityCharacter, EntityID: created.ID, Field: "record", Content: CharacterEmbeddingText(created)}, nil
+
+	case models.ProposalRelationship:
+		r := proposal.Relationship
+		if r == nil || r.RelationType == "" {
+			return nil, invalidProposal(proposal, errors.New("relationship with relation_type is required"))
+		}
+		if r.SourceCharacterID == r.TargetCharacterID {
+			return nil, invalidProposal(proposal, errors.New("a character cannot be related to itself"))
+		}
+		for _, id := range []string{r.SourceCharacterID, r.TargetCharacterID} {
+			if _, err := campaignCharacter(ctx, tx, campaignID, id); err != nil {
+				return nil, invalidProposal(proposal, err)
+			}
+		}
+		visibility, err := proposedVisibility(r.Visibility)
+		if err != nil {
+			return nil, invalidProposal(proposal, err)
+		}
+
+		created, paired, err := createRelationship(ctx, tx, &models.Relationship{
+			CampaignID:        campaignID,
+			SourceCharacterID: r.SourceCharacterID,
+			TargetCharacterID: r.TargetCharacterID,
+			RelationType:      r.RelationType,
+			Description:       r.Description,
+			Visibility:        visibility,
+		})
+		if errors.Is(err, store.ErrConflict) {
+			return nil, invalidProposal(proposal, err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		applied.result.Relationships = append(applied.result.Relationships, *created)
+		if paired != nil {
+			applied.result.Relationships = append(applied.result.Relationships, *paired)
+		}
+
+		content := created.RelationType
+		if created.Description != "" {
+			content += ": " + created.Description
+		}
+		return &models.Provenance{EntityType: models.EntityRelationship, EntityID: created.ID, Field: "record", Content: content}, nil
+
+	case models.ProposalLoreEntry:
+		l := proposal.LoreEntry
+		if l == nil || strings.TrimSpace(l.Title) == "" || strings.TrimSpace(l.Content) == "" {
+			return nil, invalidProposal(proposal, errors.New("lore_entry with title and content is required"))
+		}
+		visibility, err := proposedVisibility(l.Visibility)
+		if err != nil {
+			return nil, invalidProposal(proposal, err)
+		}
+
+		created, err := tx.CreateLoreEntry(ctx, &models.LoreEntry{
+			CampaignID: campaignID,
+			Title:      l.Title,
+			Category:   l.Category,
+			Content:    l.Content,
+			Visibility: visibility,
+		})
+		if err != nil {
+			return nil, err
+		}
+		applied.loreEntry(created)
+		return &models.Provenance{EntityType: models.EntityLoreEntry, EntityID: created.ID, Field: "record", Content: created.Content}, nil
+
+	case models.ProposalLoreEntryUpdate:
+		if proposal.LoreEntry == nil || strings.TrimSpace(proposal.LoreEntry.Content) == "" {
+			return nil, invalidProposal(proposal, errors.New("lore_entry with content is required"))
+		}
+		loreEntry, err := campaignLoreEntry(ctx, tx, campaignID, proposal.LoreEntryID)
+		if err != nil {
+			return nil, invalidProposal(proposal, err)
+		}
+
+		text := strings.TrimSpace(proposal.LoreEntry.Content)
+		if loreEntry.Content != "" {
+			loreEntry.Content += "\n\n"
+		}
+		loreEntry.Content += text
+		if loreEntry.Category == "" {
+			loreEntry.Category = proposal.LoreEntry.Category
+		}
+
+		updated, err := SaveLoreEntry(ctx, tx, loreEntry, userID)
+		if err != nil {
+			return nil, err
+		}
+		applied.loreEntry(updated)
+		return &models.Provenance{EntityType: models.EntityLoreEntry, EntityID: updated.ID, Field: "content", Content: text}, nil
+
+	default:
+		return nil, invalidProposal(proposal, fmt.Errorf("unknown kind %q", proposal.Kind))
+	}
+}
+
+// proposedVisibility is the visibility of a record created from a proposal.
+// AI-written content is GM-only unless the proposal says otherwise.
+func proposedVisibility(v string) (string, error) {
+	if !validVisibility(v) {
+		return "", errors.New("visibility must be public or gm")
+	}
+	if v == "" {
+		return models.VisibilityGM, nil
+	}
+	return v, nil
+}
+
+// campaignCharacter loads a character and checks that it belongs to the campaign.
+func campaignCharacter(ctx context.Context, s store.Store, campaignID, id string) (*models.Character, error) {
+	if id == "" {
+		return nil, errors.New("character_id is required")
+	}
+
+	character, err := s.GetCharacter(ctx, id)
+	if err != nil || character.CampaignID != campaignID {
+		return nil, fmt.Errorf("character %s not found in campaign", id)
+	}
+	return character, nil
+}
+
+// campaignLoreEntry loads a lore entry and checks that it belongs to the campaign.
+func campaignLoreEntry(ctx context.Context, s store.Store, campaignID, id string) (*models.LoreEntry, error) {
+	if id == "" {
+		return nil, errors.New("lore_entry_id is required")
+	}
+
+	loreEntry, err := s.GetLoreEntry(ctx, id)
+	if err != nil || loreEntry.CampaignID != campaignID {
+		return nil, fmt.Errorf("lore entry %s not found in campaign", id)
+	}
+	return loreEntry, nil
+}
+
+func invalidProposal(proposal models.Proposal, err error) error {
+	return fmt.Errorf("%w %s: %v", ErrInvalidProposal, proposal.ID, err)
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+func TestApplyProposalVisibility(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	campaignID, ids := seedCampaign(t, s, "Aldo", "Bea")
+	applier := NewProposalApplier(s, nil)
+
+	result, err := applier.Apply(ctx, campaignID, "00000000-0000-0000-0000-00000000000a", "", []models.Proposal{
+		{ID: "p1", Kind: models.ProposalCharacter, Character: &models.ProposedCharacter{Name: "Cora"}},
+		{ID: "p2", Kind: models.ProposalRelationship, Relationship: &models.ProposedRelationship{
+			SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Bea"], RelationType: "debtor"}},
+		{ID: "p3", Kind: models.ProposalLoreEntry, LoreEntry: &models.ProposedLoreEntry{Title: "Docks", Content: "Busy."}},
+		{ID: "p4", Kind: models.ProposalLoreEntry, LoreEntry: &models.ProposedLoreEntry{Title: "Market", Content: "Loud.", Visibility: models.VisibilityPublic}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Characters) != 1 || result.Characters[0].Visibility != models.VisibilityGM {
+		t.Errorf("characters = %+v, want one GM-only", result.Characters)
+	}
+	if len(result.Relationships) != 1 || result.Relationships[0].Visibility != models.VisibilityGM {
+		t.Errorf("relationships = %+v, want one GM-only", result.Relationships)
+	}
+	if len(result.LoreEntries) != 2 || result.LoreEntries[0].Visibility != models.VisibilityGM ||
+		result.LoreEntries[1].Visibility != models.VisibilityPublic {
+		t.Errorf("lore entries = %+v, want the first GM-only and the second public", result.LoreEntries)
+	}
+
+	_, err = applier.Apply(ctx, campaignID, "00000000-0000-0000-0000-00000000000a", "", []models.Proposal{
+		{ID: "p5", Kind: models.ProposalLoreEntry, LoreEntry: &models.ProposedLoreEntry{Title: "Lighthouse", Content: "Dark.", Visibility: "secret"}},
+	})
+	if !errors.Is(err, ErrInvalidProposal) {
+		t.Errorf("unknown visibility: err = %v, want %v", err, ErrInvalidProposal)
+	}
+}
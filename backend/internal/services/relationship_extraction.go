@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// scanTokenBudget caps how much of the campaign is sent when relationships are
+// extracted from existing backgrounds and lore instead of given text.
+const scanTokenBudget = 8000
+
+// ExtractedRelationship is a relationship as the model wrote it, with
+// characters given by name.
+type ExtractedRelationship struct {
+	Source       string `json:"source"`
+	Target       string `json:"target"`
+	RelationType string `json:"relation_type"`
+	Description  string `json:"description"`
+	Evidence     string `json:"evidence"`
+}
+
+// RelationshipExtractor turns prose into relationship proposals between
+// existing characters.
+type RelationshipExtractor struct {
+	store store.Store
+	ai    *AIService
+}
+
+func NewRelationshipExtractor(s store.Store, ai *AIService) *RelationshipExtractor {
+	return &RelationshipExtractor{store: s, ai: ai}
+}
+
+// Extract reads relationships from text, or from the campaign's backgrounds and
+// lore when text is empty. Names are matched to characters fuzzily; relationships
+// that already exist, or repeat one found earlier, are dropped.
+func (e *RelationshipExtractor) Extract(ctx context.Context, campaignID, text string) (*models.ExtractRelationshipsResponse, error) {
+	result := &models.ExtractRelationshipsResponse{
+		Proposals:  []models.Proposal{},
+		Unresolved: []models.UnresolvedRelationship{},
+	}
+
+	characters, err := e.store.ListCharacters(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := e.store.ListRelationships(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(text) == "" {
+		loreEntries, err := e.store.ListLoreEntries(ctx, campaignID)
+		if err != nil {
+			return nil, err
+		}
+		text = campaignText(characters, loreEntries)
+		if text == "" {
+			return result, nil
+		}
+	}
+
+	byID := make(map[string]models.Character, len(characters))
+	names := make([]string, len(characters))
+	for i, c := range characters {
+		byID[c.ID] = c
+		names[i] = c.Name
+	}
+
+	// Pairs are recorded in both directions: "A mentors B" and "B is A's
+	// pupil" describe the same edge
+	seen := map[[2]string]bool{}
+	var existing []string
+	for _, r := range relationships {
+		seen[[2]string{r.SourceCharacterID, r.TargetCharacterID}] = true
+		seen[[2]string{r.TargetCharacterID, r.SourceCharacterID}] = true
+		source, okSource := byID[r.SourceCharacterID]
+		target, okTarget := byID[r.TargetCharacterID]
+		if okSource && okTarget {
+			existing = append(existing, fmt.Sprintf("%s → %s: %s", source.Name, target.Name, r.RelationType))
+		}
+	}
+
+	extracted, err := e.ai.ExtractRelationships(ctx, text, names, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := newNameMatcher(names)
+	for _, r := range extracted {
+		sourceIndex, targetIndex := matcher.Match(r.Source), matcher.Match(r.Target)
+		if sourceIndex < 0 || targetIndex < 0 {
+			result.Unresolved = append(result.Unresolved, models.UnresolvedRelationship{
+				Source:       r.Source,
+				Target:       r.Target,
+				RelationType: r.RelationType,
+				Description:  r.Description,
+				Evidence:     r.Evidence,
+			})
+			continue
+		}
+		source, target := characters[sourceIndex], characters[targetIndex]
+		if source.ID == target.ID || seen[[2]string{source.ID, target.ID}] {
+			continue
+		}
+		seen[[2]string{source.ID, target.ID}] = true
+		seen[[2]string{target.ID, source.ID}] = true
+
+		result.Proposals = append(result.Proposals, models.Proposal{
+			ID:          uuid.NewString(),
+			Kind:        models.ProposalRelationship,
+			Summary:     fmt.Sprintf("%s → %s: %s", source.Name, target.Name, r.RelationType),
+			CharacterID: source.ID,
+			Relationship: &models.ProposedRelationship{
+				SourceCharacterID: source.ID,
+				TargetCharacterID: target.ID,
+				RelationType:      r.RelationType,
+				Description:       r.Description,
+			},
+			Evidence: r.Evidence,
+		})
+	}
+
+	return result, nil
+}
+
+// campaignText renders character backgrounds and lore entries as one document,
+// cut to scanTokenBudget.
+func campaignText(characters []models.Character, loreEntries []models.LoreEntry) string {
+	var b strings.Builder
+	for _, c := range characters {
+		if strings.TrimSpace(c.Background) != "" {
+			fmt.Fprintf(&b, "## %s\n%s\n\n", c.Name, c.Background)
+		}
+	}
+	for _, l := range loreEntries {
+		fmt.Fprintf(&b, "## %s\n%s\n\n", l.Title, l.Content)
+	}
+	return TruncateTokens(strings.TrimSpace(b.String()), scanTokenBudget)
+}
+
+// ExtractRelationships asks the model for the relationships between characters
+// stated or implied in text. names are the campaign's characters and existing
+// its recorded relationships, which the model is told not to repeat. Entries
+// missing a name or relation type are dropped.
+func (s *AIService) ExtractRelationships(ctx context.Context, text string, names, existing []string) ([]ExtractedRelationship, error) {
+	known := "(none)"
+	if len(names) > 0 {
+		known = "- " + strings.Join(names, "\n- ")
+	}
+	recorded := "(none)"
+	if len(existing) > 0 {
+		recorded = "- " + strings.Join(existing, "\n- ")
+	}
+
+	prompt := fmt.Sprintf(`You extract relationships between characters from world-building notes.
+
+Known characters:
+%s
+
+Already recorded relationships (do not repeat these):
+%s
+
+Text:
+%s
+
+List every relationship between two characters that the text states or clearly implies.
+When the text refers to a known character, even by a nickname, title or part of the name, use the name exactly as it appears in the known list; otherwise use the name as written.
+The relation type describes the source's role towards the target: in "A is B's mentor" the source is A, the target is B and the relation type is "mentor".
+
+Respond in JSON format:
+{"relationships": [{
+  "source": "name",
+  "target": "name",
+  "relation_type": "short label such as mentor, rival or sibling, in the language of the text",
+  "description": "one sentence about the relationship",
+  "evidence": "exact quote from the text"
+}]}`, known, recorded, text)
+
+	response, err := s.extraction.Complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	object := extractJSONObject(response)
+	if object == "" {
+		return nil, ErrUnparseableResponse
+	}
+	var reply struct {
+		Relationships []ExtractedRelationship `json:"relationships"`
+	}
+	if err := json.Unmarshal([]byte(object), &reply); err != nil {
+		return nil, ErrUnparseableResponse
+	}
+
+	relationships := make([]ExtractedRelationship, 0, len(reply.Relationships))
+	for _, r := range reply.Relationships {
+		r.Source = strings.TrimSpace(r.Source)
+		r.Target = strings.TrimSpace(r.Target)
+		r.RelationType = strings.TrimSpace(r.RelationType)
+		r.Description = strings.TrimSpace(r.Description)
+		r.Evidence = strings.TrimSpace(r.Evidence)
+		if r.Source == "" || r.Target == "" || r.RelationType == "" {
+			continue
+		}
+		relationships = append(relationships, r)
+	}
+	return relationships, nil
+}
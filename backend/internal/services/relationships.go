@@ -0,0 +1,268 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrInvalidRelationType = errors.New("invalid relation type")
+
+// relationTypeRegistry maps a relation type, case-insensitively, to the type
+// that holds in the other direction: itself for symmetric types, the inverse
+// for types registered with one (both ways round).
+type relationTypeRegistry map[string]string
+
+func loadRelationTypeRegistry(ctx context.Context, s store.Store, campaignID string) (relationTypeRegistry, error) {
+	relationTypes, err := s.ListRelationTypes(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := relationTypeRegistry{}
+	for _, t := range relationTypes {
+		switch {
+		case t.Symmetric:
+			registry[strings.ToLower(t.Name)] = t.Name
+		case t.InverseName != "":
+			registry[strings.ToLower(t.Name)] = t.InverseName
+			registry[strings.ToLower(t.InverseName)] = t.Name
+		}
+	}
+	return registry, nil
+}
+
+// inverse returns the relation type of the paired edge, if relationType has
+// one.
+func (r relationTypeRegistry) inverse(relationType string) (string, bool) {
+	inverse, ok := r[strings.ToLower(strings.TrimSpace(relationType))]
+	return inverse, ok
+}
+
+// RelationshipService writes relationships so that those whose type is
+// registered as symmetric or with an inverse keep a paired edge in the other
+// direction: "A mentor B" is stored with "B apprentice A", and the two are
+// updated and deleted together.
+type RelationshipService struct {
+	store store.Store
+}
+
+func NewRelationshipService(s store.Store) *RelationshipService {
+	return &RelationshipService{store: s}
+}
+
+// Create creates relationship and its paired edge. A reverse edge that already
+// exists with another type is a conflict.
+func (r *RelationshipService) Create(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	var created *models.Relationship
+	err := r.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		created, _, err = createRelationship(ctx, tx, relationship)
+		return err
+	})
+	return created, err
+}
+
+// Update changes the type, description and visibility of a relationship. Its
+// paired edge follows: it takes the new type's inverse, description and
+// visibility, is created if the new type pairs and the old one did not, and is
+// moved to the trash if the new type no longer pairs.
+func (r *RelationshipService) Update(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	var updated *models.Relationship
+	err := r.store.WithTx(ctx, func(tx store.Store) error {
+		registry, err := loadRelationTypeRegistry(ctx, tx, relationship.CampaignID)
+		if err != nil {
+			return err
+		}
+		previous, err := tx.GetRelationship(ctx, relationship.ID)
+		if err != nil {
+			return err
+		}
+
+		updated, err = tx.UpdateRelationship(ctx, relationship)
+		if err != nil {
+			return err
+		}
+
+		reverse, err := pairedRelationship(ctx, tx, registry, previous)
+		if err != nil {
+			return err
+		}
+		if reverse == nil {
+			_, err = ensurePair(ctx, tx, registry, updated)
+			return err
+		}
+
+		inverse, ok := registry.inverse(updated.RelationType)
+		if !ok {
+			now := time.Now().UTC()
+			return tx.SetRelationshipDeletedAt(ctx, reverse.ID, &now)
+		}
+		reverse.RelationType = inverse
+		reverse.Description = updated.Description
+		reverse.Visibility = updated.Visibility
+		_, err = tx.UpdateRelationship(ctx, reverse)
+		return err
+	})
+	return updated, err
+}
+
+// Delete moves relationship and its paired edge to the trash, to be restored
+// or purged together.
+func (r *RelationshipService) Delete(ctx context.Context, relationship *models.Relationship) error {
+	return r.store.WithTx(ctx, func(tx store.Store) error {
+		registry, err := loadRelationTypeRegistry(ctx, tx, relationship.CampaignID)
+		if err != nil {
+			return err
+		}
+		reverse, err := pairedRelationship(ctx, tx, registry, relationship)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if err := tx.SetRelationshipDeletedAt(ctx, relationship.ID, &now); err != nil {
+			return err
+		}
+		if reverse != nil {
+			return tx.SetRelationshipDeletedAt(ctx, reverse.ID, &now)
+		}
+		return nil
+	})
+}
+
+// createRelationship creates relationship within tx, along with its paired
+// edge when its type has one. The paired edge is nil when there is none or it
+// already existed.
+func createRelationship(ctx context.Context, tx store.Store, relationship *models.Relationship) (*models.Relationship, *models.Relationship, error) {
+	registry, err := loadRelationTypeRegistry(ctx, tx, relationship.CampaignID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	created, err := tx.CreateRelationship(ctx, relationship)
+	if err != nil {
+		return nil, nil, err
+	}
+	paired, err := ensurePair(ctx, tx, registry, created)
+	if err != nil {
+		return nil, nil, err
+	}
+	return created, paired, nil
+}
+
+// ensurePair creates the paired edge of relationship if its type has one and
+// the edge is missing.
+func ensurePair(ctx context.Context, tx store.Store, registry relationTypeRegistry, relationship *models.Relationship) (*models.Relationship, error) {
+	inverse, ok := registry.inverse(relationship.RelationType)
+	if !ok || relationship.SourceCharacterID == relationship.TargetCharacterID {
+		return nil, nil
+	}
+
+	reverse, err := reverseRelationship(ctx, tx, relationship)
+	if err != nil {
+		return nil, err
+	}
+	if reverse != nil {
+		if strings.EqualFold(reverse.RelationType, inverse) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: the reverse relationship is already recorded as %q, expected %q",
+			store.ErrConflict, reverse.RelationType, inverse)
+	}
+
+	return tx.CreateRelationship(ctx, &models.Relationship{
+		CampaignID:        relationship.CampaignID,
+		SourceCharacterID: relationship.TargetCharacterID,
+		TargetCharacterID: relationship.SourceCharacterID,
+		RelationType:      inverse,
+		Description:       relationship.Description,
+		Visibility:        relationship.Visibility,
+	})
+}
+
+// pairedRelationship returns the reverse edge of relationship if it is the
+// one its type pairs with, and nil otherwise. A reverse edge of an unrelated
+// type was entered separately and is left alone.
+func pairedRelationship(ctx context.Context, s store.Store, registry relationTypeRegistry, relationship *models.Relationship) (*models.Relationship, error) {
+	inverse, ok := registry.inverse(relationship.RelationType)
+	if !ok || relationship.SourceCharacterID == relationship.TargetCharacterID {
+		return nil, nil
+	}
+	reverse, err := reverseRelationship(ctx, s, relationship)
+	if err != nil || reverse == nil || !strings.EqualFold(reverse.RelationType, inverse) {
+		return nil, err
+	}
+	return reverse, nil
+}
+
+// reverseRelationship returns the relationship from relationship's target to
+// its source, if there is one.
+func reverseRelationship(ctx context.Context, s store.Store, relationship *models.Relationship) (*models.Relationship, error) {
+	relationships, err := s.ListRelationships(ctx, relationship.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range relationships {
+		if r.SourceCharacterID == relationship.TargetCharacterID && r.TargetCharacterID == relationship.SourceCharacterID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateRelationType registers a relation type. Validation errors wrap
+// ErrInvalidRelationType and a name already registered, as a type or an
+// inverse, is store.ErrConflict.
+func (r *RelationshipService) CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	if err := r.validateRelationType(ctx, relationType); err != nil {
+		return nil, err
+	}
+	return r.store.CreateRelationType(ctx, relationType)
+}
+
+// UpdateRelationType changes a registered relation type. Existing
+// relationships are not re-paired; the registry applies to later writes.
+func (r *RelationshipService) UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	if err := r.validateRelationType(ctx, relationType); err != nil {
+		return nil, err
+	}
+	return r.store.UpdateRelationType(ctx, relationType)
+}
+
+// validateRelationType normalises relationType and checks that it neither
+// contradicts itself nor reuses a name of another registered type.
+func (r *RelationshipService) validateRelationType(ctx context.Context, relationType *models.RelationType) error {
+	relationType.Name = strings.TrimSpace(relationType.Name)
+	relationType.InverseName = strings.TrimSpace(relationType.InverseName)
+	if relationType.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRelationType)
+	}
+	if strings.EqualFold(relationType.Name, relationType.InverseName) {
+		relationType.Symmetric, relationType.InverseName = true, ""
+	}
+	if relationType.Symmetric && relationType.InverseName != "" {
+		return fmt.Errorf("%w: a symmetric type has no inverse", ErrInvalidRelationType)
+	}
+
+	existing, err := r.store.ListRelationTypes(ctx, relationType.CampaignID)
+	if err != nil {
+		return err
+	}
+	for _, t := range existing {
+		if t.ID == relationType.ID {
+			continue
+		}
+		for _, name := range []string{relationType.Name, relationType.InverseName} {
+			if name != "" && (strings.EqualFold(name, t.Name) || strings.EqualFold(name, t.InverseName)) {
+				return fmt.Errorf("%w: %q is already registered", store.ErrConflict, name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// seedCampaign creates a campaign with a character for each of names and
+// returns the campaign's ID and the characters' IDs by name.
+func seedCampaign(t *testing.T, s store.Store, names ...string) (string, map[string]string) {
+	t.Helper()
+	ctx := context.Background()
+
+	campaign, err := s.CreateCampaign(ctx, &models.Campaign{UserID: "00000000-0000-0000-0000-00000000000a", Title: "Harbour Town"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := map[string]string{}
+	for _, name := range names {
+		character, err := s.CreateCharacter(ctx, &models.Character{CampaignID: campaign.ID, Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = character.ID
+	}
+	return campaign.ID, ids
+}
+
+// edges describes relationships as "source type target" strings, sorted, with
+// characters named through ids.
+func edges(relationships []models.Relationship, ids map[string]string) []string {
+	names := map[string]string{}
+	for name, id := range ids {
+		names[id] = name
+	}
+	var result []string
+	for _, r := range relationships {
+		result = append(result, names[r.SourceCharacterID]+" "+r.RelationType+" "+names[r.TargetCharacterID])
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestRelationshipPairing(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	campaignID, ids := seedCampaign(t, s, "Aldo", "Bea")
+	relationships := NewRelationshipService(s)
+
+	for _, relationType := range []*models.RelationType{
+		{CampaignID: campaignID, Name: "mentor", InverseName: "apprentice"},
+		{CampaignID: campaignID, Name: "rival", Symmetric: true},
+	} {
+		if _, err := relationships.CreateRelationType(ctx, relationType); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	live := func(t *testing.T, want ...string) {
+		t.Helper()
+		list, err := s.ListRelationships(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := edges(list, ids); strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("relationships = %q, want %q", got, want)
+		}
+	}
+	trashed := func(t *testing.T, want ...string) {
+		t.Helper()
+		list, err := s.ListTrashedRelationships(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := edges(list, ids); strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("trashed relationships = %q, want %q", got, want)
+		}
+	}
+
+	relationship, err := relationships.Create(ctx, &models.Relationship{
+		CampaignID: campaignID, SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Bea"],
+		RelationType: "Mentor", Description: "Teaches her to sail",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Run("create adds the inverse", func(t *testing.T) {
+		live(t, "Aldo Mentor Bea", "Bea apprentice Aldo")
+	})
+
+	t.Run("a conflicting reverse edge is refused", func(t *testing.T) {
+		_, err := relationships.Create(ctx, &models.Relationship{
+			CampaignID: campaignID, SourceCharacterID: ids["Bea"], TargetCharacterID: ids["Aldo"], RelationType: "friend",
+		})
+		if !errors.Is(err, store.ErrConflict) {
+			t.Errorf("err = %v, want %v", err, store.ErrConflict)
+		}
+		live(t, "Aldo Mentor Bea", "Bea apprentice Aldo")
+	})
+
+	t.Run("update to a symmetric type", func(t *testing.T) {
+		relationship.RelationType = "rival"
+		relationship.Description = "Race each other"
+		relationship.Visibility = models.VisibilityGM
+		if _, err := relationships.Update(ctx, relationship); err != nil {
+			t.Fatal(err)
+		}
+		live(t, "Aldo rival Bea", "Bea rival Aldo")
+
+		list, err := s.ListRelationships(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range list {
+			if r.Description != "Race each other" || r.Visibility != models.VisibilityGM {
+				t.Errorf("%s did not follow the update: %+v", r.RelationType, r)
+			}
+		}
+	})
+
+	t.Run("update to an unpaired type trashes the pair", func(t *testing.T) {
+		relationship.RelationType = "friend"
+		if _, err := relationships.Update(ctx, relationship); err != nil {
+			t.Fatal(err)
+		}
+		live(t, "Aldo friend Bea")
+		trashed(t, "Bea rival Aldo")
+	})
+
+	t.Run("update back to a paired type adds the pair", func(t *testing.T) {
+		relationship.RelationType = "apprentice"
+		if _, err := relationships.Update(ctx, relationship); err != nil {
+			t.Fatal(err)
+		}
+		live(t, "Aldo apprentice Bea", "Bea mentor Aldo")
+	})
+
+	t.Run("delete trashes both", func(t *testing.T) {
+		if err := relationships.Delete(ctx, relationship); err != nil {
+			t.Fatal(err)
+		}
+		live(t)
+		trashed(t, "Aldo apprentice Bea", "Bea mentor Aldo", "Bea rival Aldo")
+	})
+}
+
+func TestRelationshipWithoutPair(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	campaignID, ids := seedCampaign(t, s, "Aldo", "Bea")
+	relationships := NewRelationshipService(s)
+	if _, err := relationships.CreateRelationType(ctx, &models.RelationType{CampaignID: campaignID, Name: "rival", Symmetric: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, relationship := range []*models.Relationship{
+		{CampaignID: campaignID, SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Bea"], RelationType: "debtor"},
+		{CampaignID: campaignID, SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Aldo"], RelationType: "rival"},
+	} {
+		if _, err := relationships.Create(ctx, relationship); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := s.ListRelationships(ctx, campaignID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := edges(list, ids), []string{"Aldo debtor Bea", "Aldo rival Aldo"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("relationships = %q, want %q", got, want)
+	}
+}
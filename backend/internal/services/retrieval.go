@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"unicode"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// ContextItem is a character or lore entry retrieved as grounding for a prompt.
+type ContextItem struct {
+	Type  string
+	ID    string
+	Title string
+	Text  string
+	Score float64
+}
+
+func (item ContextItem) Source() models.ConsistencySource {
+	return models.ConsistencySource{Type: item.Type, ID: item.ID, Title: item.Title, Score: item.Score}
+}
+
+// RetrievalOptions bounds how much campaign material goes into a prompt.
+type RetrievalOptions struct {
+	TopK        int
+	TokenBudget int
+}
+
+// A truncated item shorter than this is not worth the space it takes.
+const minTruncatedTokens = 64
+
+// RetrievalOptionsFromEnv reads RAG_TOP_K and RAG_TOKEN_BUDGET, defaulting to
+// 12 items and 4000 tokens.
+func RetrievalOptionsFromEnv() RetrievalOptions {
+	return RetrievalOptions{
+		TopK:        envInt("RAG_TOP_K", 12),
+		TokenBudget: envInt("RAG_TOKEN_BUDGET", 4000),
+	}
+}
+
+// Retrieve returns the campaign's characters and lore entries most relevant to
+// query, best first, stopping once the token budget is used up. The last item
+// is truncated rather than dropped when a useful part of it fits. IDs in
+// exclude are skipped, e.g. the entry being edited.
+func (s *SearchService) Retrieve(ctx context.Context, campaignID, query string, opts RetrievalOptions, exclude ...string) ([]ContextItem, error) {
+	hits, err := s.Search(ctx, campaignID, query, nil, opts.TopK+len(exclude), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		excluded[id] = true
+	}
+
+	var items []ContextItem
+	remaining := opts.TokenBudget
+	for _, hit := range hits {
+		if remaining <= 0 || len(items) == opts.TopK {
+			break
+		}
+		if excluded[hit.ID] {
+			continue
+		}
+
+		text := hit.Text
+		cost := EstimateTokens(text)
+		if cost > remaining {
+			if remaining < minTruncatedTokens {
+				break
+			}
+			text = TruncateTokens(text, remaining)
+			cost = remaining
+		}
+		remaining -= cost
+
+		items = append(items, ContextItem{
+			Type:  hit.Type,
+			ID:    hit.ID,
+			Title: hit.Title,
+			Text:  text,
+			Score: hit.Score,
+		})
+	}
+
+	return items, nil
+}
+
+// EstimateTokens approximates a tokenizer without calling one: about four
+// characters per token for alphabetic scripts and one token per CJK character.
+func EstimateTokens(text string) int {
+	var cjk, other int
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// TruncateTokens cuts text down to roughly maxTokens as counted by EstimateTokens.
+func TruncateTokens(text string, maxTokens int) string {
+	var cjk, other int
+	for i, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			cjk++
+		} else {
+			other++
+		}
+		if cjk+(other+3)/4 > maxTokens {
+			return text[:i] + "…"
+		}
+	}
+	return text
+}
+
+func envInt(key string, fallback int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil && value > 0 {
+		return value
+	}
+	return fallback
+}
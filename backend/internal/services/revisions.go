@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// baselineSummary describes the revision that keeps an entity as it was
+// before its first recorded change.
+const baselineSummary = "Before the first recorded change"
+
+// SaveCharacter updates character through s and records the revision. Call it
+// inside WithTx so the update and its revision are kept together. An update
+// that changes nothing records no revision.
+func SaveCharacter(ctx context.Context, s store.Store, character *models.Character, userID string) (*models.Character, error) {
+	return saveCharacter(ctx, s, character, userID, "")
+}
+
+func saveCharacter(ctx context.Context, s store.Store, character *models.Character, userID, summary string) (*models.Character, error) {
+	previous, err := s.GetCharacter(ctx, character.ID)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.UpdateCharacter(ctx, character)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := characterChanges(previous, updated)
+	if len(changes) == 0 {
+		return updated, nil
+	}
+	if summary == "" {
+		summary = "Changed " + strings.Join(changes, ", ")
+	}
+	err = recordRevision(ctx, s, models.EntityCharacter, updated.ID, updated.CampaignID, previous, updated, userID, summary)
+	return updated, err
+}
+
+// SaveLoreEntry is SaveCharacter for lore entries.
+func SaveLoreEntry(ctx context.Context, s store.Store, loreEntry *models.LoreEntry, userID string) (*models.LoreEntry, error) {
+	return saveLoreEntry(ctx, s, loreEntry, userID, "")
+}
+
+func saveLoreEntry(ctx context.Context, s store.Store, loreEntry *models.LoreEntry, userID, summary string) (*models.LoreEntry, error) {
+	previous, err := s.GetLoreEntry(ctx, loreEntry.ID)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.UpdateLoreEntry(ctx, loreEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := loreEntryChanges(previous, updated)
+	if len(changes) == 0 {
+		return updated, nil
+	}
+	if summary == "" {
+		summary = "Changed " + strings.Join(changes, ", ")
+	}
+	err = recordRevision(ctx, s, models.EntityLoreEntry, updated.ID, updated.CampaignID, previous, updated, userID, summary)
+	return updated, err
+}
+
+// recordRevision appends the revision holding updated. An entity without
+// revisions, created before they were recorded or never changed since, first
+// gets one holding previous so the original is not lost. When a concurrent
+// save takes the same number first, the error wraps store.ErrConflict and the
+// caller's transaction should be retried.
+func recordRevision(ctx context.Context, s store.Store, entityType, entityID, campaignID string, previous, updated interface{}, userID, summary string) error {
+	revisions, err := s.ListRevisions(ctx, entityType, entityID)
+	if err != nil {
+		return err
+	}
+
+	number := 2
+	if len(revisions) > 0 {
+		number = revisions[len(revisions)-1].Number + 1
+	} else {
+		err = createRevision(ctx, s, entityType, entityID, campaignID, 1, previous, "", baselineSummary)
+	}
+	if err == nil {
+		err = createRevision(ctx, s, entityType, entityID, campaignID, number, updated, userID, summary)
+	}
+	if errors.Is(err, store.ErrConflict) {
+		return fmt.Errorf("%w: the %s was saved by someone else at the same time, try again", store.ErrConflict, entityType)
+	}
+	return err
+}
+
+func createRevision(ctx context.Context, s store.Store, entityType, entityID, campaignID string, number int, snapshot interface{}, userID, summary string) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = s.CreateRevision(ctx, &models.Revision{
+		CampaignID: campaignID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Number:     number,
+		Summary:    summary,
+		Snapshot:   data,
+		CreatedBy:  userID,
+	})
+	return err
+}
+
+// characterChanges names the fields that differ between a and b, with
+// attributes named one key at a time.
+func characterChanges(a, b *models.Character) []string {
+	var changes []string
+	if a.Name != b.Name {
+		changes = append(changes, "name")
+	}
+	if a.Role != b.Role {
+		changes = append(changes, "role")
+	}
+	if a.Background != b.Background {
+		changes = append(changes, "background")
+	}
+	if a.Visibility != b.Visibility {
+		changes = append(changes, "visibility")
+	}
+
+	keys := map[string]bool{}
+	for key := range a.Attributes {
+		keys[key] = true
+	}
+	for key := range b.Attributes {
+		keys[key] = true
+	}
+	var attributes []string
+	for key := range keys {
+		before, inA := a.Attributes[key]
+		after, inB := b.Attributes[key]
+		if inA != inB || !reflect.DeepEqual(before, after) ||
+			a.AttributeVisibility[key] != b.AttributeVisibility[key] {
+			attributes = append(attributes, "attributes."+key)
+		}
+	}
+	sort.Strings(attributes)
+
+	return append(changes, attributes...)
+}
+
+func loreEntryChanges(a, b *models.LoreEntry) []string {
+	var changes []string
+	if a.Title != b.Title {
+		changes = append(changes, "title")
+	}
+	if a.Category != b.Category {
+		changes = append(changes, "category")
+	}
+	if a.Content != b.Content {
+		changes = append(changes, "content")
+	}
+	if a.Visibility != b.Visibility {
+		changes = append(changes, "visibility")
+	}
+	return changes
+}
+
+// RevisionService reads an entity's history and rolls it back.
+type RevisionService struct {
+	store    store.Store
+	embedder Embedder
+}
+
+func NewRevisionService(s store.Store, embedder Embedder) *RevisionService {
+	return &RevisionService{store: s, embedder: embedder}
+}
+
+func (r *RevisionService) List(ctx context.Context, entityType, entityID string) ([]models.Revision, error) {
+	return r.store.ListRevisions(ctx, entityType, entityID)
+}
+
+// revision loads one of an entity's revisions.
+func (r *RevisionService) revision(ctx context.Context, entityType, entityID, id string) (*models.Revision, error) {
+	revision, err := r.store.GetRevision(ctx, id)
+	if err != nil || revision.EntityType != entityType || revision.EntityID != entityID {
+		return nil, fmt.Errorf("%w: %s", ErrRevisionNotFound, id)
+	}
+	return revision, nil
+}
+
+// Diff compares two of an entity's revisions line by line.
+func (r *RevisionService) Diff(ctx context.Context, entityType, entityID, fromID, toID string) (*models.RevisionDiff, error) {
+	from, err := r.revision(ctx, entityType, entityID, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.revision(ctx, entityType, entityID, toID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromLines, err := revisionLines(from)
+	if err != nil {
+		return nil, err
+	}
+	toLines, err := revisionLines(to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RevisionDiff{
+		From: *from,
+		To:   *to,
+		Diff: unifiedDiff(fmt.Sprintf("revision %d", from.Number), fmt.Sprintf("revision %d", to.Number), fromLines, toLines),
+	}, nil
+}
+
+// revisionLines renders a snapshot as text, one field per line and long text
+// split into its lines, so diffs point at what changed.
+func revisionLines(revision *models.Revision) ([]string, error) {
+	var lines []string
+	text := func(name, value string) {
+		lines = append(lines, name+":")
+		if value == "" {
+			return
+		}
+		for _, line := range strings.Split(value, "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	switch revision.EntityType {
+	case models.EntityCharacter:
+		var c models.Character
+		if err := json.Unmarshal(revision.Snapshot, &c); err != nil {
+			return nil, err
+		}
+		lines = append(lines, "name: "+c.Name, "role: "+c.Role, "visibility: "+c.Visibility)
+		keys := make([]string, 0, len(c.Attributes))
+		for key := range c.Attributes {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, _ := json.Marshal(c.Attributes[key])
+			line := "attributes." + key + ": " + string(value)
+			if c.AttributeVisibility[key] == models.VisibilityGM {
+				line += " (gm)"
+			}
+			lines = append(lines, line)
+		}
+		text("background", c.Background)
+
+	case models.EntityLoreEntry:
+		var l models.LoreEntry
+		if err := json.Unmarshal(revision.Snapshot, &l); err != nil {
+			return nil, err
+		}
+		lines = append(lines, "title: "+l.Title, "category: "+l.Category, "visibility: "+l.Visibility)
+		text("content", l.Content)
+	}
+
+	return lines, nil
+}
+
+// RestoreCharacter rolls a character back to one of its revisions, recording
+// the restore as a new revision. Its embedding is recomputed.
+func (r *RevisionService) RestoreCharacter(ctx context.Context, character *models.Character, revisionID, userID string) (*models.Character, error) {
+	revision, err := r.revision(ctx, models.EntityCharacter, character.ID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.Character
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	character.Name = snapshot.Name
+	character.Role = snapshot.Role
+	character.Attributes = snapshot.Attributes
+	character.Background = snapshot.Background
+	character.Visibility = snapshot.Visibility
+	character.AttributeVisibility = snapshot.AttributeVisibility
+
+	var restored *models.Character
+	err = r.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		restored, err = saveCharacter(ctx, tx, character, userID, fmt.Sprintf("Restored revision %d", revision.Number))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setEmbedding(ctx, r.embedder, CharacterEmbeddingText(restored), func(embedding []float32) error {
+		return r.store.SetCharacterEmbedding(ctx, restored.ID, embedding)
+	})
+	return restored, nil
+}
+
+// RestoreLoreEntry is RestoreCharacter for lore entries.
+func (r *RevisionService) RestoreLoreEntry(ctx context.Context, loreEntry *models.LoreEntry, revisionID, userID string) (*models.LoreEntry, error) {
+	revision, err := r.revision(ctx, models.EntityLoreEntry, loreEntry.ID, revisionID)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot models.LoreEntry
+	if err := json.Unmarshal(revision.Snapshot, &snapshot); err != nil {
+		return nil, err
+	}
+
+	loreEntry.Title = snapshot.Title
+	loreEntry.Category = snapshot.Category
+	loreEntry.Content = snapshot.Content
+	loreEntry.Visibility = snapshot.Visibility
+
+	var restored *models.LoreEntry
+	err = r.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		restored, err = saveLoreEntry(ctx, tx, loreEntry, userID, fmt.Sprintf("Restored revision %d", revision.Number))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	setEmbedding(ctx, r.embedder, LoreEntryEmbeddingText(restored), func(embedding []float32) error {
+		return r.store.SetLoreEntryEmbedding(ctx, restored.ID, embedding)
+	})
+	return restored, nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// staleRevisions misses the newest revision, as a save racing another one
+// does when both read the revisions before either writes.
+type staleRevisions struct {
+	store.Store
+}
+
+func (s staleRevisions) ListRevisions(ctx context.Context, entityType, entityID string) ([]models.Revision, error) {
+	revisions, err := s.Store.ListRevisions(ctx, entityType, entityID)
+	if len(revisions) > 0 {
+		revisions = revisions[:len(revisions)-1]
+	}
+	return revisions, err
+}
+
+func TestConcurrentSaveConflicts(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		_, ids := seedCampaign(t, s, "Aldo")
+
+		character, err := s.GetCharacter(ctx, ids["Aldo"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, role := range []string{"Innkeeper", "Smuggler"} {
+			character.Role = role
+			if _, err := SaveCharacter(ctx, s, character, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		character.Role = "Harbourmaster"
+		err = s.WithTx(ctx, func(tx store.Store) error {
+			_, err := SaveCharacter(ctx, staleRevisions{tx}, character, "")
+			return err
+		})
+		if !errors.Is(err, store.ErrConflict) {
+			t.Fatalf("err = %v, want %v", err, store.ErrConflict)
+		}
+
+		// The losing save is rolled back whole
+		saved, err := s.GetCharacter(ctx, character.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revisions, err := s.ListRevisions(ctx, models.EntityCharacter, character.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved.Role != "Smuggler" || len(revisions) != 3 {
+			t.Errorf("after the conflict the role is %q with %d revisions, want Smuggler with 3", saved.Role, len(revisions))
+		}
+	})
+}
+
+func TestRestoreRevision(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	campaignID, ids := seedCampaign(t, s, "Aldo")
+	revisions := NewRevisionService(s, nil)
+
+	character, err := s.GetCharacter(ctx, ids["Aldo"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *character
+	character.Role = "Innkeeper"
+	character.Background = "Runs the Drowned Rat.\nOwes the smuggler."
+	character.Attributes = map[string]interface{}{"age": 52.0}
+	character.AttributeVisibility = map[string]string{"age": models.VisibilityGM}
+	if _, err := SaveCharacter(ctx, s, character, "00000000-0000-0000-0000-00000000000a"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := revisions.List(ctx, models.EntityCharacter, character.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 || history[0].Summary != baselineSummary || history[1].Summary != "Changed role, background, attributes.age" {
+		t.Fatalf("revisions = %+v", history)
+	}
+
+	diff, err := revisions.Diff(ctx, models.EntityCharacter, character.ID, history[0].ID, history[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"-role: " + original.Role, "+role: Innkeeper", "+attributes.age: 52 (gm)", "+  Runs the Drowned Rat.", "+  Owes the smuggler."} {
+		if !strings.Contains(diff.Diff, line+"\n") {
+			t.Errorf("diff has no line %q:\n%s", line, diff.Diff)
+		}
+	}
+
+	restored, err := revisions.RestoreCharacter(ctx, character, history[0].ID, "00000000-0000-0000-0000-00000000000a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Role != original.Role || restored.Background != original.Background ||
+		len(restored.Attributes) != 0 || len(restored.AttributeVisibility) != 0 {
+		t.Errorf("restored = %+v, want %+v", restored, original)
+	}
+
+	history, err = revisions.List(ctx, models.EntityCharacter, character.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 || history[2].Number != 3 || history[2].Summary != "Restored revision 1" {
+		t.Fatalf("revisions after restoring = %+v", history)
+	}
+	// The restore's revision holds the same content as the one restored
+	diff, err = revisions.Diff(ctx, models.EntityCharacter, character.ID, history[0].ID, history[2].ID)
+	if err != nil || diff.Diff != "" {
+		t.Errorf("diff of the restored revision = %q, %v", diff.Diff, err)
+	}
+
+	// A revision of another entity is not found
+	other, err := s.CreateLoreEntry(ctx, &models.LoreEntry{CampaignID: campaignID, Title: "Docks", Content: "Busy."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := revisions.RestoreLoreEntry(ctx, other, history[0].ID, ""); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("restore another entity's revision: err = %v, want %v", err, ErrRevisionNotFound)
+	}
+}
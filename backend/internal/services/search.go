@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+const (
+	SearchHitCharacter = "character"
+	SearchHitLoreEntry = "lore_entry"
+)
+
+// Blend weights for the final score. Keyword matching keeps exact names
+// findable even when the embedding provider ranks them poorly.
+const (
+	vectorWeight  = 0.7
+	keywordWeight = 0.3
+
+	// Vector-only hits below this similarity are treated as noise.
+	minVectorScore = 0.2
+	// Number of nearest neighbours fetched from the store per type.
+	vectorCandidates = 50
+	snippetRunes     = 160
+)
+
+type SearchHit struct {
+	Type         string  `json:"type"`
+	ID           string  `json:"id"`
+	Title        string  `json:"title"`
+	Subtitle     string  `json:"subtitle,omitempty"`
+	Snippet      string  `json:"snippet"`
+	Score        float64 `json:"score"`
+	VectorScore  float64 `json:"vector_score"`
+	KeywordScore float64 `json:"keyword_score"`
+
+	// Text is the full text the hit was scored on.
+	Text string `json:"-"`
+}
+
+type SearchService struct {
+	store    store.Store
+	embedder Embedder
+}
+
+func NewSearchService(s store.Store, embedder Embedder) *SearchService {
+	return &SearchService{store: s, embedder: embedder}
+}
+
+// Search ranks a campaign's characters and lore entries against query. types
+// limits the result to SearchHitCharacter and/or SearchHitLoreEntry; an empty
+// slice searches both. Only what visibility shows is searched.
+func (s *SearchService) Search(ctx context.Context, campaignID, query string, types []string, limit int, visibility *Visibility) ([]SearchHit, error) {
+	terms := uniqueTerms(Tokenize(query))
+
+	var queryEmbedding []float32
+	if s.embedder != nil {
+		embedding, err := s.embedder.Embed(ctx, query)
+		if err != nil {
+			// Fall back to keyword matching only
+			log.Printf("Search embedding error: %v", err)
+		} else {
+			queryEmbedding = embedding
+		}
+	}
+
+	var hits []SearchHit
+	if wantType(types, SearchHitCharacter) {
+		characterHits, err := s.searchCharacters(ctx, campaignID, queryEmbedding, terms, visibility)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, characterHits...)
+	}
+	if wantType(types, SearchHitLoreEntry) {
+		loreHits, err := s.searchLoreEntries(ctx, campaignID, queryEmbedding, terms, visibility)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, loreHits...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, nil
+}
+
+func (s *SearchService) searchCharacters(ctx context.Context, campaignID string, queryEmbedding []float32, terms []string, visibility *Visibility) ([]SearchHit, error) {
+	characters, err := s.store.ListCharacters(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	// The embeddings include GM-only attributes, so those characters are
+	// only matched by keyword when the attributes are hidden
+	vectorHidden := map[string]bool{}
+	if !visibility.GM() {
+		for i := range characters {
+			vectorHidden[characters[i].ID] = hasSecretAttributes(&characters[i])
+		}
+		characters = visibility.Characters(characters)
+	}
+
+	vectorScores := map[string]float64{}
+	if queryEmbedding != nil {
+		scored, err := s.store.SimilarCharacters(ctx, campaignID, queryEmbedding, vectorCandidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, sc := range scored {
+			if !vectorHidden[sc.ID] {
+				vectorScores[sc.ID] = sc.Score
+			}
+		}
+	}
+
+	var hits []SearchHit
+	for i := range characters {
+		character := &characters[i]
+		text := CharacterEmbeddingText(character)
+		hit, ok := blend(vectorScores[character.ID], keywordScore(terms, character.Name, text))
+		if !ok {
+			continue
+		}
+
+		hit.Type = SearchHitCharacter
+		hit.ID = character.ID
+		hit.Title = character.Name
+		hit.Subtitle = character.Role
+		hit.Text = text
+		hit.Snippet = snippet(character.Background, terms)
+		if hit.Snippet == "" {
+			hit.Snippet = snippet(text, terms)
+		}
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+func (s *SearchService) searchLoreEntries(ctx context.Context, campaignID string, queryEmbedding []float32, terms []string, visibility *Visibility) ([]SearchHit, error) {
+	loreEntries, err := s.store.ListLoreEntries(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	loreEntries = visibility.LoreEntries(loreEntries)
+
+	vectorScores := map[string]float64{}
+	if queryEmbedding != nil {
+		scored, err := s.store.SimilarLoreEntries(ctx, campaignID, queryEmbedding, vectorCandidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, sc := range scored {
+			vectorScores[sc.ID] = sc.Score
+		}
+	}
+
+	var hits []SearchHit
+	for i := range loreEntries {
+		loreEntry := &loreEntries[i]
+		text := LoreEntryEmbeddingText(loreEntry)
+		hit, ok := blend(vectorScores[loreEntry.ID], keywordScore(terms, loreEntry.Title, text))
+		if !ok {
+			continue
+		}
+
+		hit.Type = SearchHitLoreEntry
+		hit.ID = loreEntry.ID
+		hit.Title = loreEntry.Title
+		hit.Subtitle = loreEntry.Category
+		hit.Text = text
+		hit.Snippet = snippet(loreEntry.Content, terms)
+		hits = append(hits, hit)
+	}
+
+	return hits, nil
+}
+
+func blend(vectorScore, keywordScore float64) (SearchHit, bool) {
+	if keywordScore == 0 && vectorScore < minVectorScore {
+		return SearchHit{}, false
+	}
+	if vectorScore < 0 {
+		vectorScore = 0
+	}
+
+	return SearchHit{
+		Score:        vectorWeight*vectorScore + keywordWeight*keywordScore,
+		VectorScore:  vectorScore,
+		KeywordScore: keywordScore,
+	}, true
+}
+
+// keywordScore is based on the fraction of query terms found in text. A title
+// containing every term scores 1. The result is in [0, 1].
+func keywordScore(terms []string, title, text string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+
+	matched := 0
+	for _, term := range terms {
+		if containsTerm(text, term) {
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0
+	}
+
+	for _, term := range terms {
+		if !containsTerm(title, term) {
+			return 0.8 * float64(matched) / float64(len(terms))
+		}
+	}
+
+	return 1
+}
+
+// containsTerm matches whole words, except for CJK terms: a one-character query
+// never equals one of the bigrams Tokenize produces, so those match as substrings.
+func containsTerm(text, term string) bool {
+	for _, r := range term {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+			return strings.Contains(strings.ToLower(text), term)
+		}
+	}
+
+	for _, token := range Tokenize(text) {
+		if token == term {
+			return true
+		}
+	}
+	return false
+}
+
+// snippet returns about snippetRunes runes of text around the first term
+// occurrence, or the start of text when no term occurs.
+func snippet(text string, terms []string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+
+	runes := []rune(text)
+	lower := []rune(strings.ToLower(text))
+	start := 0
+	if len(lower) == len(runes) {
+		lowerText := string(lower)
+		best := -1
+		for _, term := range terms {
+			if i := strings.Index(lowerText, term); i >= 0 && (best < 0 || i < best) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			start = utf8.RuneCountInString(lowerText[:best]) - snippetRunes/4
+			if start < 0 {
+				start = 0
+			}
+		}
+	}
+
+	end := start + snippetRunes
+	if end > len(runes) {
+		end = len(runes)
+		start = end - snippetRunes
+		if start < 0 {
+			start = 0
+		}
+	}
+
+	result := string(runes[start:end])
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
+
+func uniqueTerms(tokens []string) []string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, token := range tokens {
+		if !seen[token] {
+			seen[token] = true
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+func wantType(types []string, hitType string) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == hitType {
+			return true
+		}
+	}
+	return false
+}
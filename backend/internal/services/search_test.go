@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+func TestSearchRanking(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	embedder := NewHashingEmbedder(EmbeddingDimensions)
+	campaignID, _ := seedCampaign(t, s)
+
+	embed := func(text string) []float32 {
+		embedding, err := embedder.Embed(ctx, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return embedding
+	}
+	texts := map[string]string{}
+	for _, c := range []models.Character{
+		{Name: "Bea", Role: "Smuggler", Background: "Runs boats out of the harbour at night."},
+		// Mentioned once among many other words, so the vector alone is too
+		// weak to find him
+		{Name: "Aldo", Role: "Innkeeper", Background: "Keeps the Drowned Rat, pours cheap ale, listens to every sailor, remembers debts, never forgets faces, sleeps above the cellar and waters the wine."},
+		{Name: "Cora", Role: "Fisher", Background: "Mends nets."},
+	} {
+		c.CampaignID = campaignID
+		created, err := s.CreateCharacter(ctx, &c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts[created.Name] = CharacterEmbeddingText(created)
+		if err := s.SetCharacterEmbedding(ctx, created.ID, embed(texts[created.Name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, l := range []models.LoreEntry{
+		{Title: "Harbour Smugglers", Content: "Smugglers use the harbour."},
+		{Title: "Docks", Content: "The harbour is busy and loud."},
+		{Title: "Smuggler's Cove", Content: "Where the harbour smugglers hide the cargo.", Visibility: models.VisibilityGM},
+		{Title: "Lighthouse", Content: "Dark since the storm."},
+	} {
+		l.CampaignID = campaignID
+		created, err := s.CreateLoreEntry(ctx, &l)
+		if err != nil {
+			t.Fatal(err)
+		}
+		texts[created.Title] = LoreEntryEmbeddingText(created)
+		if err := s.SetLoreEntryEmbedding(ctx, created.ID, embed(texts[created.Title])); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := NewSearchService(s, embedder)
+	gm := NewVisibility(models.RoleOwner, nil)
+	player := NewVisibility(models.RolePlayer, nil)
+
+	tests := []struct {
+		name       string
+		query      string
+		types      []string
+		limit      int
+		visibility *Visibility
+		want       string
+	}{
+		// Both terms in the title first, then both in the text, then one
+		{name: "keywords and vectors", query: "harbour smugglers", visibility: gm, want: "Harbour Smugglers|Smuggler's Cove|Docks|Bea"},
+		{name: "GM-only entries hidden", query: "harbour smugglers", visibility: player, want: "Harbour Smugglers|Docks|Bea"},
+		{name: "characters only", query: "harbour smugglers", types: []string{SearchHitCharacter}, visibility: gm, want: "Bea"},
+		{name: "limit", query: "harbour smugglers", limit: 2, visibility: gm, want: "Harbour Smugglers|Smuggler's Cove"},
+		{name: "exact name with a weak vector", query: "Aldo", visibility: gm, want: "Aldo"},
+		{name: "nothing shared", query: "kraken", visibility: gm, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := search.Search(ctx, campaignID, tt.query, tt.types, tt.limit, tt.visibility)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var titles []string
+			for _, hit := range hits {
+				titles = append(titles, hit.Title)
+			}
+			if got := strings.Join(titles, "|"); got != tt.want {
+				t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+
+			query := embed(tt.query)
+			for _, hit := range hits {
+				var vector float64
+				for i, v := range embed(texts[hit.Title]) {
+					vector += float64(v) * float64(query[i])
+				}
+				if math.Abs(hit.VectorScore-vector) > 1e-6 {
+					t.Errorf("%s: vector score = %v, want %v", hit.Title, hit.VectorScore, vector)
+				}
+				if want := vectorWeight*hit.VectorScore + keywordWeight*hit.KeywordScore; math.Abs(hit.Score-want) > 1e-9 {
+					t.Errorf("%s: score = %v, want %v", hit.Title, hit.Score, want)
+				}
+			}
+		})
+	}
+
+	// The name decides the exact match, not the vector
+	hits, err := search.Search(ctx, campaignID, "Aldo", nil, 0, gm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].KeywordScore != 1 || hits[0].VectorScore >= minVectorScore {
+		t.Errorf("hits for Aldo = %+v, want a keyword match with a vector score under %v", hits, minVectorScore)
+	}
+}
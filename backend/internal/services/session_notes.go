@@ -0,0 +1,344 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// duplicateSimilarity is the embedding similarity above which an extracted
+// entity is taken to be an existing record under another name.
+const duplicateSimilarity = 0.85
+
+// ExtractedEntity is a character or lore entry the model found in session
+// notes. Kind is models.EntityCharacter or models.EntityLoreEntry.
+type ExtractedEntity struct {
+	Kind        string                 `json:"kind"`
+	Name        string                 `json:"name"`
+	Role        string                 `json:"role"`
+	Category    string                 `json:"category"`
+	Description string                 `json:"description"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Evidence    string                 `json:"evidence"`
+}
+
+// SessionNotesIngester turns raw session notes into a changeset of character
+// and lore entry proposals.
+type SessionNotesIngester struct {
+	store    store.Store
+	ai       *AIService
+	embedder Embedder
+}
+
+func NewSessionNotesIngester(s store.Store, ai *AIService, embedder Embedder) *SessionNotesIngester {
+	return &SessionNotesIngester{store: s, ai: ai, embedder: embedder}
+}
+
+// Ingest extracts the NPCs, places, items and facts in notes. Each one is
+// matched against the campaign by name, then by embedding similarity; matches
+// become updates carrying only what is new, the rest become creates.
+func (i *SessionNotesIngester) Ingest(ctx context.Context, campaignID, notes string) (*models.SessionNotesChangeset, error) {
+	characters, err := i.store.ListCharacters(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	loreEntries, err := i.store.ListLoreEntries(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	characterNames := make([]string, len(characters))
+	for n, c := range characters {
+		characterNames[n] = c.Name
+	}
+	loreTitles := make([]string, len(loreEntries))
+	for n, l := range loreEntries {
+		loreTitles[n] = l.Title
+	}
+
+	entities, err := i.ai.ExtractEntities(ctx, notes, characterNames, loreTitles)
+	if err != nil {
+		return nil, err
+	}
+
+	changeset := &models.SessionNotesChangeset{Proposals: []models.Proposal{}}
+	characterMatcher := newNameMatcher(characterNames)
+	loreMatcher := newNameMatcher(loreTitles)
+
+	for _, entity := range mergeEntities(entities) {
+		if entity.Kind == models.EntityCharacter {
+			existing, matchedBy := i.matchCharacter(ctx, campaignID, characters, characterMatcher, entity)
+			changeset.Proposals = append(changeset.Proposals, characterProposals(entity, existing, matchedBy)...)
+		} else {
+			existing, matchedBy := i.matchLoreEntry(ctx, campaignID, loreEntries, loreMatcher, entity)
+			changeset.Proposals = append(changeset.Proposals, loreEntryProposals(entity, existing, matchedBy)...)
+		}
+	}
+
+	return changeset, nil
+}
+
+func (i *SessionNotesIngester) matchCharacter(ctx context.Context, campaignID string, characters []models.Character, matcher *nameMatcher, entity ExtractedEntity) (*models.Character, string) {
+	if n := matcher.Match(entity.Name); n >= 0 {
+		return &characters[n], "name"
+	}
+
+	embedding := i.embed(ctx, CharacterEmbeddingText(&models.Character{Name: entity.Name, Role: entity.Role, Attributes: entity.Attributes, Background: entity.Description}))
+	if embedding == nil {
+		return nil, ""
+	}
+	similar, err := i.store.SimilarCharacters(ctx, campaignID, embedding, 1)
+	if err != nil {
+		log.Printf("Session notes similarity error: %v", err)
+		return nil, ""
+	}
+	if len(similar) == 0 || similar[0].Score < duplicateSimilarity {
+		return nil, ""
+	}
+	return &similar[0].Character, "embedding"
+}
+
+func (i *SessionNotesIngester) matchLoreEntry(ctx context.Context, campaignID string, loreEntries []models.LoreEntry, matcher *nameMatcher, entity ExtractedEntity) (*models.LoreEntry, string) {
+	if n := matcher.Match(entity.Name); n >= 0 {
+		return &loreEntries[n], "name"
+	}
+
+	embedding := i.embed(ctx, LoreEntryEmbeddingText(&models.LoreEntry{Title: entity.Name, Category: entity.Category, Content: entity.Description}))
+	if embedding == nil {
+		return nil, ""
+	}
+	similar, err := i.store.SimilarLoreEntries(ctx, campaignID, embedding, 1)
+	if err != nil {
+		log.Printf("Session notes similarity error: %v", err)
+		return nil, ""
+	}
+	if len(similar) == 0 || similar[0].Score < duplicateSimilarity {
+		return nil, ""
+	}
+	return &similar[0].LoreEntry, "embedding"
+}
+
+// embed returns nil when there is no embedder or it fails, in which case
+// entities are matched by name only.
+func (i *SessionNotesIngester) embed(ctx context.Context, text string) []float32 {
+	if i.embedder == nil {
+		return nil
+	}
+	embedding, err := i.embedder.Embed(ctx, text)
+	if err != nil {
+		log.Printf("Session notes embedding error: %v", err)
+		return nil
+	}
+	return embedding
+}
+
+// characterProposals creates the character, or for an existing one proposes
+// the description as background and each attribute that differs.
+func characterProposals(entity ExtractedEntity, existing *models.Character, matchedBy string) []models.Proposal {
+	if existing == nil {
+		return []models.Proposal{{
+			ID:      uuid.NewString(),
+			Kind:    models.ProposalCharacter,
+			Summary: fmt.Sprintf("New character: %s", entity.Name),
+			Character: &models.ProposedCharacter{
+				Name:       entity.Name,
+				Role:       entity.Role,
+				Background: entity.Description,
+				Attributes: entity.Attributes,
+			},
+			Evidence: entity.Evidence,
+		}}
+	}
+
+	var proposals []models.Proposal
+	if entity.Description != "" && !strings.Contains(existing.Background, entity.Description) {
+		proposals = append(proposals, models.Proposal{
+			ID:          uuid.NewString(),
+			Kind:        models.ProposalBackground,
+			Summary:     fmt.Sprintf("Add to %s's background", existing.Name),
+			CharacterID: existing.ID,
+			Background:  entity.Description,
+			Evidence:    entity.Evidence,
+			MatchedBy:   matchedBy,
+		})
+	}
+	for _, key := range sortedKeys(entity.Attributes) {
+		value := entity.Attributes[key]
+		if current, ok := existing.Attributes[key]; ok && reflect.DeepEqual(current, value) {
+			continue
+		}
+		proposals = append(proposals, models.Proposal{
+			ID:             uuid.NewString(),
+			Kind:           models.ProposalAttribute,
+			Summary:        fmt.Sprintf("Set %s's %s", existing.Name, key),
+			CharacterID:    existing.ID,
+			AttributeKey:   key,
+			AttributeValue: value,
+			Evidence:       entity.Evidence,
+			MatchedBy:      matchedBy,
+		})
+	}
+	return proposals
+}
+
+// loreEntryProposals creates the entry, or appends the description to an
+// existing one that does not already contain it.
+func loreEntryProposals(entity ExtractedEntity, existing *models.LoreEntry, matchedBy string) []models.Proposal {
+	if entity.Description == "" {
+		return nil
+	}
+
+	if existing == nil {
+		return []models.Proposal{{
+			ID:      uuid.NewString(),
+			Kind:    models.ProposalLoreEntry,
+			Summary: fmt.Sprintf("New lore entry: %s", entity.Name),
+			LoreEntry: &models.ProposedLoreEntry{
+				Title:    entity.Name,
+				Category: entity.Category,
+				Content:  entity.Description,
+			},
+			Evidence: entity.Evidence,
+		}}
+	}
+
+	if strings.Contains(existing.Content, entity.Description) {
+		return nil
+	}
+	return []models.Proposal{{
+		ID:          uuid.NewString(),
+		Kind:        models.ProposalLoreEntryUpdate,
+		Summary:     fmt.Sprintf("Add to %s", existing.Title),
+		LoreEntryID: existing.ID,
+		LoreEntry: &models.ProposedLoreEntry{
+			Title:    existing.Title,
+			Category: entity.Category,
+			Content:  entity.Description,
+		},
+		Evidence:  entity.Evidence,
+		MatchedBy: matchedBy,
+	}}
+}
+
+// mergeEntities combines entities the model listed more than once under the
+// same (normalised) name, keeping the first one's order.
+func mergeEntities(entities []ExtractedEntity) []ExtractedEntity {
+	var merged []ExtractedEntity
+	index := map[string]int{}
+	for _, entity := range entities {
+		key := entity.Kind + ":" + strings.Join(nameParts(entity.Name), "")
+		n, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, entity)
+			continue
+		}
+
+		m := &merged[n]
+		if entity.Description != "" && !strings.Contains(m.Description, entity.Description) {
+			m.Description = strings.TrimSpace(m.Description + "\n" + entity.Description)
+		}
+		if m.Role == "" {
+			m.Role = entity.Role
+		}
+		if m.Category == "" {
+			m.Category = entity.Category
+		}
+		for key, value := range entity.Attributes {
+			if m.Attributes == nil {
+				m.Attributes = map[string]interface{}{}
+			}
+			if _, exists := m.Attributes[key]; !exists {
+				m.Attributes[key] = value
+			}
+		}
+	}
+	return merged
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ExtractEntities asks the model for the characters, places, items and facts in
+// session notes. characters and loreTitles are what the campaign already has,
+// so the model can refer to them by their recorded names.
+func (s *AIService) ExtractEntities(ctx context.Context, notes string, characters, loreTitles []string) ([]ExtractedEntity, error) {
+	list := func(names []string) string {
+		if len(names) == 0 {
+			return "(none)"
+		}
+		return "- " + strings.Join(names, "\n- ")
+	}
+
+	prompt := fmt.Sprintf(`You turn a game master's raw session notes into world-building records.
+
+Known characters:
+%s
+
+Known lore entries:
+%s
+
+Session notes:
+%s
+
+List the NPCs, places, items, factions and facts about the world that the notes introduce or reveal. For known characters and lore entries, use the recorded name exactly and describe only what the notes add. Skip anything the notes do not say.
+
+Respond in JSON format:
+{"entities": [{
+  "kind": "character" | "lore_entry",
+  "name": "character name or lore entry title",
+  "role": "NPC or PC (characters only)",
+  "category": "location, item, faction, event or other (lore entries only)",
+  "description": "what the notes establish, written as a standalone paragraph",
+  "attributes": {"key": "value"} (characters only, optional),
+  "evidence": "exact quote from the notes"
+}]}`, list(characters), list(loreTitles), notes)
+
+	response, err := s.extraction.Complete(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	object := extractJSONObject(response)
+	if object == "" {
+		return nil, ErrUnparseableResponse
+	}
+	var reply struct {
+		Entities []ExtractedEntity `json:"entities"`
+	}
+	if err := json.Unmarshal([]byte(object), &reply); err != nil {
+		return nil, ErrUnparseableResponse
+	}
+
+	entities := make([]ExtractedEntity, 0, len(reply.Entities))
+	for _, e := range reply.Entities {
+		e.Kind = strings.ToLower(strings.TrimSpace(e.Kind))
+		e.Name = strings.TrimSpace(e.Name)
+		e.Role = strings.TrimSpace(e.Role)
+		e.Category = strings.TrimSpace(e.Category)
+		e.Description = strings.TrimSpace(e.Description)
+		e.Evidence = strings.TrimSpace(e.Evidence)
+		if e.Name == "" || (e.Kind != models.EntityCharacter && e.Kind != models.EntityLoreEntry) {
+			continue
+		}
+		if e.Kind == models.EntityLoreEntry {
+			e.Role, e.Attributes = "", nil
+		}
+		entities = append(entities, e)
+	}
+	return entities, nil
+}
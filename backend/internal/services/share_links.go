@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var (
+	ErrShareLinkNotFound = errors.New("share link not found or expired")
+	ErrInvalidShareLink  = errors.New("expires_at must be in the future")
+)
+
+// ShareLinkService manages the read-only links to a campaign and reads what
+// they expose. A link's token is a random value stored with it, so links
+// survive restarts and deleting the link revokes it.
+type ShareLinkService struct {
+	store store.Store
+}
+
+func NewShareLinkService(s store.Store) *ShareLinkService {
+	return &ShareLinkService{store: s}
+}
+
+// Create makes a link to campaign. Categories are trimmed and deduplicated;
+// none means every category.
+func (s *ShareLinkService) Create(ctx context.Context, campaign *models.Campaign, createdBy string, req *models.CreateShareLinkRequest) (*models.ShareLink, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidShareLink
+	}
+
+	categories := []string{}
+	seen := map[string]bool{}
+	for _, category := range req.Categories {
+		category = strings.TrimSpace(category)
+		if category != "" && !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+
+	return s.store.CreateShareLink(ctx, &models.ShareLink{
+		CampaignID: campaign.ID,
+		Label:      strings.TrimSpace(req.Label),
+		Categories: categories,
+		CreatedBy:  createdBy,
+		Token:      rand.Text(),
+		ExpiresAt:  req.ExpiresAt,
+	})
+}
+
+// List returns campaign's links, expired ones included, with their tokens.
+func (s *ShareLinkService) List(ctx context.Context, campaign *models.Campaign) ([]models.ShareLink, error) {
+	return s.store.ListShareLinks(ctx, campaign.ID)
+}
+
+// Resolve returns the link token belongs to. A token that is unknown, deleted
+// or expired, or whose campaign is in the trash, is ErrShareLinkNotFound.
+func (s *ShareLinkService) Resolve(ctx context.Context, token string) (*models.ShareLink, error) {
+	if token == "" {
+		return nil, ErrShareLinkNotFound
+	}
+
+	link, err := s.store.GetShareLinkByToken(ctx, token)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if link.ExpiresAt != nil && !time.Now().Before(*link.ExpiresAt) {
+		return nil, ErrShareLinkNotFound
+	}
+
+	_, err = s.store.GetCampaign(ctx, link.CampaignID)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// Campaign describes link's campaign.
+func (s *ShareLinkService) Campaign(ctx context.Context, link *models.ShareLink) (*models.SharedCampaign, error) {
+	campaign, err := s.store.GetCampaign(ctx, link.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SharedCampaign{
+		Title:       campaign.Title,
+		Description: campaign.Description,
+		Categories:  link.Categories,
+		ExpiresAt:   link.ExpiresAt,
+	}, nil
+}
+
+// visibility shows what a viewer of link's campaign sees.
+func (s *ShareLinkService) visibility(ctx context.Context, link *models.ShareLink) (*Visibility, []models.Character, error) {
+	characters, err := s.store.ListCharacters(ctx, link.CampaignID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewVisibility(models.RoleViewer, characters), characters, nil
+}
+
+func (s *ShareLinkService) Characters(ctx context.Context, link *models.ShareLink) ([]models.Character, error) {
+	visibility, characters, err := s.visibility(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	return visibility.Characters(characters), nil
+}
+
+// Character returns one of link's characters, or store.ErrNotFound if it is
+// not shared.
+func (s *ShareLinkService) Character(ctx context.Context, link *models.ShareLink, id string) (*models.Character, error) {
+	character, err := s.store.GetCharacter(ctx, id)
+	if err != nil {
+		return nil, store.ErrNotFound
+	}
+	if character.CampaignID != link.CampaignID || !NewVisibility(models.RoleViewer, nil).Character(character) {
+		return nil, store.ErrNotFound
+	}
+	return character, nil
+}
+
+func (s *ShareLinkService) Relationships(ctx context.Context, link *models.ShareLink) ([]models.Relationship, error) {
+	visibility, _, err := s.visibility(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := s.store.ListRelationships(ctx, link.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	return visibility.Relationships(relationships), nil
+}
+
+// sharesLoreEntry reports whether link exposes loreEntry.
+func sharesLoreEntry(link *models.ShareLink, loreEntry *models.LoreEntry) bool {
+	if !NewVisibility(models.RoleViewer, nil).LoreEntry(loreEntry) {
+		return false
+	}
+	if len(link.Categories) == 0 {
+		return true
+	}
+	for _, category := range link.Categories {
+		if category == loreEntry.Category {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ShareLinkService) LoreEntries(ctx context.Context, link *models.ShareLink) ([]models.LoreEntry, error) {
+	loreEntries, err := s.store.ListLoreEntries(ctx, link.CampaignID)
+	if err != nil {
+		return nil, err
+	}
+	shared := []models.LoreEntry{}
+	for _, l := range loreEntries {
+		if sharesLoreEntry(link, &l) {
+			shared = append(shared, l)
+		}
+	}
+	return shared, nil
+}
+
+// LoreEntry returns one of link's lore entries, or store.ErrNotFound if it is
+// not shared.
+func (s *ShareLinkService) LoreEntry(ctx context.Context, link *models.ShareLink, id string) (*models.LoreEntry, error) {
+	loreEntry, err := s.store.GetLoreEntry(ctx, id)
+	if err != nil {
+		return nil, store.ErrNotFound
+	}
+	if loreEntry.CampaignID != link.CampaignID || !sharesLoreEntry(link, loreEntry) {
+		return nil, store.ErrNotFound
+	}
+	return loreEntry, nil
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// suggestionStream picks complete elements out of a JSON array of suggestions
+// while the array is still arriving, so each suggestion can be shown as soon as
+// its closing quote or brace is received. Text before the opening bracket
+// (prose, code fences) is ignored, as are bare numbers and literals. Elements
+// are returned as raw JSON.
+type suggestionStream struct {
+	started  bool
+	depth    int
+	inString bool
+	escaped  bool
+	current  strings.Builder
+}
+
+// Write consumes the next piece of model output and returns the elements it
+// completed.
+func (s *suggestionStream) Write(text string) []json.RawMessage {
+	var completed []json.RawMessage
+	for _, r := range text {
+		if !s.started {
+			if r == '[' {
+				s.started = true
+				s.depth = 1
+			}
+			continue
+		}
+		if s.depth == 0 {
+			continue
+		}
+
+		// Runes are kept while inside a top-level element
+		capturing := s.inString || s.depth > 1
+		if s.inString {
+			s.current.WriteRune(r)
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case r == '\\':
+				s.escaped = true
+			case r == '"':
+				s.inString = false
+				if s.depth == 1 {
+					completed = s.emit(completed)
+				}
+			}
+			continue
+		}
+
+		switch r {
+		case '"':
+			s.inString = true
+			s.current.WriteRune(r)
+		case '[', '{':
+			s.depth++
+			s.current.WriteRune(r)
+		case ']', '}':
+			s.depth--
+			if capturing {
+				s.current.WriteRune(r)
+			}
+			if s.depth == 1 {
+				completed = s.emit(completed)
+			}
+		default:
+			if capturing {
+				s.current.WriteRune(r)
+			}
+		}
+	}
+	return completed
+}
+
+func (s *suggestionStream) emit(completed []json.RawMessage) []json.RawMessage {
+	element := s.current.String()
+	s.current.Reset()
+	if element == "" || !json.Valid([]byte(element)) {
+		return completed
+	}
+	return append(completed, json.RawMessage(element))
+}
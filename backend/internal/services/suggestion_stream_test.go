@@ -0,0 +1,71 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestionStream(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		// want lists the elements completed by each chunk
+		want [][]string
+	}{
+		{
+			name:   "strings split mid-string",
+			chunks: []string{`["fir`, `st", "sec`, `ond"]`},
+			want:   [][]string{nil, {`"first"`}, {`"second"`}},
+		},
+		{
+			name:   "split mid-escape",
+			chunks: []string{`["a \`, `"quoted\`, `" b", "c\`, `\"]`},
+			want:   [][]string{nil, nil, {`"a \"quoted\" b"`}, {`"c\\"`}},
+		},
+		{
+			name:   "escaped backslash before a closing quote",
+			chunks: []string{`["path \\`, `", "next"]`},
+			want:   [][]string{nil, {`"path \\"`, `"next"`}},
+		},
+		{
+			name:   "brackets inside strings and nested values",
+			chunks: []string{`[{"summary": "a ] or }", "n": [1,`, ` {"x": "{"}]}`, `, "after"]`},
+			want:   [][]string{nil, {`{"summary": "a ] or }", "n": [1, {"x": "{"}]}`}, {`"after"`}},
+		},
+		{
+			name:   "prose and code fence before the array",
+			chunks: []string{"Here are some ideas:\n```json\n", `["one"]`, "\n```"},
+			want:   [][]string{nil, {`"one"`}, nil},
+		},
+		{
+			name:   "bare numbers and literals are skipped",
+			chunks: []string{`[1, true, "kept", null]`},
+			want:   [][]string{{`"kept"`}},
+		},
+		{
+			name:   "multibyte text split between chunks",
+			chunks: []string{`["酒場の`, `主人", "宿屋"]`},
+			want:   [][]string{nil, {`"酒場の主人"`, `"宿屋"`}},
+		},
+		{
+			name:   "text after the array is ignored",
+			chunks: []string{`["done"] and ["not this"]`},
+			want:   [][]string{{`"done"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream suggestionStream
+			for i, chunk := range tt.chunks {
+				var got []string
+				for _, element := range stream.Write(chunk) {
+					got = append(got, string(element))
+				}
+				if !reflect.DeepEqual(got, tt.want[i]) {
+					t.Errorf("chunk %d (%q): got %q, want %q", i, chunk, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+var ErrNotInTrash = errors.New("not found in the trash")
+
+const defaultTrashRetentionDays = 30
+
+// TrashRetentionFromEnv returns how long trashed content is kept before it is
+// purged: TRASH_RETENTION_DAYS, 30 days by default. 0 keeps it until it is
+// purged by hand.
+func TrashRetentionFromEnv() time.Duration {
+	days := defaultTrashRetentionDays
+	if value := os.Getenv("TRASH_RETENTION_DAYS"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			log.Printf("Invalid TRASH_RETENTION_DAYS %q, keeping trashed content for %d days", value, days)
+		} else {
+			days = parsed
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// TrashCampaign moves a campaign to the trash. What is in it stays as it is
+// and comes back with the campaign.
+func TrashCampaign(ctx context.Context, s store.Store, campaign *models.Campaign) error {
+	now := time.Now().UTC()
+	return s.SetCampaignDeletedAt(ctx, campaign.ID, &now)
+}
+
+// TrashCharacter moves a character to the trash along with its relationships,
+// which are restored with it.
+func TrashCharacter(ctx context.Context, s store.Store, character *models.Character) error {
+	return s.WithTx(ctx, func(tx store.Store) error {
+		relationships, err := tx.ListRelationships(ctx, character.CampaignID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		if err := tx.SetCharacterDeletedAt(ctx, character.ID, &now); err != nil {
+			return err
+		}
+		for _, relationship := range relationships {
+			if relationship.SourceCharacterID == character.ID || relationship.TargetCharacterID == character.ID {
+				if err := tx.SetRelationshipDeletedAt(ctx, relationship.ID, &now); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func TrashLoreEntry(ctx context.Context, s store.Store, loreEntry *models.LoreEntry) error {
+	now := time.Now().UTC()
+	return s.SetLoreEntryDeletedAt(ctx, loreEntry.ID, &now)
+}
+
+// TrashService lists, restores and purges trashed content. Rows trashed
+// together, a character and its relationships or a pair of relationships,
+// share their deleted_at and are restored and purged together.
+type TrashService struct {
+	store     store.Store
+	retention time.Duration
+}
+
+func NewTrashService(s store.Store, retention time.Duration) *TrashService {
+	return &TrashService{store: s, retention: retention}
+}
+
+// List returns a campaign's trash, most recently trashed first.
+func (t *TrashService) List(ctx context.Context, campaignID string) (*models.Trash, error) {
+	characters, err := t.store.ListTrashedCharacters(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	relationships, err := t.store.ListTrashedRelationships(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	loreEntries, err := t.store.ListTrashedLoreEntries(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Trash{
+		Characters:    characters,
+		Relationships: relationships,
+		LoreEntries:   loreEntries,
+		RetentionDays: int(t.retention / (24 * time.Hour)),
+	}, nil
+}
+
+// trashedItems is what goes back, or away, with one trashed row.
+type trashedItems struct {
+	characters    []models.Character
+	relationships []models.Relationship
+	loreEntries   []models.LoreEntry
+}
+
+// find collects the trashed row of entityType with id, and the relationships
+// trashed with it.
+func (t *TrashService) find(ctx context.Context, campaignID, entityType, id string) (*trashedItems, error) {
+	trash, err := t.List(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	items := &trashedItems{}
+	switch entityType {
+	case models.EntityCharacter:
+		for _, character := range trash.Characters {
+			if character.ID != id {
+				continue
+			}
+			items.characters = append(items.characters, character)
+			for _, relationship := range trash.Relationships {
+				if relationship.DeletedAt.Equal(*character.DeletedAt) &&
+					(relationship.SourceCharacterID == id || relationship.TargetCharacterID == id) {
+					items.relationships = append(items.relationships, relationship)
+				}
+			}
+		}
+
+	case models.EntityRelationship:
+		for _, found := range trash.Relationships {
+			if found.ID != id {
+				continue
+			}
+			items.relationships = append(items.relationships, found)
+			for _, relationship := range trash.Relationships {
+				if relationship.DeletedAt.Equal(*found.DeletedAt) &&
+					relationship.SourceCharacterID == found.TargetCharacterID &&
+					relationship.TargetCharacterID == found.SourceCharacterID {
+					items.relationships = append(items.relationships, relationship)
+				}
+			}
+		}
+
+	case models.EntityLoreEntry:
+		for _, loreEntry := range trash.LoreEntries {
+			if loreEntry.ID == id {
+				items.loreEntries = append(items.loreEntries, loreEntry)
+			}
+		}
+	}
+
+	if len(items.characters) == 0 && len(items.relationships) == 0 && len(items.loreEntries) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotInTrash, entityType, id)
+	}
+	return items, nil
+}
+
+// Restore takes a row out of the trash. A character brings back the
+// relationships trashed with it, except those to a character that is still in
+// the trash or between characters that have since been given a new
+// relationship in the same direction; restoring such a relationship by itself
+// is a conflict.
+func (t *TrashService) Restore(ctx context.Context, campaignID, entityType, id string) error {
+	items, err := t.find(ctx, campaignID, entityType, id)
+	if err != nil {
+		return err
+	}
+
+	return t.store.WithTx(ctx, func(tx store.Store) error {
+		for _, character := range items.characters {
+			if err := tx.SetCharacterDeletedAt(ctx, character.ID, nil); err != nil {
+				return err
+			}
+		}
+
+		characters, err := tx.ListCharacters(ctx, campaignID)
+		if err != nil {
+			return err
+		}
+		live := make(map[string]bool, len(characters))
+		for _, character := range characters {
+			live[character.ID] = true
+		}
+
+		relationships, err := tx.ListRelationships(ctx, campaignID)
+		if err != nil {
+			return err
+		}
+		taken := make(map[[2]string]bool, len(relationships))
+		for _, relationship := range relationships {
+			taken[[2]string{relationship.SourceCharacterID, relationship.TargetCharacterID}] = true
+		}
+
+		for _, relationship := range items.relationships {
+			pair := [2]string{relationship.SourceCharacterID, relationship.TargetCharacterID}
+			var conflict string
+			switch {
+			case !live[relationship.SourceCharacterID] || !live[relationship.TargetCharacterID]:
+				conflict = "restore the relationship's characters first"
+			case taken[pair]:
+				conflict = "the characters already have a relationship in this direction"
+			}
+			if conflict != "" {
+				if entityType == models.EntityRelationship {
+					return fmt.Errorf("%w: %s", store.ErrConflict, conflict)
+				}
+				continue
+			}
+			if err := tx.SetRelationshipDeletedAt(ctx, relationship.ID, nil); err != nil {
+				return err
+			}
+			taken[pair] = true
+		}
+
+		for _, loreEntry := range items.loreEntries {
+			if err := tx.SetLoreEntryDeletedAt(ctx, loreEntry.ID, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Purge deletes a trashed row for good, with the relationships trashed with it.
+func (t *TrashService) Purge(ctx context.Context, campaignID, entityType, id string) error {
+	items, err := t.find(ctx, campaignID, entityType, id)
+	if err != nil {
+		return err
+	}
+	return t.purge(ctx, items)
+}
+
+// Empty deletes everything in a campaign's trash for good.
+func (t *TrashService) Empty(ctx context.Context, campaignID string) error {
+	trash, err := t.List(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+	return t.purge(ctx, &trashedItems{
+		characters:    trash.Characters,
+		relationships: trash.Relationships,
+		loreEntries:   trash.LoreEntries,
+	})
+}
+
+// purge runs outside a transaction: deleting is idempotent, and whatever a
+// failure leaves behind stays in the trash to be purged again.
+func (t *TrashService) purge(ctx context.Context, items *trashedItems) error {
+	for _, relationship := range items.relationships {
+		if err := t.store.DeleteRelationship(ctx, relationship.ID); err != nil {
+			return err
+		}
+	}
+	for _, character := range items.characters {
+		if err := t.store.DeleteCharacter(ctx, character.ID); err != nil {
+			return err
+		}
+	}
+	for _, loreEntry := range items.loreEntries {
+		if err := t.store.DeleteLoreEntry(ctx, loreEntry.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListCampaigns returns the trashed campaigns userID owns.
+func (t *TrashService) ListCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	return t.store.ListTrashedCampaigns(ctx, userID)
+}
+
+// trashedCampaign finds a campaign userID owns in the trash. Members cannot
+// see trashed campaigns.
+func (t *TrashService) trashedCampaign(ctx context.Context, userID, id string) (*models.Campaign, error) {
+	campaigns, err := t.store.ListTrashedCampaigns(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, campaign := range campaigns {
+		if campaign.ID == id {
+			return &campaign, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: campaign %s", ErrNotInTrash, id)
+}
+
+func (t *TrashService) RestoreCampaign(ctx context.Context, userID, id string) (*models.Campaign, error) {
+	campaign, err := t.trashedCampaign(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.store.SetCampaignDeletedAt(ctx, campaign.ID, nil); err != nil {
+		return nil, err
+	}
+
+	campaign.DeletedAt = nil
+	campaign.Role = models.RoleOwner
+	return campaign, nil
+}
+
+// PurgeCampaign deletes a trashed campaign and everything in it for good.
+func (t *TrashService) PurgeCampaign(ctx context.Context, userID, id string) error {
+	campaign, err := t.trashedCampaign(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	return t.store.DeleteCampaign(ctx, campaign.ID)
+}
+
+// PurgeExpired deletes everything trashed longer ago than the retention
+// period. It does nothing when the retention period is 0.
+func (t *TrashService) PurgeExpired(ctx context.Context) error {
+	if t.retention <= 0 {
+		return nil
+	}
+	return t.store.PurgeTrash(ctx, time.Now().UTC().Add(-t.retention))
+}
+
+// Run purges expired trash every interval until ctx is done.
+func (t *TrashService) Run(ctx context.Context, interval time.Duration) {
+	if t.retention <= 0 {
+		log.Println("TRASH_RETENTION_DAYS is 0: trashed content is kept until it is purged by hand")
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := t.PurgeExpired(ctx); err != nil {
+			log.Printf("Failed to purge expired trash: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// forEachStore runs test against the memory store, a migrated SQLite file and,
+// when DATABASE_URL is set, PostgreSQL.
+func forEachStore(t *testing.T, test func(t *testing.T, s store.Store)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, store.NewMemoryStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		s, err := store.NewSQLiteStore(context.Background(), filepath.Join(t.TempDir(), "lore-keeper.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		test(t, s)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		databaseURL := os.Getenv("DATABASE_URL")
+		if databaseURL == "" {
+			t.Skip("DATABASE_URL is not set")
+		}
+		s, err := store.NewPostgresStore(context.Background(), databaseURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer s.Close()
+		// Left over if an earlier run stopped halfway
+		deleteCampaigns(t, s, "00000000-0000-0000-0000-00000000000a", stranger)
+		defer deleteCampaigns(t, s, "00000000-0000-0000-0000-00000000000a", stranger)
+		test(t, s)
+	})
+}
+
+// deleteCampaigns deletes every campaign of the test users, which outlive the
+// test in a shared database.
+func deleteCampaigns(t *testing.T, s store.Store, userIDs ...string) {
+	t.Helper()
+	ctx := context.Background()
+	for _, userID := range userIDs {
+		campaigns, err := s.ListCampaigns(ctx, userID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, campaign := range campaigns {
+			if err := s.DeleteCampaign(ctx, campaign.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+// TestRelationshipsToTrashedCharacters checks that a relationship still live
+// while one of its characters is in the trash shows up nowhere, so an export
+// made in that state imports again.
+func TestRelationshipsToTrashedCharacters(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		campaignID, ids := seedCampaign(t, s, "Aldo", "Bea", "Cora")
+		for _, pair := range [][2]string{{"Aldo", "Bea"}, {"Bea", "Cora"}} {
+			_, err := s.CreateRelationship(ctx, &models.Relationship{
+				CampaignID: campaignID, SourceCharacterID: ids[pair[0]], TargetCharacterID: ids[pair[1]], RelationType: "friend",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Trash Cora alone, leaving Bea's relationship to her live
+		now := time.Now().UTC()
+		if err := s.SetCharacterDeletedAt(ctx, ids["Cora"], &now); err != nil {
+			t.Fatal(err)
+		}
+
+		listed, err := s.ListRelationships(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := edges(listed, ids); strings.Join(got, "|") != "Aldo friend Bea" {
+			t.Errorf("listed relationships = %q", got)
+		}
+
+		link := &models.ShareLink{CampaignID: campaignID}
+		shared, err := NewShareLinkService(s).Relationships(ctx, link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := edges(shared, ids); strings.Join(got, "|") != "Aldo friend Bea" {
+			t.Errorf("shared relationships = %q", got)
+		}
+
+		archives := NewArchiveService(s, nil)
+		campaign, err := s.GetCampaign(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		campaign.Role = models.RoleOwner
+		archive, err := archives.Export(ctx, campaign)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(archive.Characters) != 2 || len(archive.Relationships) != 1 {
+			t.Fatalf("archive has %d characters and %d relationships, want 2 and 1", len(archive.Characters), len(archive.Relationships))
+		}
+
+		imported, err := archives.Import(ctx, campaign.UserID, archive)
+		if err != nil {
+			t.Fatalf("import the export: %v", err)
+		}
+		relationships, err := s.ListRelationships(ctx, imported.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(relationships) != 1 {
+			t.Errorf("imported %d relationships, want 1", len(relationships))
+		}
+	})
+}
+
+func TestTrashCharacterWithRelationships(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		campaignID, ids := seedCampaign(t, s, "Aldo", "Bea", "Cora")
+		relationships := NewRelationshipService(s)
+		if _, err := relationships.CreateRelationType(ctx, &models.RelationType{CampaignID: campaignID, Name: "rival", Symmetric: true}); err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range []*models.Relationship{
+			{CampaignID: campaignID, SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Bea"], RelationType: "rival"},
+			{CampaignID: campaignID, SourceCharacterID: ids["Bea"], TargetCharacterID: ids["Cora"], RelationType: "friend"},
+		} {
+			if _, err := relationships.Create(ctx, r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		live := func(t *testing.T, want ...string) {
+			t.Helper()
+			list, err := s.ListRelationships(ctx, campaignID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := edges(list, ids); strings.Join(got, "|") != strings.Join(want, "|") {
+				t.Errorf("relationships = %q, want %q", got, want)
+			}
+		}
+
+		bea, err := s.GetCharacter(ctx, ids["Bea"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := TrashCharacter(ctx, s, bea); err != nil {
+			t.Fatal(err)
+		}
+		live(t)
+
+		trashService := NewTrashService(s, 30*24*time.Hour)
+		trash, err := trashService.List(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash.Characters) != 1 || len(trash.Relationships) != 3 || trash.RetentionDays != 30 {
+			t.Fatalf("trash has %d characters and %d relationships, retention %d days", len(trash.Characters), len(trash.Relationships), trash.RetentionDays)
+		}
+
+		// A relationship trashed with its character cannot come back alone
+		if err := trashService.Restore(ctx, campaignID, models.EntityRelationship, trash.Relationships[0].ID); !errors.Is(err, store.ErrConflict) {
+			t.Errorf("restoring a relationship of a trashed character: err = %v, want %v", err, store.ErrConflict)
+		}
+
+		if err := trashService.Restore(ctx, campaignID, models.EntityCharacter, ids["Bea"]); err != nil {
+			t.Fatal(err)
+		}
+		live(t, "Aldo rival Bea", "Bea friend Cora", "Bea rival Aldo")
+		if trash, err := trashService.List(ctx, campaignID); err != nil || len(trash.Characters)+len(trash.Relationships) != 0 {
+			t.Errorf("trash after restoring = %+v, %v", trash, err)
+		}
+
+		if err := trashService.Restore(ctx, campaignID, models.EntityCharacter, ids["Bea"]); !errors.Is(err, ErrNotInTrash) {
+			t.Errorf("restoring twice: err = %v, want %v", err, ErrNotInTrash)
+		}
+	})
+}
+
+func TestRecreatePairWhileTrashed(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		campaignID, ids := seedCampaign(t, s, "Aldo", "Bea")
+		relationships := NewRelationshipService(s)
+		if _, err := relationships.CreateRelationType(ctx, &models.RelationType{CampaignID: campaignID, Name: "mentor", InverseName: "apprentice"}); err != nil {
+			t.Fatal(err)
+		}
+
+		mentor := &models.Relationship{CampaignID: campaignID, SourceCharacterID: ids["Aldo"], TargetCharacterID: ids["Bea"], RelationType: "mentor"}
+		old, err := relationships.Create(ctx, mentor)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := relationships.Delete(ctx, old); err != nil {
+			t.Fatal(err)
+		}
+
+		// The trashed pair does not stand in the way of a new one
+		if _, err := relationships.Create(ctx, mentor); err != nil {
+			t.Fatalf("re-create while the old pair is in the trash: %v", err)
+		}
+		list, err := s.ListRelationships(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := edges(list, ids); strings.Join(got, "|") != "Aldo mentor Bea|Bea apprentice Aldo" {
+			t.Errorf("relationships = %q", got)
+		}
+
+		// Restoring the old pair now would duplicate it
+		trashService := NewTrashService(s, 0)
+		if err := trashService.Restore(ctx, campaignID, models.EntityRelationship, old.ID); !errors.Is(err, store.ErrConflict) {
+			t.Errorf("restore over the new pair: err = %v, want %v", err, store.ErrConflict)
+		}
+
+		// Purging the old one takes its pair and leaves the new ones
+		if err := trashService.Purge(ctx, campaignID, models.EntityRelationship, old.ID); err != nil {
+			t.Fatal(err)
+		}
+		trash, err := trashService.List(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash.Relationships) != 0 {
+			t.Errorf("trash still holds %d relationships", len(trash.Relationships))
+		}
+		if list, err := s.ListRelationships(ctx, campaignID); err != nil || len(list) != 2 {
+			t.Errorf("purge touched the live pair: %d left, %v", len(list), err)
+		}
+	})
+}
+
+func TestPurgeExpiredTrash(t *testing.T) {
+	forEachStore(t, func(t *testing.T, s store.Store) {
+		ctx := context.Background()
+		campaignID, ids := seedCampaign(t, s, "Expired", "Recent")
+		retention := 30 * 24 * time.Hour
+		now := time.Now().UTC()
+
+		trashedAt := map[string]time.Time{
+			"Expired": now.Add(-retention - time.Hour),
+			"Recent":  now.Add(-retention + time.Hour),
+		}
+		for name, at := range trashedAt {
+			if err := s.SetCharacterDeletedAt(ctx, ids[name], &at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		expiredLore, err := s.CreateLoreEntry(ctx, &models.LoreEntry{CampaignID: campaignID, Title: "Old Docks", Content: "Gone."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expiredAt := now.Add(-2 * retention)
+		if err := s.SetLoreEntryDeletedAt(ctx, expiredLore.ID, &expiredAt); err != nil {
+			t.Fatal(err)
+		}
+
+		// A retention of 0 keeps everything
+		if err := NewTrashService(s, 0).PurgeExpired(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if trash, err := NewTrashService(s, 0).List(ctx, campaignID); err != nil || len(trash.Characters) != 2 || len(trash.LoreEntries) != 1 {
+			t.Fatalf("trash after purging with no retention = %+v, %v", trash, err)
+		}
+
+		trashService := NewTrashService(s, retention)
+		if err := trashService.PurgeExpired(ctx); err != nil {
+			t.Fatal(err)
+		}
+		trash, err := trashService.List(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(trash.Characters) != 1 || trash.Characters[0].Name != "Recent" || len(trash.LoreEntries) != 0 {
+			t.Errorf("trash after purging = %+v", trash)
+		}
+		if _, err := s.GetCharacter(ctx, ids["Expired"]); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("expired character: err = %v, want %v", err, store.ErrNotFound)
+		}
+
+		// SQLite compares the times as text, which must still order them
+		// within a second, with and without fractions
+		cutoff := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		for _, at := range []time.Time{cutoff.Add(-time.Millisecond), cutoff, cutoff.Add(time.Millisecond)} {
+			loreEntry, err := s.CreateLoreEntry(ctx, &models.LoreEntry{CampaignID: campaignID, Title: at.Format(time.RFC3339Nano), Content: "-"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetLoreEntryDeletedAt(ctx, loreEntry.ID, &at); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := s.PurgeTrash(ctx, cutoff); err != nil {
+			t.Fatal(err)
+		}
+		loreEntries, err := s.ListTrashedLoreEntries(ctx, campaignID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var kept []string
+		for _, loreEntry := range loreEntries {
+			kept = append(kept, loreEntry.Title)
+		}
+		sort.Strings(kept)
+		if want := []string{"2026-01-02T03:04:05.001Z", "2026-01-02T03:04:05Z"}; strings.Join(kept, "|") != strings.Join(want, "|") {
+			t.Errorf("kept %q, want %q", kept, want)
+		}
+	})
+}
@@ -0,0 +1,838 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/goccy/go-yaml"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// Markdown vault layout, shared by export and import:
+//
+//	<campaign>/index.md
+//	<campaign>/Characters/<name>.md
+//	<campaign>/Lore/<category>/<title>.md
+//
+// Every note has YAML front matter with its type. Relationships are listed in
+// the source character's note as "- → [[Target]]: type — description", and in
+// the target's as "- ← [[Source]]: type", with "(gm)" after the type of
+// GM-only ones.
+const (
+	vaultCharactersDir = "Characters"
+	vaultLoreDir       = "Lore"
+	vaultIndex         = "index"
+
+	vaultTypeCharacter = "character"
+	vaultTypeLoreEntry = "lore_entry"
+	vaultTypeIndex     = "index"
+
+	vaultRelationshipsHeading = "## Relationships"
+)
+
+type characterFrontMatter struct {
+	Type       string                 `yaml:"type"`
+	ID         string                 `yaml:"id,omitempty"`
+	Role       string                 `yaml:"role,omitempty"`
+	Visibility string                 `yaml:"visibility,omitempty"`
+	Attributes map[string]interface{} `yaml:"attributes,omitempty"`
+	// AttributeVisibility lists the GM-only attributes
+	AttributeVisibility map[string]string `yaml:"attribute_visibility,omitempty"`
+}
+
+type loreEntryFrontMatter struct {
+	Type       string `yaml:"type"`
+	ID         string `yaml:"id,omitempty"`
+	Category   string `yaml:"category,omitempty"`
+	Visibility string `yaml:"visibility,omitempty"`
+}
+
+type indexFrontMatter struct {
+	Type       string `yaml:"type"`
+	CampaignID string `yaml:"campaign_id"`
+}
+
+// ExportMarkdown renders a campaign as a zip of Markdown notes that can be
+// opened as an Obsidian vault. Names of other characters and lore entries are
+// turned into wikilinks where they are first mentioned in a note.
+func (a *ArchiveService) ExportMarkdown(ctx context.Context, campaign *models.Campaign) ([]byte, error) {
+	archive, err := a.Export(ctx, campaign)
+	if err != nil {
+		return nil, err
+	}
+
+	root := vaultFileName(campaign.Title)
+	names := newVaultNames()
+	characterNotes := make(map[string]string, len(archive.Characters))
+	for _, c := range archive.Characters {
+		characterNotes[c.ID] = names.claim(c.Name)
+	}
+	loreNotes := make(map[string]string, len(archive.LoreEntries))
+	for _, l := range archive.LoreEntries {
+		loreNotes[l.ID] = names.claim(l.Title)
+	}
+
+	// Every note can link to every other by name
+	var targets []vaultLink
+	for _, c := range archive.Characters {
+		targets = append(targets, vaultLink{id: c.ID, name: c.Name, note: characterNotes[c.ID]})
+	}
+	for _, l := range archive.LoreEntries {
+		targets = append(targets, vaultLink{id: l.ID, name: l.Title, note: loreNotes[l.ID]})
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return len([]rune(targets[i].name)) > len([]rune(targets[j].name))
+	})
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	write := func(name string, frontMatter interface{}, body string) error {
+		header, err := yaml.Marshal(frontMatter)
+		if err != nil {
+			return err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: archive.ExportedAt})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "---\n%s---\n\n%s\n", header, strings.TrimSpace(body))
+		return err
+	}
+
+	if err := write(path.Join(root, vaultIndex+".md"), indexFrontMatter{Type: vaultTypeIndex, CampaignID: campaign.ID}, vaultIndexBody(archive, characterNotes, loreNotes)); err != nil {
+		return nil, err
+	}
+
+	charactersByID := make(map[string]models.ArchivedCharacter, len(archive.Characters))
+	for _, c := range archive.Characters {
+		charactersByID[c.ID] = c
+	}
+
+	for _, c := range archive.Characters {
+		var body strings.Builder
+		fmt.Fprintf(&body, "# %s\n\n", c.Name)
+		if c.Background != "" {
+			fmt.Fprintf(&body, "%s\n\n", linkMentions(c.Background, c.ID, targets))
+		}
+
+		var lines []string
+		for _, r := range archive.Relationships {
+			var arrow, otherID string
+			switch c.ID {
+			case r.SourceCharacterID:
+				arrow, otherID = "→", r.TargetCharacterID
+			case r.TargetCharacterID:
+				arrow, otherID = "←", r.SourceCharacterID
+			default:
+				continue
+			}
+			line := fmt.Sprintf("- %s %s: %s", arrow, wikilink(characterNotes[otherID], charactersByID[otherID].Name), r.RelationType)
+			if r.Visibility == models.VisibilityGM {
+				line += " (" + models.VisibilityGM + ")"
+			}
+			if r.Description != "" && arrow == "→" {
+				line += " — " + strings.Join(strings.Fields(r.Description), " ")
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&body, "%s\n\n%s\n", vaultRelationshipsHeading, strings.Join(lines, "\n"))
+		}
+
+		frontMatter := characterFrontMatter{
+			Type:                vaultTypeCharacter,
+			ID:                  c.ID,
+			Role:                c.Role,
+			Visibility:          vaultVisibility(c.Visibility),
+			Attributes:          frontMatterAttributes(c.Attributes),
+			AttributeVisibility: c.AttributeVisibility,
+		}
+		if err := write(path.Join(root, vaultCharactersDir, characterNotes[c.ID]+".md"), frontMatter, body.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range archive.LoreEntries {
+		dir := path.Join(root, vaultLoreDir)
+		if l.Category != "" {
+			dir = path.Join(dir, vaultFileName(l.Category))
+		}
+		body := fmt.Sprintf("# %s\n\n%s\n", l.Title, linkMentions(l.Content, l.ID, targets))
+		frontMatter := loreEntryFrontMatter{Type: vaultTypeLoreEntry, ID: l.ID, Category: l.Category, Visibility: vaultVisibility(l.Visibility)}
+		if err := write(path.Join(dir, loreNotes[l.ID]+".md"), frontMatter, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// vaultVisibility leaves public, the default, out of the front matter.
+func vaultVisibility(v string) string {
+	if v == models.VisibilityPublic {
+		return ""
+	}
+	return v
+}
+
+// frontMatterAttributes writes whole numbers, which JSON decodes as float64, as
+// integers so that an age of 17 is not rendered as 17.0.
+func frontMatterAttributes(attributes map[string]interface{}) map[string]interface{} {
+	if len(attributes) == 0 {
+		return nil
+	}
+	converted := make(map[string]interface{}, len(attributes))
+	for key, value := range attributes {
+		if f, ok := value.(float64); ok && f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+			value = int64(f)
+		}
+		converted[key] = value
+	}
+	return converted
+}
+
+func vaultIndexBody(archive *models.CampaignArchive, characterNotes, loreNotes map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", archive.Campaign.Title)
+	if archive.Campaign.Description != "" {
+		fmt.Fprintf(&b, "%s\n\n", archive.Campaign.Description)
+	}
+
+	if len(archive.Characters) > 0 {
+		b.WriteString("## Characters\n\n")
+		for _, c := range archive.Characters {
+			fmt.Fprintf(&b, "- %s", wikilink(characterNotes[c.ID], c.Name))
+			if c.Role != "" {
+				fmt.Fprintf(&b, " (%s)", c.Role)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if len(archive.LoreEntries) > 0 {
+		b.WriteString("## Lore\n")
+		byCategory := map[string][]models.ArchivedLoreEntry{}
+		var categories []string
+		for _, l := range archive.LoreEntries {
+			if _, ok := byCategory[l.Category]; !ok {
+				categories = append(categories, l.Category)
+			}
+			byCategory[l.Category] = append(byCategory[l.Category], l)
+		}
+		sort.Strings(categories)
+		for _, category := range categories {
+			if category != "" {
+				fmt.Fprintf(&b, "\n### %s\n", category)
+			}
+			b.WriteString("\n")
+			for _, l := range byCategory[category] {
+				fmt.Fprintf(&b, "- %s\n", wikilink(loreNotes[l.ID], l.Title))
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// vaultLink is a note that mentions of name can link to.
+type vaultLink struct {
+	id   string
+	name string
+	note string
+}
+
+// linkMentions wikilinks the first mention of each target in text, longest
+// names first so "Aria Stormwind" is not linked as "Aria". selfID is skipped,
+// and Latin names only match as whole words.
+func linkMentions(text, selfID string, targets []vaultLink) string {
+	type mention struct {
+		start, end int
+		target     vaultLink
+	}
+	var mentions []mention
+	overlaps := func(start, end int) bool {
+		for _, m := range mentions {
+			if start < m.end && m.start < end {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, target := range targets {
+		if target.id == selfID || len([]rune(target.name)) < 2 {
+			continue
+		}
+		for offset := 0; offset < len(text); {
+			i := strings.Index(text[offset:], target.name)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(target.name)
+			offset = end
+			if overlaps(start, end) || !mentionBoundary(text, start, end) {
+				continue
+			}
+			mentions = append(mentions, mention{start, end, target})
+			break
+		}
+	}
+
+	sort.Slice(mentions, func(i, j int) bool { return mentions[i].start < mentions[j].start })
+	var b strings.Builder
+	last := 0
+	for _, m := range mentions {
+		b.WriteString(text[last:m.start])
+		b.WriteString(wikilink(m.target.note, text[m.start:m.end]))
+		last = m.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// mentionBoundary rejects matches inside a longer word ("Bram" in "Brambles")
+// or inside an existing wikilink. Names in scripts written without spaces have
+// no word boundaries to check.
+func mentionBoundary(text string, start, end int) bool {
+	if strings.Count(text[:start], "[[") > strings.Count(text[:start], "]]") {
+		return false
+	}
+	wordRune := func(r rune) bool {
+		return (unicode.IsLetter(r) || unicode.IsDigit(r)) && !isCJK(r)
+	}
+	before := []rune(text[:start])
+	after := []rune(text[end:])
+	name := []rune(text[start:end])
+	if len(before) > 0 && wordRune(before[len(before)-1]) && wordRune(name[0]) {
+		return false
+	}
+	if len(after) > 0 && wordRune(after[0]) && wordRune(name[len(name)-1]) {
+		return false
+	}
+	return true
+}
+
+func wikilink(note, label string) string {
+	if note == label {
+		return "[[" + note + "]]"
+	}
+	return "[[" + note + "|" + label + "]]"
+}
+
+// vaultNames hands out note names that are unique across the vault, since
+// Obsidian resolves wikilinks by file name regardless of folder.
+type vaultNames map[string]bool
+
+func newVaultNames() vaultNames {
+	return vaultNames{vaultIndex: true}
+}
+
+func (n vaultNames) claim(name string) string {
+	base := vaultFileName(name)
+	candidate := base
+	for i := 2; n[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s (%d)", base, i)
+	}
+	n[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// vaultFileName replaces the characters that file systems or Obsidian links
+// do not allow.
+func vaultFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|#^[]`, r) {
+			return '-'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// isCJK reports whether r belongs to a script written without spaces between
+// words.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// Limits on uploaded vaults, so a small zip cannot expand into gigabytes.
+const (
+	maxVaultNoteSize  = 1 << 20
+	maxVaultTotalSize = 64 << 20
+)
+
+// defaultRelationType is used for a wikilink between character notes that
+// does not say what the relationship is.
+const defaultRelationType = "related"
+
+var wikilinkPattern = regexp.MustCompile(`!?\[\[([^\]|#^]*)(?:[#^][^\]|]*)?(?:\|([^\]]*))?\]\]`)
+
+// vaultNote is one Markdown file of an uploaded vault.
+type vaultNote struct {
+	path        string // relative to the vault root, without ".md"
+	frontMatter map[string]interface{}
+	heading     string
+	body        string // without front matter and the leading heading
+}
+
+func (n *vaultNote) dir() string {
+	return path.Dir(n.path)
+}
+
+func (n *vaultNote) base() string {
+	return path.Base(n.path)
+}
+
+func (n *vaultNote) noteType() string {
+	return strings.ToLower(frontMatterString(n.frontMatter, "type"))
+}
+
+// name is the note's title: a name or title in the front matter, the first
+// heading, or the file name, in that order.
+func (n *vaultNote) name() string {
+	for _, key := range []string{"name", "title"} {
+		if name := frontMatterString(n.frontMatter, key); name != "" {
+			return name
+		}
+	}
+	if n.heading != "" {
+		return n.heading
+	}
+	return n.base()
+}
+
+// ImportMarkdown creates a campaign from a zip of Markdown notes, such as an
+// Obsidian vault or the output of ExportMarkdown. Notes with "type: character"
+// in their front matter become characters, the rest lore entries categorised
+// by their "category" front matter, first tag or folder. Wikilinks between
+// character notes become relationships. The campaign is named by the vault's
+// index note, its top-level folder or title, in that order. Malformed vaults
+// wrap ErrInvalidArchive.
+func (a *ArchiveService) ImportMarkdown(ctx context.Context, userID string, data []byte, title string) (*models.Campaign, error) {
+	notes, root, err := readVault(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArchive, err)
+	}
+	if root != "" {
+		title = root
+	}
+	archive := vaultArchive(notes, title)
+	if len(archive.Characters) == 0 && len(archive.LoreEntries) == 0 {
+		return nil, fmt.Errorf("%w: no Markdown notes found", ErrInvalidArchive)
+	}
+	return a.Import(ctx, userID, archive)
+}
+
+// readVault reads the Markdown notes in a zip, skipping hidden folders such as
+// .obsidian. When every note is inside one top-level folder, paths are made
+// relative to it and its name is returned as root.
+func readVault(data []byte) ([]vaultNote, string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, "", fmt.Errorf("not a zip file: %v", err)
+	}
+
+	var notes []vaultNote
+	var total int64
+	for _, f := range zr.File {
+		name := path.Clean(strings.ReplaceAll(f.Name, `\`, "/"))
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(name), ".md") || hiddenVaultPath(name) {
+			continue
+		}
+		if f.UncompressedSize64 > maxVaultNoteSize {
+			return nil, "", fmt.Errorf("%s is larger than %d bytes", name, maxVaultNoteSize)
+		}
+		total += int64(f.UncompressedSize64)
+		if total > maxVaultTotalSize {
+			return nil, "", fmt.Errorf("vault is larger than %d bytes", maxVaultTotalSize)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", name, err)
+		}
+		content, err := io.ReadAll(io.LimitReader(rc, maxVaultNoteSize+1))
+		rc.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", name, err)
+		}
+		if len(content) > maxVaultNoteSize {
+			return nil, "", fmt.Errorf("%s is larger than %d bytes", name, maxVaultNoteSize)
+		}
+
+		note, err := parseVaultNote(strings.TrimSuffix(name, path.Ext(name)), string(content))
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %v", name, err)
+		}
+		notes = append(notes, note)
+	}
+
+	// Zips of a folder put everything under the folder's name
+	var root string
+	if len(notes) > 0 {
+		root = strings.SplitN(notes[0].path, "/", 2)[0]
+		for _, n := range notes {
+			if !strings.HasPrefix(n.path, root+"/") {
+				root = ""
+				break
+			}
+		}
+		if root != "" {
+			for i := range notes {
+				notes[i].path = strings.TrimPrefix(notes[i].path, root+"/")
+			}
+		}
+	}
+	return notes, root, nil
+}
+
+func hiddenVaultPath(name string) bool {
+	for _, part := range strings.Split(name, "/") {
+		if strings.HasPrefix(part, ".") || part == "__MACOSX" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseVaultNote splits a note into its YAML front matter, leading "# "
+// heading and body.
+func parseVaultNote(notePath, content string) (vaultNote, error) {
+	note := vaultNote{path: notePath}
+	content = strings.TrimPrefix(strings.ReplaceAll(content, "\r\n", "\n"), "\uFEFF")
+
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		// An empty front matter block closes on its first line
+		header, body, found := "", "", false
+		if body, found = strings.CutPrefix(rest, "---"); !found {
+			header, body, found = strings.Cut(rest, "\n---")
+		}
+		if !found {
+			return note, errors.New("front matter is not closed")
+		}
+		if err := yaml.Unmarshal([]byte(header), &note.frontMatter); err != nil {
+			return note, fmt.Errorf("invalid front matter: %v", err)
+		}
+		content = body
+	}
+
+	content = strings.TrimSpace(content)
+	if heading, ok := strings.CutPrefix(content, "# "); ok {
+		heading, body, _ := strings.Cut(heading, "\n")
+		note.heading = strings.TrimSpace(unlinkWikilinks(heading))
+		content = strings.TrimSpace(body)
+	}
+	note.body = content
+	return note, nil
+}
+
+// vaultArchive maps notes onto a campaign archive, which Import then
+// validates and creates.
+func vaultArchive(notes []vaultNote, title string) *models.CampaignArchive {
+	archive := &models.CampaignArchive{
+		Version:       models.CampaignArchiveVersion,
+		ExportedAt:    time.Now().UTC(),
+		Campaign:      models.ArchivedCampaign{Title: strings.TrimSpace(title)},
+		Characters:    []models.ArchivedCharacter{},
+		Relationships: []models.ArchivedRelationship{},
+		LoreEntries:   []models.ArchivedLoreEntry{},
+	}
+
+	// Wikilinks name a note by file name, with or without its folder
+	var characterNotes []*vaultNote
+	links := map[string]string{}
+	for i := range notes {
+		n := &notes[i]
+		switch {
+		case n.noteType() == vaultTypeIndex || (n.path == vaultIndex && n.noteType() == ""):
+			if n.heading != "" {
+				archive.Campaign.Title = n.heading
+			}
+			description, _, _ := strings.Cut(n.body, "\n## ")
+			if !strings.HasPrefix(n.body, "## ") {
+				archive.Campaign.Description = strings.TrimSpace(unlinkWikilinks(description))
+			}
+
+		case n.noteType() == vaultTypeCharacter:
+			characterNotes = append(characterNotes, n)
+			links[strings.ToLower(n.path)] = n.path
+			links[strings.ToLower(n.base())] = n.path
+			links[strings.ToLower(n.name())] = n.path
+
+		default:
+			content := strings.TrimSpace(unlinkWikilinks(n.body))
+			if content == "" {
+				continue
+			}
+			archive.LoreEntries = append(archive.LoreEntries, models.ArchivedLoreEntry{
+				ID:         n.path,
+				Title:      n.name(),
+				Category:   vaultCategory(n),
+				Content:    content,
+				Visibility: noteVisibility(n.frontMatter),
+			})
+		}
+	}
+	if archive.Campaign.Title == "" {
+		archive.Campaign.Title = "Imported vault"
+	}
+
+	resolve := func(link string) string {
+		link = strings.TrimSuffix(strings.TrimSpace(link), ".md")
+		if p, ok := links[strings.ToLower(link)]; ok {
+			return p
+		}
+		return links[strings.ToLower(path.Base(link))]
+	}
+
+	// Explicit relationship lines first, so that a bare link does not claim
+	// the pair with the default relation type
+	pairs := map[[2]string]int{}
+	addRelationship := func(source, target, relationType, description, visibility string) {
+		if source == "" || target == "" || source == target {
+			return
+		}
+		// Both notes list an exported relationship, only the source's with
+		// its description
+		if i, ok := pairs[[2]string{source, target}]; ok {
+			if archive.Relationships[i].Description == "" {
+				archive.Relationships[i].Description = description
+			}
+			if visibility == models.VisibilityGM {
+				archive.Relationships[i].Visibility = visibility
+			}
+			return
+		}
+		pairs[[2]string{source, target}] = len(archive.Relationships)
+		archive.Relationships = append(archive.Relationships, models.ArchivedRelationship{
+			ID:                fmt.Sprintf("%s→%s", source, target),
+			SourceCharacterID: source,
+			TargetCharacterID: target,
+			RelationType:      relationType,
+			Description:       description,
+			Visibility:        visibility,
+		})
+	}
+
+	backgrounds := map[string]string{}
+	var mentions [][2]string
+	for _, n := range characterNotes {
+		var background []string
+		inRelationships := false
+		for _, line := range strings.Split(n.body, "\n") {
+			if strings.HasPrefix(line, "#") && strings.HasPrefix(strings.TrimLeft(line, "#"), " ") {
+				inRelationships = strings.TrimSpace(line) == vaultRelationshipsHeading
+				if inRelationships {
+					continue
+				}
+			}
+			if inRelationships {
+				if r, ok := parseRelationshipLine(line); ok {
+					if r.incoming {
+						addRelationship(resolve(r.link), n.path, r.relationType, r.description, r.visibility)
+					} else {
+						addRelationship(n.path, resolve(r.link), r.relationType, r.description, r.visibility)
+					}
+					continue
+				}
+			}
+			background = append(background, line)
+			for _, m := range wikilinkPattern.FindAllStringSubmatch(line, -1) {
+				if target := resolve(m[1]); target != "" {
+					mentions = append(mentions, [2]string{n.path, target})
+				}
+			}
+		}
+		backgrounds[n.path] = strings.TrimSpace(unlinkWikilinks(strings.Join(background, "\n")))
+	}
+	for _, m := range mentions {
+		if _, ok := pairs[[2]string{m[1], m[0]}]; !ok {
+			addRelationship(m[0], m[1], defaultRelationType, "", "")
+		}
+	}
+
+	for _, n := range characterNotes {
+		role := frontMatterString(n.frontMatter, "role")
+		if role == "" {
+			role = "NPC"
+		}
+		attributes := vaultAttributes(n.frontMatter)
+		archive.Characters = append(archive.Characters, models.ArchivedCharacter{
+			ID:                  n.path,
+			Name:                n.name(),
+			Role:                role,
+			Attributes:          attributes,
+			Background:          backgrounds[n.path],
+			Visibility:          noteVisibility(n.frontMatter),
+			AttributeVisibility: vaultAttributeVisibility(n.frontMatter, attributes),
+		})
+	}
+
+	return archive
+}
+
+// vaultRelationship is a line of a "## Relationships" section.
+type vaultRelationship struct {
+	link         string
+	incoming     bool
+	relationType string
+	description  string
+	visibility   string
+}
+
+// parseRelationshipLine reads the lines ExportMarkdown writes, "- → [[B]]:
+// type — description" and "- ← [[B]]: type (gm)", as well as the hand-written
+// "- [[B]]: type" and "- type: [[B]]".
+func parseRelationshipLine(line string) (vaultRelationship, bool) {
+	var r vaultRelationship
+	line, ok := strings.CutPrefix(strings.TrimSpace(line), "- ")
+	if !ok {
+		line, ok = strings.CutPrefix(line, "* ")
+	}
+	if !ok {
+		return r, false
+	}
+	line = strings.TrimSpace(line)
+	if rest, ok := strings.CutPrefix(line, "←"); ok {
+		r.incoming, line = true, strings.TrimSpace(rest)
+	} else {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "→"))
+	}
+
+	links := wikilinkPattern.FindAllStringSubmatchIndex(line, -1)
+	if len(links) != 1 {
+		return r, false
+	}
+	start, end := links[0][0], links[0][1]
+	r.link = line[links[0][2]:links[0][3]]
+
+	var label string
+	switch {
+	case start == 0:
+		label, ok = strings.CutPrefix(strings.TrimSpace(line[end:]), ":")
+	case strings.TrimSpace(line[end:]) == "":
+		label, ok = strings.CutSuffix(strings.TrimSpace(line[:start]), ":")
+	default:
+		ok = false
+	}
+	if !ok {
+		return r, false
+	}
+	relationType, description, _ := strings.Cut(label, "—")
+	relationType = strings.TrimSpace(relationType)
+	if t, ok := strings.CutSuffix(relationType, "("+models.VisibilityGM+")"); ok {
+		relationType, r.visibility = strings.TrimSpace(t), models.VisibilityGM
+	}
+	r.relationType = relationType
+	r.description = strings.TrimSpace(description)
+	return r, r.relationType != ""
+}
+
+// vaultCategory is a lore note's "category" front matter, its first tag, or
+// the folder it is in below the exported Lore folder.
+func vaultCategory(n *vaultNote) string {
+	if category := frontMatterString(n.frontMatter, "category"); category != "" {
+		return category
+	}
+	switch tags := n.frontMatter["tags"].(type) {
+	case string:
+		if fields := strings.FieldsFunc(tags, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }); len(fields) > 0 {
+			return strings.TrimPrefix(fields[0], "#")
+		}
+	case []interface{}:
+		if len(tags) > 0 {
+			if tag, ok := tags[0].(string); ok {
+				return strings.TrimPrefix(tag, "#")
+			}
+		}
+	}
+
+	dir := strings.TrimPrefix(strings.TrimPrefix(n.dir(), vaultLoreDir), "/")
+	if dir == "" || dir == "." || n.dir() == vaultLoreDir {
+		return ""
+	}
+	return path.Base(dir)
+}
+
+// vaultAttributes are a character note's "attributes" front matter, plus any
+// other keys that are not note metadata.
+func vaultAttributes(frontMatter map[string]interface{}) map[string]interface{} {
+	attributes := map[string]interface{}{}
+	for key, value := range frontMatter {
+		switch key {
+		case "type", "id", "name", "title", "role", "tags", "aliases", "cssclasses", "visibility", "attribute_visibility":
+		case "attributes":
+			if nested, ok := value.(map[string]interface{}); ok {
+				for k, v := range nested {
+					attributes[k] = v
+				}
+			}
+		default:
+			attributes[key] = value
+		}
+	}
+	return attributes
+}
+
+// noteVisibility reads "visibility: gm" from the front matter. Anything else
+// is public.
+func noteVisibility(frontMatter map[string]interface{}) string {
+	if strings.EqualFold(frontMatterString(frontMatter, "visibility"), models.VisibilityGM) {
+		return models.VisibilityGM
+	}
+	return models.VisibilityPublic
+}
+
+// vaultAttributeVisibility reads the "attribute_visibility" front matter of a
+// character note, keeping the GM-only attributes it has.
+func vaultAttributeVisibility(frontMatter map[string]interface{}, attributes map[string]interface{}) map[string]string {
+	nested, _ := frontMatter["attribute_visibility"].(map[string]interface{})
+	visibility := map[string]string{}
+	for key := range nested {
+		if strings.EqualFold(frontMatterString(nested, key), models.VisibilityGM) {
+			visibility[key] = models.VisibilityGM
+		}
+	}
+	return SecretAttributes(attributes, visibility)
+}
+
+func frontMatterString(frontMatter map[string]interface{}, key string) string {
+	switch value := frontMatter[key].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case nil:
+		return ""
+	default:
+		return strings.TrimSpace(fmt.Sprint(value))
+	}
+}
+
+// unlinkWikilinks replaces wikilinks with the text they display.
+func unlinkWikilinks(text string) string {
+	return wikilinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		m := wikilinkPattern.FindStringSubmatch(link)
+		if m[2] != "" {
+			return m[2]
+		}
+		return path.Base(m[1])
+	})
+}
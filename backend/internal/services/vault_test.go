@@ -0,0 +1,164 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/store"
+)
+
+// testVault zips notes, given as path and content pairs.
+func testVault(t *testing.T, notes ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i+1 < len(notes); i += 2 {
+		w, err := zw.Create(notes[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(notes[i+1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	campaign := seedArchiveCampaign(t, s)
+	archives := NewArchiveService(s, nil)
+
+	data, err := archives.ExportMarkdown(ctx, campaign)
+	if err != nil {
+		t.Fatal(err)
+	}
+	imported, err := archives.ImportMarkdown(ctx, stranger, data, "Upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.ID == campaign.ID || imported.Title != campaign.Title {
+		t.Errorf("imported campaign = %+v", imported)
+	}
+
+	// A vault has no place for relation types
+	var want []string
+	for _, line := range campaignSummary(t, s, campaign.ID) {
+		if !strings.HasPrefix(line, "relation type ") {
+			want = append(want, line)
+		}
+	}
+	got := campaignSummary(t, s, imported.ID)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("imported campaign:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestImportMarkdownVault(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	archives := NewArchiveService(s, nil)
+
+	data := testVault(t,
+		"Harbour/Aldo.md", "---\ntype: character\nrole: Innkeeper\n---\n# Aldo\n\nOwes [[Bea]] money, drinks with [[Cora|the fisher]].\n",
+		"Harbour/People/Cora.md", "---\ntype: character\n---\n",
+		"Harbour/People/Bea.md", "---\ntype: character\n---\nSmuggler.\n\n## Relationships\n\n- creditor: [[Aldo]]\n",
+		"Harbour/Docks.md", "---\ntags: [places]\n---\nWhere [[Bea]] lands her cargo.\n",
+		"Harbour/.obsidian/workspace.md", "Not a note.",
+		"__MACOSX/Harbour/._Aldo.md", "Not a note either.",
+		"Harbour/cover.png", "PNG",
+	)
+	imported, err := archives.ImportMarkdown(ctx, stranger, data, "harbour-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Named after the vault's folder. A mention makes a relationship unless
+	// the other character already lists one.
+	want := []string{
+		`character Aldo (Innkeeper, public) map[] map[] "Owes Bea money, drinks with the fisher."`,
+		`character Bea (NPC, public) map[] map[] "Smuggler."`,
+		`character Cora (NPC, public) map[] map[] ""`,
+		`lore places/Docks (public) "Where Bea lands her cargo."`,
+		`relationship Aldo related Cora (public) ""`,
+		`relationship Bea creditor Aldo (public) ""`,
+	}
+	got := campaignSummary(t, s, imported.ID)
+	if imported.Title != "Harbour" || strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("imported %q:\n%s\nwant:\n%s", imported.Title, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestImportMarkdownRejectsMalformedVault(t *testing.T) {
+	// Every note fits, but together they are over the limit
+	var large []string
+	note := "# Note\n\n" + strings.Repeat("x", maxVaultNoteSize-len("# Note\n\n"))
+	for i := 0; i <= maxVaultTotalSize/maxVaultNoteSize; i++ {
+		large = append(large, "Lore/"+strings.Repeat("n", i+1)+".md", note)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"not a zip", []byte("# Aldo\n"), "not a zip file"},
+		{"no notes", testVault(t, ".obsidian/app.md", "{}", "cover.png", "PNG"), "no Markdown notes found"},
+		{"front matter not closed", testVault(t, "Aldo.md", "---\ntype: character\n# Aldo\n"), "front matter is not closed"},
+		{"note over the limit", testVault(t, "Aldo.md", "# Aldo\n\n"+strings.Repeat("x", maxVaultNoteSize)), "Aldo.md is larger than"},
+		{"vault over the limit", testVault(t, large...), "vault is larger than"},
+	}
+
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	archives := NewArchiveService(s, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := archives.ImportMarkdown(ctx, stranger, tt.data, "Upload")
+			if !errors.Is(err, ErrInvalidArchive) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want %v with %q", err, ErrInvalidArchive, tt.wantErr)
+			}
+		})
+	}
+
+	campaigns, err := s.ListCampaigns(ctx, stranger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(campaigns) != 0 {
+		t.Errorf("rejected vaults created %d campaigns", len(campaigns))
+	}
+}
+
+func TestParseRelationshipLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want vaultRelationship
+		ok   bool
+	}{
+		{"- → [[Bea]]: mentor — Taught her to brew.", vaultRelationship{link: "Bea", relationType: "mentor", description: "Taught her to brew."}, true},
+		{"- ← [[Aldo]]: apprentice", vaultRelationship{link: "Aldo", incoming: true, relationType: "apprentice"}, true},
+		{"- → [[Bea|Captain Bea]]: creditor (gm) — Holds his debts.", vaultRelationship{link: "Bea", relationType: "creditor", description: "Holds his debts.", visibility: "gm"}, true},
+		{"- ← [[Aldo]]: creditor (gm)", vaultRelationship{link: "Aldo", incoming: true, relationType: "creditor", visibility: "gm"}, true},
+		{"* [[Bea]]: rival", vaultRelationship{link: "Bea", relationType: "rival"}, true},
+		{"- 師匠: [[アルド]]", vaultRelationship{link: "アルド", relationType: "師匠"}, true},
+		{"- [[Bea]]: (gm)", vaultRelationship{}, false},
+		{"- [[Bea]] and [[Cora]]: friends", vaultRelationship{}, false},
+		{"Owes [[Bea]]: money", vaultRelationship{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseRelationshipLine(tt.line)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("parseRelationshipLine(%q) = %+v, %v, want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
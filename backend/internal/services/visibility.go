@@ -0,0 +1,155 @@
+package services
+
+import (
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// CanSeeSecrets reports whether role sees GM-only content.
+func CanSeeSecrets(role string) bool {
+	return RoleAtLeast(role, models.RoleEditor)
+}
+
+// validVisibility accepts the visibility levels, and empty for public.
+func validVisibility(v string) bool {
+	return v == "" || v == models.VisibilityPublic || v == models.VisibilityGM
+}
+
+// UpdatedVisibility is the visibility an update sets when requested is sent
+// in place of current. Leaving it out keeps current, so older clients that do
+// not know about visibility cannot reveal secrets.
+func UpdatedVisibility(current, requested string) string {
+	if requested == "" {
+		return current
+	}
+	return requested
+}
+
+// Visibility filters campaign content down to what a campaign role may see.
+// The owner and editors see everything. Players and viewers only see public
+// characters, relationships and lore entries, characters without their GM-only
+// attributes, and no relationships of characters hidden from them. A nil
+// Visibility shows everything.
+type Visibility struct {
+	gm bool
+	// hidden holds the campaign's GM-only characters
+	hidden map[string]bool
+}
+
+// NewVisibility filters for role. characters are the campaign's characters,
+// needed to hide the relationships of GM-only ones.
+func NewVisibility(role string, characters []models.Character) *Visibility {
+	v := &Visibility{gm: CanSeeSecrets(role), hidden: map[string]bool{}}
+	for _, c := range characters {
+		if c.Visibility == models.VisibilityGM {
+			v.hidden[c.ID] = true
+		}
+	}
+	return v
+}
+
+// GM reports whether everything is visible.
+func (v *Visibility) GM() bool {
+	return v == nil || v.gm
+}
+
+// Character reports whether character is visible, removing its GM-only
+// attributes if they are not. The attribute visibility is cleared too, as it
+// would give away the names of the hidden attributes.
+func (v *Visibility) Character(character *models.Character) bool {
+	if v.GM() {
+		return true
+	}
+	if character.Visibility == models.VisibilityGM {
+		return false
+	}
+	if len(character.AttributeVisibility) > 0 {
+		attributes := make(map[string]interface{}, len(character.Attributes))
+		for key, value := range character.Attributes {
+			if character.AttributeVisibility[key] != models.VisibilityGM {
+				attributes[key] = value
+			}
+		}
+		character.Attributes = attributes
+	}
+	character.AttributeVisibility = nil
+	return true
+}
+
+func (v *Visibility) Characters(characters []models.Character) []models.Character {
+	if v.GM() {
+		return characters
+	}
+	visible := []models.Character{}
+	for _, c := range characters {
+		if v.Character(&c) {
+			visible = append(visible, c)
+		}
+	}
+	return visible
+}
+
+// Relationship reports whether relationship and both its characters are
+// visible.
+func (v *Visibility) Relationship(relationship *models.Relationship) bool {
+	if v.GM() {
+		return true
+	}
+	return relationship.Visibility != models.VisibilityGM &&
+		!v.hidden[relationship.SourceCharacterID] && !v.hidden[relationship.TargetCharacterID]
+}
+
+func (v *Visibility) Relationships(relationships []models.Relationship) []models.Relationship {
+	if v.GM() {
+		return relationships
+	}
+	visible := []models.Relationship{}
+	for _, r := range relationships {
+		if v.Relationship(&r) {
+			visible = append(visible, r)
+		}
+	}
+	return visible
+}
+
+func (v *Visibility) LoreEntry(loreEntry *models.LoreEntry) bool {
+	return v.GM() || loreEntry.Visibility != models.VisibilityGM
+}
+
+func (v *Visibility) LoreEntries(loreEntries []models.LoreEntry) []models.LoreEntry {
+	if v.GM() {
+		return loreEntries
+	}
+	visible := []models.LoreEntry{}
+	for _, l := range loreEntries {
+		if v.LoreEntry(&l) {
+			visible = append(visible, l)
+		}
+	}
+	return visible
+}
+
+// SecretAttributes keeps the GM-only entries of an attribute visibility that
+// are among attributes, as public is the default. It returns nil when no
+// attribute is secret.
+func SecretAttributes(attributes map[string]interface{}, visibility map[string]string) map[string]string {
+	var secret map[string]string
+	for key, v := range visibility {
+		if _, ok := attributes[key]; ok && v == models.VisibilityGM {
+			if secret == nil {
+				secret = map[string]string{}
+			}
+			secret[key] = v
+		}
+	}
+	return secret
+}
+
+// hasSecretAttributes reports whether any of character's attributes is GM-only.
+func hasSecretAttributes(character *models.Character) bool {
+	for key := range character.Attributes {
+		if character.AttributeVisibility[key] == models.VisibilityGM {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+func TestVisibilityCharacter(t *testing.T) {
+	character := func() models.Character {
+		return models.Character{
+			ID:                  "c1",
+			Name:                "Innkeeper",
+			Visibility:          models.VisibilityPublic,
+			Attributes:          map[string]interface{}{"age": 52.0, "true_name": "Prince Aldo"},
+			AttributeVisibility: map[string]string{"true_name": models.VisibilityGM, "age": models.VisibilityPublic},
+		}
+	}
+
+	tests := []struct {
+		role           string
+		wantAttributes map[string]interface{}
+		wantSecrets    bool
+	}{
+		{models.RoleOwner, map[string]interface{}{"age": 52.0, "true_name": "Prince Aldo"}, true},
+		{models.RoleEditor, map[string]interface{}{"age": 52.0, "true_name": "Prince Aldo"}, true},
+		{models.RolePlayer, map[string]interface{}{"age": 52.0}, false},
+		{models.RoleViewer, map[string]interface{}{"age": 52.0}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			c := character()
+			original := c.Attributes
+			if !NewVisibility(tt.role, nil).Character(&c) {
+				t.Fatal("public character is hidden")
+			}
+			if !reflect.DeepEqual(c.Attributes, tt.wantAttributes) {
+				t.Errorf("attributes = %v, want %v", c.Attributes, tt.wantAttributes)
+			}
+			// The attribute visibility would name the hidden keys
+			if (c.AttributeVisibility != nil) != tt.wantSecrets {
+				t.Errorf("attribute visibility = %v", c.AttributeVisibility)
+			}
+			if len(original) != 2 {
+				t.Errorf("the caller's attribute map was changed: %v", original)
+			}
+		})
+	}
+}
+
+func TestVisibilityHidesGMContent(t *testing.T) {
+	characters := []models.Character{
+		{ID: "public", Visibility: models.VisibilityPublic},
+		{ID: "secret", Visibility: models.VisibilityGM},
+		{ID: "unset"},
+	}
+	relationships := []models.Relationship{
+		{ID: "between public", SourceCharacterID: "public", TargetCharacterID: "unset"},
+		{ID: "gm relationship", SourceCharacterID: "public", TargetCharacterID: "unset", Visibility: models.VisibilityGM},
+		{ID: "to secret character", SourceCharacterID: "public", TargetCharacterID: "secret"},
+		{ID: "from secret character", SourceCharacterID: "secret", TargetCharacterID: "unset"},
+	}
+	loreEntries := []models.LoreEntry{
+		{ID: "public lore", Visibility: models.VisibilityPublic},
+		{ID: "gm lore", Visibility: models.VisibilityGM},
+	}
+
+	ids := func(items interface{}) []string {
+		var result []string
+		switch items := items.(type) {
+		case []models.Character:
+			for _, item := range items {
+				result = append(result, item.ID)
+			}
+		case []models.Relationship:
+			for _, item := range items {
+				result = append(result, item.ID)
+			}
+		case []models.LoreEntry:
+			for _, item := range items {
+				result = append(result, item.ID)
+			}
+		}
+		return result
+	}
+
+	for _, role := range []string{models.RolePlayer, models.RoleViewer} {
+		v := NewVisibility(role, characters)
+		if got, want := ids(v.Characters(characters)), []string{"public", "unset"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s characters = %v, want %v", role, got, want)
+		}
+		if got, want := ids(v.Relationships(relationships)), []string{"between public"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s relationships = %v, want %v", role, got, want)
+		}
+		if got, want := ids(v.LoreEntries(loreEntries)), []string{"public lore"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("%s lore entries = %v, want %v", role, got, want)
+		}
+	}
+
+	for _, role := range []string{models.RoleOwner, models.RoleEditor} {
+		v := NewVisibility(role, characters)
+		if len(v.Characters(characters)) != 3 || len(v.Relationships(relationships)) != 4 || len(v.LoreEntries(loreEntries)) != 2 {
+			t.Errorf("%s does not see everything", role)
+		}
+	}
+
+	var everything *Visibility
+	if !everything.GM() || len(everything.Relationships(relationships)) != 4 {
+		t.Error("a nil Visibility filters")
+	}
+}
@@ -0,0 +1,306 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// journalStore emulates a transaction for backends that have none. It records
+// how to undo every create and update made through it, so a failed fn can be
+// compensated. Deletes other than of relationships and invitations, and any
+// writes not overridden here, pass through and are not undone, and other
+// clients can see the intermediate state; it is a best effort, not isolation.
+type journalStore struct {
+	Store
+	undo []func(ctx context.Context) error
+}
+
+func withJournal(ctx context.Context, s Store, fn func(tx Store) error) error {
+	journal := &journalStore{Store: s}
+	err := fn(journal)
+	if err == nil {
+		return nil
+	}
+
+	// Undo in reverse order so later writes are reverted first
+	var undoErrs []error
+	for i := len(journal.undo) - 1; i >= 0; i-- {
+		if undoErr := journal.undo[i](ctx); undoErr != nil {
+			undoErrs = append(undoErrs, undoErr)
+		}
+	}
+	if len(undoErrs) > 0 {
+		return errors.Join(err, fmt.Errorf("rollback incomplete: %w", errors.Join(undoErrs...)))
+	}
+
+	return err
+}
+
+// relationshipRestorer is implemented by stores that can put a deleted
+// relationship back as it was, ID included.
+type relationshipRestorer interface {
+	restoreRelationship(ctx context.Context, relationship *models.Relationship) error
+}
+
+func (j *journalStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return fn(j)
+}
+
+func (j *journalStore) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	created, err := j.Store.CreateCampaign(ctx, campaign)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteCampaign(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+func (j *journalStore) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	previous, err := j.Store.GetCampaign(ctx, campaign.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := j.Store.UpdateCampaign(ctx, campaign)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			_, err := j.Store.UpdateCampaign(ctx, previous)
+			return err
+		})
+	}
+	return updated, err
+}
+
+func (j *journalStore) CreateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	created, err := j.Store.CreateCampaignMember(ctx, member)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteCampaignMember(ctx, created.CampaignID, created.UserID)
+		})
+	}
+	return created, err
+}
+
+// DeleteInvitation is undone by recreating the invitation with the same code.
+// Accepting an invitation uses it up, so this one is journaled.
+func (j *journalStore) DeleteInvitation(ctx context.Context, id string) error {
+	previous, err := j.Store.GetInvitation(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := j.Store.DeleteInvitation(ctx, id); err != nil {
+		return err
+	}
+	j.undo = append(j.undo, func(ctx context.Context) error {
+		_, err := j.Store.CreateInvitation(ctx, previous)
+		return err
+	})
+	return nil
+}
+
+func (j *journalStore) CreateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	created, err := j.Store.CreateCharacter(ctx, character)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteCharacter(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+func (j *journalStore) UpdateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	previous, err := j.Store.GetCharacter(ctx, character.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := j.Store.UpdateCharacter(ctx, character)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			_, err := j.Store.UpdateCharacter(ctx, previous)
+			return err
+		})
+	}
+	return updated, err
+}
+
+func (j *journalStore) CreateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	created, err := j.Store.CreateRelationship(ctx, relationship)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteRelationship(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+func (j *journalStore) UpdateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	previous, err := j.Store.GetRelationship(ctx, relationship.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := j.Store.UpdateRelationship(ctx, relationship)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			_, err := j.Store.UpdateRelationship(ctx, previous)
+			return err
+		})
+	}
+	return updated, err
+}
+
+// DeleteRelationship is undone by recreating the relationship, under the same
+// ID where the store allows it so that provenance and revisions still point at
+// it. Pairs of relationships are deleted together, so this one is journaled.
+func (j *journalStore) DeleteRelationship(ctx context.Context, id string) error {
+	previous, err := j.Store.GetRelationship(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := j.Store.DeleteRelationship(ctx, id); err != nil {
+		return err
+	}
+	j.undo = append(j.undo, func(ctx context.Context) error {
+		if restorer, ok := j.Store.(relationshipRestorer); ok {
+			return restorer.restoreRelationship(ctx, previous)
+		}
+		_, err := j.Store.CreateRelationship(ctx, previous)
+		return err
+	})
+	return nil
+}
+
+func (j *journalStore) CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	created, err := j.Store.CreateRelationType(ctx, relationType)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteRelationType(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+func (j *journalStore) UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	previous, err := j.Store.GetRelationType(ctx, relationType.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := j.Store.UpdateRelationType(ctx, relationType)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			_, err := j.Store.UpdateRelationType(ctx, previous)
+			return err
+		})
+	}
+	return updated, err
+}
+
+func (j *journalStore) CreateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	created, err := j.Store.CreateLoreEntry(ctx, loreEntry)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteLoreEntry(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+func (j *journalStore) UpdateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	previous, err := j.Store.GetLoreEntry(ctx, loreEntry.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := j.Store.UpdateLoreEntry(ctx, loreEntry)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			_, err := j.Store.UpdateLoreEntry(ctx, previous)
+			return err
+		})
+	}
+	return updated, err
+}
+
+func (j *journalStore) CreateRevision(ctx context.Context, revision *models.Revision) (*models.Revision, error) {
+	created, err := j.Store.CreateRevision(ctx, revision)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteRevision(ctx, created.ID)
+		})
+	}
+	return created, err
+}
+
+// trashed records the undo of moving a row to the trash, which is taking it
+// out again. Only rows outside the trash are moved there, so nothing else is
+// lost; taking rows out of the trash is not undone.
+func (j *journalStore) trashed(deletedAt *time.Time, restore func(ctx context.Context) error) {
+	if deletedAt != nil {
+		j.undo = append(j.undo, restore)
+	}
+}
+
+func (j *journalStore) SetCampaignDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	err := j.Store.SetCampaignDeletedAt(ctx, id, deletedAt)
+	if err == nil {
+		j.trashed(deletedAt, func(ctx context.Context) error {
+			return j.Store.SetCampaignDeletedAt(ctx, id, nil)
+		})
+	}
+	return err
+}
+
+func (j *journalStore) SetCharacterDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	err := j.Store.SetCharacterDeletedAt(ctx, id, deletedAt)
+	if err == nil {
+		j.trashed(deletedAt, func(ctx context.Context) error {
+			return j.Store.SetCharacterDeletedAt(ctx, id, nil)
+		})
+	}
+	return err
+}
+
+func (j *journalStore) SetRelationshipDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	err := j.Store.SetRelationshipDeletedAt(ctx, id, deletedAt)
+	if err == nil {
+		j.trashed(deletedAt, func(ctx context.Context) error {
+			return j.Store.SetRelationshipDeletedAt(ctx, id, nil)
+		})
+	}
+	return err
+}
+
+func (j *journalStore) SetLoreEntryDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	err := j.Store.SetLoreEntryDeletedAt(ctx, id, deletedAt)
+	if err == nil {
+		j.trashed(deletedAt, func(ctx context.Context) error {
+			return j.Store.SetLoreEntryDeletedAt(ctx, id, nil)
+		})
+	}
+	return err
+}
+
+func (j *journalStore) CreateProvenance(ctx context.Context, provenance *models.Provenance) (*models.Provenance, error) {
+	created, err := j.Store.CreateProvenance(ctx, provenance)
+	if err == nil {
+		j.undo = append(j.undo, func(ctx context.Context) error {
+			return j.Store.DeleteProvenance(ctx, created.ID)
+		})
+	}
+	return created, err
+}
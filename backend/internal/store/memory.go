@@ -0,0 +1,1188 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// MemoryStore keeps everything in process memory. It mirrors the foreign key,
+// cascade and uniqueness rules of database/init.sql so handlers behave the same
+// way they do against Supabase. It is meant for tests and local demos.
+type MemoryStore struct {
+	mu            sync.RWMutex
+	txMu          sync.Mutex
+	campaigns     map[string]models.Campaign
+	members       map[string]models.CampaignMember // keyed by memberKey
+	invitations   map[string]models.Invitation
+	shareLinks    map[string]models.ShareLink
+	characters    map[string]models.Character
+	relationships map[string]models.Relationship
+	relationTypes map[string]models.RelationType
+	loreEntries   map[string]models.LoreEntry
+	provenance    map[string]models.Provenance
+	revisions     map[string]models.Revision
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		campaigns:     make(map[string]models.Campaign),
+		members:       make(map[string]models.CampaignMember),
+		invitations:   make(map[string]models.Invitation),
+		shareLinks:    make(map[string]models.ShareLink),
+		characters:    make(map[string]models.Character),
+		relationships: make(map[string]models.Relationship),
+		relationTypes: make(map[string]models.RelationType),
+		loreEntries:   make(map[string]models.LoreEntry),
+		provenance:    make(map[string]models.Provenance),
+		revisions:     make(map[string]models.Revision),
+	}
+}
+
+// WithTx snapshots the store and restores the snapshot if fn fails.
+// Transactions are serialised with each other, but writes made outside a
+// transaction while fn runs are rolled back with it.
+func (s *MemoryStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	snapshot := s.snapshot()
+	s.mu.RUnlock()
+
+	if err := fn(s); err != nil {
+		s.mu.Lock()
+		s.campaigns = snapshot.campaigns
+		s.members = snapshot.members
+		s.invitations = snapshot.invitations
+		s.shareLinks = snapshot.shareLinks
+		s.characters = snapshot.characters
+		s.relationships = snapshot.relationships
+		s.relationTypes = snapshot.relationTypes
+		s.loreEntries = snapshot.loreEntries
+		s.provenance = snapshot.provenance
+		s.revisions = snapshot.revisions
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// snapshot copies the maps. Stored values are replaced, never mutated, on
+// write, so copying the entries is enough.
+func (s *MemoryStore) snapshot() *MemoryStore {
+	return &MemoryStore{
+		campaigns:     copyMap(s.campaigns),
+		members:       copyMap(s.members),
+		invitations:   copyMap(s.invitations),
+		shareLinks:    copyMap(s.shareLinks),
+		characters:    copyMap(s.characters),
+		relationships: copyMap(s.relationships),
+		relationTypes: copyMap(s.relationTypes),
+		loreEntries:   copyMap(s.loreEntries),
+		provenance:    copyMap(s.provenance),
+		revisions:     copyMap(s.revisions),
+	}
+}
+
+func copyMap[T any](m map[string]T) map[string]T {
+	copied := make(map[string]T, len(m))
+	for key, value := range m {
+		copied[key] = value
+	}
+	return copied
+}
+
+func (s *MemoryStore) ListCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	campaigns := []models.Campaign{}
+	for _, campaign := range s.campaigns {
+		if campaign.DeletedAt != nil {
+			continue
+		}
+		if _, member := s.members[memberKey(campaign.ID, userID)]; campaign.UserID == userID || member {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].CreatedAt.Before(campaigns[j].CreatedAt)
+	})
+
+	return campaigns, nil
+}
+
+func (s *MemoryStore) GetCampaign(ctx context.Context, id string) (*models.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	campaign, ok := s.campaigns[id]
+	if !ok || campaign.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	return &campaign, nil
+}
+
+func (s *MemoryStore) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC()
+	created := *campaign
+	created.ID = uuid.NewString()
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.campaigns[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.campaigns[campaign.ID]
+	if !ok || existing.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	existing.Title = campaign.Title
+	existing.Description = campaign.Description
+	existing.UpdatedAt = time.Now().UTC()
+	s.campaigns[existing.ID] = existing
+
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteCampaign(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteCampaign(id)
+	return nil
+}
+
+// deleteCampaign removes a campaign and everything in it. Callers hold s.mu.
+func (s *MemoryStore) deleteCampaign(id string) {
+	delete(s.campaigns, id)
+	for key, member := range s.members {
+		if member.CampaignID == id {
+			delete(s.members, key)
+		}
+	}
+	for invitationID, invitation := range s.invitations {
+		if invitation.CampaignID == id {
+			delete(s.invitations, invitationID)
+		}
+	}
+	for linkID, link := range s.shareLinks {
+		if link.CampaignID == id {
+			delete(s.shareLinks, linkID)
+		}
+	}
+	for characterID, character := range s.characters {
+		if character.CampaignID == id {
+			delete(s.characters, characterID)
+		}
+	}
+	for relationshipID, relationship := range s.relationships {
+		if relationship.CampaignID == id {
+			delete(s.relationships, relationshipID)
+		}
+	}
+	for relationTypeID, relationType := range s.relationTypes {
+		if relationType.CampaignID == id {
+			delete(s.relationTypes, relationTypeID)
+		}
+	}
+	for loreEntryID, loreEntry := range s.loreEntries {
+		if loreEntry.CampaignID == id {
+			delete(s.loreEntries, loreEntryID)
+		}
+	}
+	for provenanceID, provenance := range s.provenance {
+		if provenance.CampaignID == id {
+			delete(s.provenance, provenanceID)
+		}
+	}
+	for revisionID, revision := range s.revisions {
+		if revision.CampaignID == id {
+			delete(s.revisions, revisionID)
+		}
+	}
+}
+
+func memberKey(campaignID, userID string) string {
+	return campaignID + "/" + userID
+}
+
+func (s *MemoryStore) ListCampaignMembers(ctx context.Context, campaignID string) ([]models.CampaignMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	members := []models.CampaignMember{}
+	for _, member := range s.members {
+		if member.CampaignID == campaignID {
+			members = append(members, member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].CreatedAt.Before(members[j].CreatedAt)
+	})
+
+	return members, nil
+}
+
+func (s *MemoryStore) GetCampaignMember(ctx context.Context, campaignID, userID string) (*models.CampaignMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	member, ok := s.members[memberKey(campaignID, userID)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &member, nil
+}
+
+func (s *MemoryStore) CreateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[member.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", member.CampaignID, ErrNotFound)
+	}
+	key := memberKey(member.CampaignID, member.UserID)
+	if _, ok := s.members[key]; ok {
+		return nil, fmt.Errorf("member already exists: %w", ErrConflict)
+	}
+
+	now := time.Now().UTC()
+	created := *member
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.members[key] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := memberKey(member.CampaignID, member.UserID)
+	existing, ok := s.members[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	existing.Role = member.Role
+	existing.UpdatedAt = time.Now().UTC()
+	s.members[key] = existing
+
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteCampaignMember(ctx context.Context, campaignID, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.members, memberKey(campaignID, userID))
+	return nil
+}
+
+func (s *MemoryStore) listInvitations(match func(models.Invitation) bool) []models.Invitation {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitations := []models.Invitation{}
+	for _, invitation := range s.invitations {
+		if match(invitation) {
+			invitations = append(invitations, invitation)
+		}
+	}
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.Before(invitations[j].CreatedAt)
+	})
+	return invitations
+}
+
+func (s *MemoryStore) ListInvitations(ctx context.Context, campaignID string) ([]models.Invitation, error) {
+	return s.listInvitations(func(invitation models.Invitation) bool {
+		return invitation.CampaignID == campaignID
+	}), nil
+}
+
+func (s *MemoryStore) ListInvitationsForEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	return s.listInvitations(func(invitation models.Invitation) bool {
+		return invitation.Email != "" && strings.EqualFold(invitation.Email, email)
+	}), nil
+}
+
+func (s *MemoryStore) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	invitation, ok := s.invitations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &invitation, nil
+}
+
+func (s *MemoryStore) GetInvitationByCode(ctx context.Context, code string) (*models.Invitation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, invitation := range s.invitations {
+		if invitation.Code == code {
+			return &invitation, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) CreateInvitation(ctx context.Context, invitation *models.Invitation) (*models.Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[invitation.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", invitation.CampaignID, ErrNotFound)
+	}
+	for _, existing := range s.invitations {
+		if existing.Code == invitation.Code {
+			return nil, fmt.Errorf("invitation code already exists: %w", ErrConflict)
+		}
+	}
+
+	created := *invitation
+	created.ID = uuid.NewString()
+	created.ExpiresAt = created.ExpiresAt.UTC()
+	created.CreatedAt = time.Now().UTC()
+	s.invitations[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) DeleteInvitation(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.invitations, id)
+	return nil
+}
+
+func (s *MemoryStore) ListShareLinks(ctx context.Context, campaignID string) ([]models.ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	links := []models.ShareLink{}
+	for _, link := range s.shareLinks {
+		if link.CampaignID == campaignID {
+			links = append(links, link)
+		}
+	}
+	sort.Slice(links, func(i, j int) bool {
+		return links[i].CreatedAt.Before(links[j].CreatedAt)
+	})
+
+	return links, nil
+}
+
+func (s *MemoryStore) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	link, ok := s.shareLinks[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &link, nil
+}
+
+func (s *MemoryStore) GetShareLinkByToken(ctx context.Context, token string) (*models.ShareLink, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, link := range s.shareLinks {
+		if link.Token == token {
+			return &link, nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *MemoryStore) CreateShareLink(ctx context.Context, link *models.ShareLink) (*models.ShareLink, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[link.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", link.CampaignID, ErrNotFound)
+	}
+	for _, existing := range s.shareLinks {
+		if existing.Token == link.Token {
+			return nil, fmt.Errorf("share link token already exists: %w", ErrConflict)
+		}
+	}
+
+	created := *link
+	created.ID = uuid.NewString()
+	created.Categories = append([]string{}, link.Categories...)
+	if link.ExpiresAt != nil {
+		expiresAt := link.ExpiresAt.UTC()
+		created.ExpiresAt = &expiresAt
+	}
+	created.CreatedAt = time.Now().UTC()
+	s.shareLinks[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) DeleteShareLink(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.shareLinks, id)
+	return nil
+}
+
+func (s *MemoryStore) ListCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	characters := []models.Character{}
+	for _, character := range s.characters {
+		if character.CampaignID == campaignID && character.DeletedAt == nil {
+			characters = append(characters, copyCharacter(character))
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool {
+		return characters[i].CreatedAt.Before(characters[j].CreatedAt)
+	})
+
+	return characters, nil
+}
+
+func (s *MemoryStore) GetCharacter(ctx context.Context, id string) (*models.Character, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	character, ok := s.characters[id]
+	if !ok || character.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	character = copyCharacter(character)
+	return &character, nil
+}
+
+func (s *MemoryStore) CreateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[character.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", character.CampaignID, ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	created := copyCharacter(*character)
+	created.ID = uuid.NewString()
+	if created.Role == "" {
+		created.Role = "NPC"
+	}
+	if created.Attributes == nil {
+		created.Attributes = map[string]interface{}{}
+	}
+	created.Visibility = visibility(created.Visibility)
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.characters[created.ID] = created
+
+	created = copyCharacter(created)
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.characters[character.ID]
+	if !ok || existing.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	updated := copyCharacter(*character)
+	existing.Name = updated.Name
+	existing.Role = updated.Role
+	existing.Attributes = updated.Attributes
+	existing.Background = updated.Background
+	existing.Visibility = visibility(updated.Visibility)
+	existing.AttributeVisibility = updated.AttributeVisibility
+	if updated.Embedding != nil {
+		existing.Embedding = updated.Embedding
+	}
+	existing.UpdatedAt = time.Now().UTC()
+	s.characters[existing.ID] = existing
+
+	existing = copyCharacter(existing)
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteCharacter(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteCharacter(id)
+	return nil
+}
+
+// deleteCharacter removes a character and its relationships. Callers hold s.mu.
+func (s *MemoryStore) deleteCharacter(id string) {
+	delete(s.characters, id)
+	for relationshipID, relationship := range s.relationships {
+		if relationship.SourceCharacterID == id || relationship.TargetCharacterID == id {
+			delete(s.relationships, relationshipID)
+		}
+	}
+}
+
+func (s *MemoryStore) ListRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationships := []models.Relationship{}
+	for _, relationship := range s.relationships {
+		if relationship.CampaignID == campaignID && relationship.DeletedAt == nil &&
+			s.liveCharacter(relationship.SourceCharacterID) && s.liveCharacter(relationship.TargetCharacterID) {
+			relationships = append(relationships, relationship)
+		}
+	}
+	sort.Slice(relationships, func(i, j int) bool {
+		return relationships[i].CreatedAt.Before(relationships[j].CreatedAt)
+	})
+
+	return relationships, nil
+}
+
+// liveCharacter reports whether the character with id exists and is not in
+// the trash. The caller holds s.mu.
+func (s *MemoryStore) liveCharacter(id string) bool {
+	character, ok := s.characters[id]
+	return ok && character.DeletedAt == nil
+}
+
+func (s *MemoryStore) GetRelationship(ctx context.Context, id string) (*models.Relationship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationship, ok := s.relationships[id]
+	if !ok || relationship.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	return &relationship, nil
+}
+
+func (s *MemoryStore) CreateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[relationship.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", relationship.CampaignID, ErrNotFound)
+	}
+	for _, characterID := range []string{relationship.SourceCharacterID, relationship.TargetCharacterID} {
+		if _, ok := s.characters[characterID]; !ok {
+			return nil, fmt.Errorf("character %s: %w", characterID, ErrNotFound)
+		}
+	}
+	if s.relationshipPairTaken(relationship.SourceCharacterID, relationship.TargetCharacterID, "") {
+		return nil, fmt.Errorf("relationship already exists: %w", ErrConflict)
+	}
+
+	created := *relationship
+	created.ID = uuid.NewString()
+	created.Visibility = visibility(created.Visibility)
+	created.CreatedAt = time.Now().UTC()
+	s.relationships[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.relationships[relationship.ID]
+	if !ok || existing.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	existing.RelationType = relationship.RelationType
+	existing.Description = relationship.Description
+	existing.Visibility = visibility(relationship.Visibility)
+	s.relationships[existing.ID] = existing
+
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteRelationship(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.relationships, id)
+	return nil
+}
+
+func (s *MemoryStore) ListRelationTypes(ctx context.Context, campaignID string) ([]models.RelationType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationTypes := []models.RelationType{}
+	for _, relationType := range s.relationTypes {
+		if relationType.CampaignID == campaignID {
+			relationTypes = append(relationTypes, relationType)
+		}
+	}
+	sort.Slice(relationTypes, func(i, j int) bool {
+		return relationTypes[i].Name < relationTypes[j].Name
+	})
+
+	return relationTypes, nil
+}
+
+func (s *MemoryStore) GetRelationType(ctx context.Context, id string) (*models.RelationType, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationType, ok := s.relationTypes[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &relationType, nil
+}
+
+func (s *MemoryStore) CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[relationType.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", relationType.CampaignID, ErrNotFound)
+	}
+	if s.relationTypeNameTaken(relationType.CampaignID, relationType.Name, "") {
+		return nil, fmt.Errorf("relation type already exists: %w", ErrConflict)
+	}
+
+	now := time.Now().UTC()
+	created := *relationType
+	created.ID = uuid.NewString()
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.relationTypes[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.relationTypes[relationType.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if s.relationTypeNameTaken(existing.CampaignID, relationType.Name, existing.ID) {
+		return nil, fmt.Errorf("relation type already exists: %w", ErrConflict)
+	}
+
+	existing.Name = relationType.Name
+	existing.InverseName = relationType.InverseName
+	existing.Symmetric = relationType.Symmetric
+	existing.UpdatedAt = time.Now().UTC()
+	s.relationTypes[existing.ID] = existing
+
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteRelationType(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.relationTypes, id)
+	return nil
+}
+
+// relationshipPairTaken mirrors the unique index on the pair of characters of
+// relationships outside the trash. Callers hold s.mu.
+func (s *MemoryStore) relationshipPairTaken(sourceID, targetID, exceptID string) bool {
+	for _, existing := range s.relationships {
+		if existing.ID != exceptID && existing.DeletedAt == nil &&
+			existing.SourceCharacterID == sourceID && existing.TargetCharacterID == targetID {
+			return true
+		}
+	}
+	return false
+}
+
+// relationTypeNameTaken mirrors unique(campaign_id, name). Callers hold s.mu.
+func (s *MemoryStore) relationTypeNameTaken(campaignID, name, exceptID string) bool {
+	for _, existing := range s.relationTypes {
+		if existing.ID != exceptID && existing.CampaignID == campaignID && existing.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *MemoryStore) ListLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	loreEntries := []models.LoreEntry{}
+	for _, loreEntry := range s.loreEntries {
+		if loreEntry.CampaignID == campaignID && loreEntry.DeletedAt == nil {
+			loreEntries = append(loreEntries, loreEntry)
+		}
+	}
+	sort.Slice(loreEntries, func(i, j int) bool {
+		return loreEntries[i].CreatedAt.Before(loreEntries[j].CreatedAt)
+	})
+
+	return loreEntries, nil
+}
+
+func (s *MemoryStore) GetLoreEntry(ctx context.Context, id string) (*models.LoreEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	loreEntry, ok := s.loreEntries[id]
+	if !ok || loreEntry.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	return &loreEntry, nil
+}
+
+func (s *MemoryStore) CreateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[loreEntry.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", loreEntry.CampaignID, ErrNotFound)
+	}
+
+	now := time.Now().UTC()
+	created := *loreEntry
+	created.ID = uuid.NewString()
+	created.Visibility = visibility(created.Visibility)
+	created.CreatedAt = now
+	created.UpdatedAt = now
+	s.loreEntries[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) UpdateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.loreEntries[loreEntry.ID]
+	if !ok || existing.DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+
+	existing.Title = loreEntry.Title
+	existing.Category = loreEntry.Category
+	existing.Content = loreEntry.Content
+	existing.Visibility = visibility(loreEntry.Visibility)
+	if loreEntry.Embedding != nil {
+		existing.Embedding = loreEntry.Embedding
+	}
+	existing.UpdatedAt = time.Now().UTC()
+	s.loreEntries[existing.ID] = existing
+
+	return &existing, nil
+}
+
+func (s *MemoryStore) DeleteLoreEntry(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.loreEntries, id)
+	return nil
+}
+
+func (s *MemoryStore) ListCharactersMissingEmbedding(ctx context.Context, limit, offset int) ([]models.Character, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	characters := []models.Character{}
+	for _, character := range s.characters {
+		if character.Embedding == nil && character.DeletedAt == nil {
+			characters = append(characters, copyCharacter(character))
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool {
+		return characters[i].CreatedAt.Before(characters[j].CreatedAt)
+	})
+
+	return paginate(characters, limit, offset), nil
+}
+
+func (s *MemoryStore) ListLoreEntriesMissingEmbedding(ctx context.Context, limit, offset int) ([]models.LoreEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	loreEntries := []models.LoreEntry{}
+	for _, loreEntry := range s.loreEntries {
+		if loreEntry.Embedding == nil && loreEntry.DeletedAt == nil {
+			loreEntries = append(loreEntries, loreEntry)
+		}
+	}
+	sort.Slice(loreEntries, func(i, j int) bool {
+		return loreEntries[i].CreatedAt.Before(loreEntries[j].CreatedAt)
+	})
+
+	return paginate(loreEntries, limit, offset), nil
+}
+
+func (s *MemoryStore) SetCharacterEmbedding(ctx context.Context, id string, embedding []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	character, ok := s.characters[id]
+	if !ok {
+		return ErrNotFound
+	}
+	character.Embedding = embedding
+	s.characters[id] = character
+
+	return nil
+}
+
+func (s *MemoryStore) SetLoreEntryEmbedding(ctx context.Context, id string, embedding []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loreEntry, ok := s.loreEntries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	loreEntry.Embedding = embedding
+	s.loreEntries[id] = loreEntry
+
+	return nil
+}
+
+func (s *MemoryStore) SimilarCharacters(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredCharacter, error) {
+	characters, err := s.ListCharacters(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	return rankCharacters(characters, query, limit), nil
+}
+
+func (s *MemoryStore) SimilarLoreEntries(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredLoreEntry, error) {
+	loreEntries, err := s.ListLoreEntries(ctx, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	return rankLoreEntries(loreEntries, query, limit), nil
+}
+
+func (s *MemoryStore) ListProvenance(ctx context.Context, campaignID string) ([]models.Provenance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := []models.Provenance{}
+	for _, provenance := range s.provenance {
+		if provenance.CampaignID == campaignID {
+			records = append(records, provenance)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+
+	return records, nil
+}
+
+func (s *MemoryStore) CreateProvenance(ctx context.Context, provenance *models.Provenance) (*models.Provenance, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[provenance.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", provenance.CampaignID, ErrNotFound)
+	}
+
+	created := *provenance
+	created.ID = uuid.NewString()
+	created.CreatedAt = time.Now().UTC()
+	s.provenance[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) DeleteProvenance(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.provenance, id)
+	return nil
+}
+
+func (s *MemoryStore) ListRevisions(ctx context.Context, entityType, entityID string) ([]models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revisions := []models.Revision{}
+	for _, revision := range s.revisions {
+		if revision.EntityType == entityType && revision.EntityID == entityID {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Number < revisions[j].Number
+	})
+
+	return revisions, nil
+}
+
+func (s *MemoryStore) GetRevision(ctx context.Context, id string) (*models.Revision, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	revision, ok := s.revisions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return &revision, nil
+}
+
+func (s *MemoryStore) CreateRevision(ctx context.Context, revision *models.Revision) (*models.Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.campaigns[revision.CampaignID]; !ok {
+		return nil, fmt.Errorf("campaign %s: %w", revision.CampaignID, ErrNotFound)
+	}
+	for _, existing := range s.revisions {
+		if existing.EntityType == revision.EntityType && existing.EntityID == revision.EntityID && existing.Number == revision.Number {
+			return nil, fmt.Errorf("revision %d already exists: %w", revision.Number, ErrConflict)
+		}
+	}
+
+	created := *revision
+	created.ID = uuid.NewString()
+	created.Snapshot = append([]byte(nil), revision.Snapshot...)
+	created.CreatedAt = time.Now().UTC()
+	s.revisions[created.ID] = created
+
+	return &created, nil
+}
+
+func (s *MemoryStore) DeleteRevision(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.revisions, id)
+	return nil
+}
+
+func (s *MemoryStore) SetCampaignDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	campaign, ok := s.campaigns[id]
+	if !ok {
+		return ErrNotFound
+	}
+	campaign.DeletedAt = deletedAt
+	s.campaigns[id] = campaign
+
+	return nil
+}
+
+func (s *MemoryStore) SetCharacterDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	character, ok := s.characters[id]
+	if !ok {
+		return ErrNotFound
+	}
+	character.DeletedAt = deletedAt
+	s.characters[id] = character
+
+	return nil
+}
+
+func (s *MemoryStore) SetRelationshipDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	relationship, ok := s.relationships[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if deletedAt == nil && s.relationshipPairTaken(relationship.SourceCharacterID, relationship.TargetCharacterID, id) {
+		return fmt.Errorf("relationship already exists: %w", ErrConflict)
+	}
+	relationship.DeletedAt = deletedAt
+	s.relationships[id] = relationship
+
+	return nil
+}
+
+func (s *MemoryStore) SetLoreEntryDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	loreEntry, ok := s.loreEntries[id]
+	if !ok {
+		return ErrNotFound
+	}
+	loreEntry.DeletedAt = deletedAt
+	s.loreEntries[id] = loreEntry
+
+	return nil
+}
+
+func (s *MemoryStore) ListTrashedCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	campaigns := []models.Campaign{}
+	for _, campaign := range s.campaigns {
+		if campaign.UserID == userID && campaign.DeletedAt != nil {
+			campaigns = append(campaigns, campaign)
+		}
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].DeletedAt.After(*campaigns[j].DeletedAt)
+	})
+
+	return campaigns, nil
+}
+
+func (s *MemoryStore) ListTrashedCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	characters := []models.Character{}
+	for _, character := range s.characters {
+		if character.CampaignID == campaignID && character.DeletedAt != nil {
+			characters = append(characters, copyCharacter(character))
+		}
+	}
+	sort.Slice(characters, func(i, j int) bool {
+		return characters[i].DeletedAt.After(*characters[j].DeletedAt)
+	})
+
+	return characters, nil
+}
+
+func (s *MemoryStore) ListTrashedRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	relationships := []models.Relationship{}
+	for _, relationship := range s.relationships {
+		if relationship.CampaignID == campaignID && relationship.DeletedAt != nil {
+			relationships = append(relationships, relationship)
+		}
+	}
+	sort.Slice(relationships, func(i, j int) bool {
+		return relationships[i].DeletedAt.After(*relationships[j].DeletedAt)
+	})
+
+	return relationships, nil
+}
+
+func (s *MemoryStore) ListTrashedLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	loreEntries := []models.LoreEntry{}
+	for _, loreEntry := range s.loreEntries {
+		if loreEntry.CampaignID == campaignID && loreEntry.DeletedAt != nil {
+			loreEntries = append(loreEntries, loreEntry)
+		}
+	}
+	sort.Slice(loreEntries, func(i, j int) bool {
+		return loreEntries[i].DeletedAt.After(*loreEntries[j].DeletedAt)
+	})
+
+	return loreEntries, nil
+}
+
+func (s *MemoryStore) PurgeTrash(ctx context.Context, cutoff time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, relationship := range s.relationships {
+		if relationship.DeletedAt != nil && relationship.DeletedAt.Before(cutoff) {
+			delete(s.relationships, id)
+		}
+	}
+	for id, loreEntry := range s.loreEntries {
+		if loreEntry.DeletedAt != nil && loreEntry.DeletedAt.Before(cutoff) {
+			delete(s.loreEntries, id)
+		}
+	}
+	for id, character := range s.characters {
+		if character.DeletedAt != nil && character.DeletedAt.Before(cutoff) {
+			s.deleteCharacter(id)
+		}
+	}
+	for id, campaign := range s.campaigns {
+		if campaign.DeletedAt != nil && campaign.DeletedAt.Before(cutoff) {
+			s.deleteCampaign(id)
+		}
+	}
+
+	return nil
+}
+
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	items = items[offset:]
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// copyCharacter detaches the attributes map so callers cannot mutate stored state.
+func copyCharacter(character models.Character) models.Character {
+	if character.Attributes != nil {
+		attributes := make(map[string]interface{}, len(character.Attributes))
+		for key, value := range character.Attributes {
+			attributes[key] = value
+		}
+		character.Attributes = attributes
+	}
+	if character.AttributeVisibility != nil {
+		attributeVisibility := make(map[string]string, len(character.AttributeVisibility))
+		for key, value := range character.AttributeVisibility {
+			attributeVisibility[key] = value
+		}
+		character.AttributeVisibility = attributeVisibility
+	}
+	return character
+}
@@ -0,0 +1,53 @@
+-- Derived from database/init.sql without the Supabase-specific parts
+-- (auth.users foreign key and row level security).
+create extension if not exists vector;
+
+create table if not exists campaigns (
+  id uuid primary key default gen_random_uuid(),
+  user_id uuid not null,
+  title text not null,
+  description text,
+  created_at timestamptz default now(),
+  updated_at timestamptz default now()
+);
+
+create index if not exists campaigns_user_id_idx on campaigns (user_id);
+
+create table if not exists characters (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  name text not null,
+  role text default 'NPC',
+  attributes jsonb default '{}'::jsonb,
+  background text,
+  embedding vector(1536),
+  created_at timestamptz default now(),
+  updated_at timestamptz default now()
+);
+
+create index if not exists characters_embedding_idx on characters using ivfflat (embedding vector_cosine_ops);
+
+create table if not exists relationships (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  source_character_id uuid references characters(id) on delete cascade not null,
+  target_character_id uuid references characters(id) on delete cascade not null,
+  relation_type text not null,
+  description text,
+  created_at timestamptz default now(),
+
+  unique(source_character_id, target_character_id)
+);
+
+create table if not exists lore_entries (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  title text not null,
+  category text,
+  content text not null,
+  embedding vector(1536),
+  created_at timestamptz default now(),
+  updated_at timestamptz default now()
+);
+
+create index if not exists lore_entries_embedding_idx on lore_entries using ivfflat (embedding vector_cosine_ops);
@@ -0,0 +1,15 @@
+create table if not exists ai_provenance (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  entity_type text not null, -- "character", "relationship", "lore_entry"
+  entity_id uuid not null,
+  field text not null, -- "background", "attributes.<key>", "record"
+  content text,
+  source text not null, -- "deep_dive" 等
+  proposal_id text,
+  created_by uuid,
+  created_at timestamptz default now()
+);
+
+create index if not exists ai_provenance_campaign_id_idx on ai_provenance (campaign_id);
+create index if not exists ai_provenance_entity_id_idx on ai_provenance (entity_id);
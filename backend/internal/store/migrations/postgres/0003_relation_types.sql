@@ -0,0 +1,11 @@
+create table if not exists relation_types (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  name text not null,
+  inverse_name text, -- "mentor" に対する "apprentice" 等
+  symmetric boolean not null default false, -- "sibling" のように向きのない関係
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  unique(campaign_id, name)
+);
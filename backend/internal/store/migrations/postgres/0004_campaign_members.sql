@@ -0,0 +1,26 @@
+create table if not exists campaign_members (
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  user_id uuid not null,
+  email text,
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  primary key (campaign_id, user_id)
+);
+
+create index if not exists campaign_members_user_id_idx on campaign_members (user_id);
+
+create table if not exists campaign_invitations (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  email text,
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  code text not null unique,
+  created_by uuid not null,
+  expires_at timestamptz not null,
+  created_at timestamptz default now()
+);
+
+create index if not exists campaign_invitations_campaign_id_idx on campaign_invitations (campaign_id);
+create index if not exists campaign_invitations_email_idx on campaign_invitations (lower(email));
@@ -0,0 +1,9 @@
+-- 'public' はプレイヤーにも公開、'gm' はオーナーと編集者のみ
+alter table characters add column if not exists visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
+-- 属性キーごとの公開範囲 (例: {"true_identity": "gm"})。記載のないキーは公開
+alter table characters add column if not exists attribute_visibility jsonb not null default '{}'::jsonb;
+alter table relationships add column if not exists visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
+alter table lore_entries add column if not exists visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
@@ -0,0 +1,11 @@
+create table if not exists campaign_share_links (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  label text,
+  categories jsonb not null default '[]'::jsonb,
+  created_by uuid not null,
+  expires_at timestamptz,
+  created_at timestamptz default now()
+);
+
+create index if not exists campaign_share_links_campaign_id_idx on campaign_share_links (campaign_id);
@@ -0,0 +1,15 @@
+create table if not exists revisions (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  entity_type text not null check (entity_type in ('character', 'lore_entry')),
+  entity_id uuid not null,
+  number integer not null,
+  summary text not null default '',
+  snapshot jsonb not null,
+  created_by uuid,
+  created_at timestamptz default now(),
+
+  unique (entity_type, entity_id, number)
+);
+
+create index if not exists revisions_campaign_id_idx on revisions (campaign_id);
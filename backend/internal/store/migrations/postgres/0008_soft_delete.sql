@@ -0,0 +1,11 @@
+-- ゴミ箱: deleted_at が入っている行はゴミ箱にあり、一覧・取得・検索には出ない。
+-- 保持期間を過ぎると完全に削除される
+alter table campaigns add column if not exists deleted_at timestamptz;
+alter table characters add column if not exists deleted_at timestamptz;
+alter table relationships add column if not exists deleted_at timestamptz;
+alter table lore_entries add column if not exists deleted_at timestamptz;
+
+create index if not exists campaigns_deleted_at_idx on campaigns (deleted_at) where deleted_at is not null;
+create index if not exists characters_deleted_at_idx on characters (deleted_at) where deleted_at is not null;
+create index if not exists relationships_deleted_at_idx on relationships (deleted_at) where deleted_at is not null;
+create index if not exists lore_entries_deleted_at_idx on lore_entries (deleted_at) where deleted_at is not null;
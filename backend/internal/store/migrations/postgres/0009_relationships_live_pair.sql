@@ -0,0 +1,6 @@
+-- 同じペアの重複を防ぐのはゴミ箱にない関係性だけにする。ゴミ箱の関係性は、
+-- 同じペアの関係性を作り直しても保持期間まで残り、復元できる
+alter table relationships drop constraint if exists relationships_source_character_id_target_character_id_key;
+
+create unique index if not exists relationships_live_pair_idx
+  on relationships (source_character_id, target_character_id) where deleted_at is null;
@@ -0,0 +1,12 @@
+-- 共有リンクのトークンは署名ではなく行に保存したランダムな値にする。
+-- 鍵がなくてもサーバーの再起動や複数台構成でリンクが使い続けられる。
+-- 既存のリンクには新しいトークンを発行するため、URLは変わる
+alter table campaign_share_links add column if not exists token text;
+
+update campaign_share_links
+set token = replace(gen_random_uuid()::text, '-', '') || replace(gen_random_uuid()::text, '-', '')
+where token is null;
+
+alter table campaign_share_links alter column token set not null;
+
+create unique index if not exists campaign_share_links_token_idx on campaign_share_links (token);
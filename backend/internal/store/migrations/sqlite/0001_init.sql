@@ -0,0 +1,54 @@
+-- SQLite version of database/init.sql for the offline single-user mode.
+-- UUIDs and timestamps are generated by the application, embeddings are
+-- stored as little-endian float32 blobs and searched in Go.
+create table if not exists campaigns (
+  id text primary key,
+  user_id text not null,
+  title text not null,
+  description text,
+  created_at timestamp not null default current_timestamp,
+  updated_at timestamp not null default current_timestamp
+);
+
+create index if not exists campaigns_user_id_idx on campaigns (user_id);
+
+create table if not exists characters (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  name text not null,
+  role text default 'NPC',
+  attributes text default '{}',
+  background text,
+  embedding blob,
+  created_at timestamp not null default current_timestamp,
+  updated_at timestamp not null default current_timestamp
+);
+
+create index if not exists characters_campaign_id_idx on characters (campaign_id);
+
+create table if not exists relationships (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  source_character_id text not null references characters(id) on delete cascade,
+  target_character_id text not null references characters(id) on delete cascade,
+  relation_type text not null,
+  description text,
+  created_at timestamp not null default current_timestamp,
+
+  unique(source_character_id, target_character_id)
+);
+
+create index if not exists relationships_campaign_id_idx on relationships (campaign_id);
+
+create table if not exists lore_entries (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  title text not null,
+  category text,
+  content text not null,
+  embedding blob,
+  created_at timestamp not null default current_timestamp,
+  updated_at timestamp not null default current_timestamp
+);
+
+create index if not exists lore_entries_campaign_id_idx on lore_entries (campaign_id);
@@ -0,0 +1,15 @@
+create table if not exists ai_provenance (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  entity_type text not null,
+  entity_id text not null,
+  field text not null,
+  content text,
+  source text not null,
+  proposal_id text,
+  created_by text,
+  created_at timestamp not null default current_timestamp
+);
+
+create index if not exists ai_provenance_campaign_id_idx on ai_provenance (campaign_id);
+create index if not exists ai_provenance_entity_id_idx on ai_provenance (entity_id);
@@ -0,0 +1,11 @@
+create table if not exists relation_types (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  name text not null,
+  inverse_name text,
+  symmetric boolean not null default false,
+  created_at timestamp not null default current_timestamp,
+  updated_at timestamp not null default current_timestamp,
+
+  unique(campaign_id, name)
+);
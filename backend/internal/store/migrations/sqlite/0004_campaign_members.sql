@@ -0,0 +1,26 @@
+create table if not exists campaign_members (
+  campaign_id text not null references campaigns(id) on delete cascade,
+  user_id text not null,
+  email text,
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  created_at timestamp not null default current_timestamp,
+  updated_at timestamp not null default current_timestamp,
+
+  primary key (campaign_id, user_id)
+);
+
+create index if not exists campaign_members_user_id_idx on campaign_members (user_id);
+
+create table if not exists campaign_invitations (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  email text,
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  code text not null unique,
+  created_by text not null,
+  expires_at timestamp not null,
+  created_at timestamp not null default current_timestamp
+);
+
+create index if not exists campaign_invitations_campaign_id_idx on campaign_invitations (campaign_id);
+create index if not exists campaign_invitations_email_idx on campaign_invitations (lower(email));
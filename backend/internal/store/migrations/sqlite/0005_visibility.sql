@@ -0,0 +1,7 @@
+alter table characters add column visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
+alter table characters add column attribute_visibility text not null default '{}';
+alter table relationships add column visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
+alter table lore_entries add column visibility text not null default 'public'
+  check (visibility in ('public', 'gm'));
@@ -0,0 +1,11 @@
+create table if not exists campaign_share_links (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  label text,
+  categories text not null default '[]',
+  created_by text not null,
+  expires_at timestamp,
+  created_at timestamp not null default current_timestamp
+);
+
+create index if not exists campaign_share_links_campaign_id_idx on campaign_share_links (campaign_id);
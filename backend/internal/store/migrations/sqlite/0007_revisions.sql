@@ -0,0 +1,15 @@
+create table if not exists revisions (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  entity_type text not null check (entity_type in ('character', 'lore_entry')),
+  entity_id text not null,
+  number integer not null,
+  summary text not null default '',
+  snapshot text not null,
+  created_by text,
+  created_at timestamp not null default current_timestamp,
+
+  unique (entity_type, entity_id, number)
+);
+
+create index if not exists revisions_campaign_id_idx on revisions (campaign_id);
@@ -0,0 +1,9 @@
+alter table campaigns add column deleted_at timestamp;
+alter table characters add column deleted_at timestamp;
+alter table relationships add column deleted_at timestamp;
+alter table lore_entries add column deleted_at timestamp;
+
+create index if not exists campaigns_deleted_at_idx on campaigns (deleted_at) where deleted_at is not null;
+create index if not exists characters_deleted_at_idx on characters (deleted_at) where deleted_at is not null;
+create index if not exists relationships_deleted_at_idx on relationships (deleted_at) where deleted_at is not null;
+create index if not exists lore_entries_deleted_at_idx on lore_entries (deleted_at) where deleted_at is not null;
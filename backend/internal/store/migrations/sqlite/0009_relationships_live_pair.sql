@@ -0,0 +1,28 @@
+-- SQLite cannot drop a table constraint, so the table is rebuilt with the
+-- unique pair as a partial index over the relationships outside the trash.
+create table relationships_rebuilt (
+  id text primary key,
+  campaign_id text not null references campaigns(id) on delete cascade,
+  source_character_id text not null references characters(id) on delete cascade,
+  target_character_id text not null references characters(id) on delete cascade,
+  relation_type text not null,
+  description text,
+  created_at timestamp not null default current_timestamp,
+  visibility text not null default 'public'
+    check (visibility in ('public', 'gm')),
+  deleted_at timestamp
+);
+
+insert into relationships_rebuilt (id, campaign_id, source_character_id, target_character_id, relation_type,
+  description, created_at, visibility, deleted_at)
+select id, campaign_id, source_character_id, target_character_id, relation_type,
+  description, created_at, visibility, deleted_at
+from relationships;
+
+drop table relationships;
+alter table relationships_rebuilt rename to relationships;
+
+create index if not exists relationships_campaign_id_idx on relationships (campaign_id);
+create index if not exists relationships_deleted_at_idx on relationships (deleted_at) where deleted_at is not null;
+create unique index if not exists relationships_live_pair_idx
+  on relationships (source_character_id, target_character_id) where deleted_at is null;
@@ -0,0 +1,7 @@
+-- Share links keep a random token instead of being signed. Existing links get
+-- a new token, so their URLs change.
+alter table campaign_share_links add column token text;
+
+update campaign_share_links set token = lower(hex(randomblob(32))) where token is null;
+
+create unique index if not exists campaign_share_links_token_idx on campaign_share_links (token);
@@ -0,0 +1,52 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/database"
+)
+
+// Backend names the backend selected by STORAGE_BACKEND. When it is unset,
+// DATABASE_URL selects the direct Postgres backend and Supabase is used otherwise.
+func Backend() string {
+	if backend := os.Getenv("STORAGE_BACKEND"); backend != "" {
+		return backend
+	}
+	if os.Getenv("DATABASE_URL") != "" {
+		return "postgres"
+	}
+	return "supabase"
+}
+
+// Open returns the backend named by Backend.
+func Open(ctx context.Context) (Store, error) {
+	switch backend := Backend(); backend {
+	case "supabase":
+		if database.Client == nil {
+			if err := database.InitSupabase(); err != nil {
+				return nil, err
+			}
+		}
+		return NewSupabaseStore(database.Client), nil
+	case "postgres":
+		databaseURL := os.Getenv("DATABASE_URL")
+		if databaseURL == "" {
+			return nil, fmt.Errorf("DATABASE_URL is required for the postgres backend")
+		}
+		return NewPostgresStore(ctx, databaseURL)
+	case "sqlite":
+		path := os.Getenv("SQLITE_PATH")
+		if path == "" {
+			path = "lore-keeper.db"
+		}
+		return NewSQLiteStore(ctx, path)
+	case "memory":
+		log.Println("Using in-memory storage; data will be lost on restart")
+		return NewMemoryStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_BACKEND: %s", backend)
+	}
+}
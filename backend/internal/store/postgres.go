@@ -0,0 +1,47 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+var postgresDialect = dialect{
+	name:     "postgres",
+	numbered: true,
+	isConflict: func(err error) bool {
+		var pgErr *pgconn.PgError
+		return errors.As(err, &pgErr) && pgErr.Code == "23505"
+	},
+	encodeVector: func(vector []float32) interface{} {
+		return formatVector(vector)
+	},
+	decodeVector: func(value []byte) ([]float32, error) {
+		return parseVector(string(value))
+	},
+	vectorSearch: true,
+}
+
+// NewPostgresStore connects to a self-hosted PostgreSQL (with pgvector) through
+// pgx and brings the schema up to date before returning.
+func NewPostgresStore(ctx context.Context, databaseURL string) (*SQLStore, error) {
+	db, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrate(ctx, db, postgresDialect); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return newSQLStore(db, postgresDialect), nil
+}
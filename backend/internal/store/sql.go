@@ -0,0 +1,1142 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+// dialect captures the differences between the SQL databases SQLStore can run on.
+type dialect struct {
+	name string
+	// numbered placeholders ($1, $2, ...) instead of ?
+	numbered bool
+	// isConflict reports whether err is a unique constraint violation.
+	isConflict func(err error) bool
+	// encodeVector and decodeVector convert embeddings to and from the column type.
+	encodeVector func(vector []float32) interface{}
+	decodeVector func(value []byte) ([]float32, error)
+	// vectorSearch is set when the database can rank by cosine distance itself
+	// (pgvector); otherwise similarity search is done in Go.
+	vectorSearch bool
+}
+
+func (d dialect) vector(vector []float32) interface{} {
+	if vector == nil {
+		return nil
+	}
+	return d.encodeVector(vector)
+}
+
+func (d dialect) rebind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sqlConn is the part of *sql.DB and *sql.Tx that SQLStore queries through.
+type sqlConn interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// SQLStore implements Store directly against a SQL database instead of PostgREST.
+type SQLStore struct {
+	db *sql.DB
+	// conn is db, or the transaction inside WithTx.
+	conn    sqlConn
+	dialect dialect
+}
+
+func newSQLStore(db *sql.DB, d dialect) *SQLStore {
+	return &SQLStore{db: db, conn: db, dialect: d}
+}
+
+func (s *SQLStore) Close() error {
+	return s.db.Close()
+}
+
+// WithTx runs fn in a database transaction. Nested calls join the outer one.
+func (s *SQLStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if _, ok := s.conn.(*sql.Tx); ok {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&SQLStore{db: s.db, conn: tx, dialect: s.dialect}); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLStore) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	result, err := s.conn.ExecContext(ctx, s.dialect.rebind(query), args...)
+	return result, s.translate(err)
+}
+
+func (s *SQLStore) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	rows, err := s.conn.QueryContext(ctx, s.dialect.rebind(query), args...)
+	return rows, s.translate(err)
+}
+
+func (s *SQLStore) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.conn.QueryRowContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *SQLStore) translate(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case s.dialect.isConflict != nil && s.dialect.isConflict(err):
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	default:
+		return err
+	}
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+const campaignColumns = "id, user_id, title, coalesce(description, ''), created_at, updated_at"
+
+func campaignDest(campaign *models.Campaign) []interface{} {
+	return []interface{}{&campaign.ID, &campaign.UserID, &campaign.Title, &campaign.Description,
+		&campaign.CreatedAt, &campaign.UpdatedAt}
+}
+
+func scanCampaign(row rowScanner) (*models.Campaign, error) {
+	var campaign models.Campaign
+	if err := row.Scan(campaignDest(&campaign)...); err != nil {
+		return nil, err
+	}
+	return &campaign, nil
+}
+
+func (s *SQLStore) ListCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	rows, err := s.query(ctx,
+		`select `+campaignColumns+` from campaigns
+		where deleted_at is null
+			and (user_id = ? or id in (select campaign_id from campaign_members where user_id = ?))
+		order by created_at`,
+		userID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []models.Campaign{}
+	for rows.Next() {
+		campaign, err := scanCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, *campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+func (s *SQLStore) GetCampaign(ctx context.Context, id string) (*models.Campaign, error) {
+	campaign, err := scanCampaign(s.queryRow(ctx, "select "+campaignColumns+" from campaigns where id = ? and deleted_at is null", id))
+	return campaign, s.translate(err)
+}
+
+func (s *SQLStore) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	now := time.Now().UTC()
+	created, err := scanCampaign(s.queryRow(ctx,
+		`insert into campaigns (id, user_id, title, description, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?)
+		returning `+campaignColumns,
+		uuid.NewString(), campaign.UserID, campaign.Title, campaign.Description, now, now))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	updated, err := scanCampaign(s.queryRow(ctx,
+		`update campaigns set title = ?, description = ?, updated_at = ?
+		where id = ? and deleted_at is null
+		returning `+campaignColumns,
+		campaign.Title, campaign.Description, time.Now().UTC(), campaign.ID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteCampaign(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from campaigns where id = ?", id)
+	return err
+}
+
+const memberColumns = "campaign_id, user_id, coalesce(email, ''), role, created_at, updated_at"
+
+func scanMember(row rowScanner) (*models.CampaignMember, error) {
+	var member models.CampaignMember
+	err := row.Scan(&member.CampaignID, &member.UserID, &member.Email, &member.Role,
+		&member.CreatedAt, &member.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (s *SQLStore) ListCampaignMembers(ctx context.Context, campaignID string) ([]models.CampaignMember, error) {
+	rows, err := s.query(ctx, "select "+memberColumns+" from campaign_members where campaign_id = ? order by created_at", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []models.CampaignMember{}
+	for rows.Next() {
+		member, err := scanMember(rows)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, *member)
+	}
+
+	return members, rows.Err()
+}
+
+func (s *SQLStore) GetCampaignMember(ctx context.Context, campaignID, userID string) (*models.CampaignMember, error) {
+	member, err := scanMember(s.queryRow(ctx,
+		"select "+memberColumns+" from campaign_members where campaign_id = ? and user_id = ?",
+		campaignID, userID))
+	return member, s.translate(err)
+}
+
+func (s *SQLStore) CreateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	now := time.Now().UTC()
+	created, err := scanMember(s.queryRow(ctx,
+		`insert into campaign_members (campaign_id, user_id, email, role, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?)
+		returning `+memberColumns,
+		member.CampaignID, member.UserID, nullIfEmpty(member.Email), member.Role, now, now))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	updated, err := scanMember(s.queryRow(ctx,
+		`update campaign_members set role = ?, updated_at = ?
+		where campaign_id = ? and user_id = ?
+		returning `+memberColumns,
+		member.Role, time.Now().UTC(), member.CampaignID, member.UserID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteCampaignMember(ctx context.Context, campaignID, userID string) error {
+	_, err := s.exec(ctx, "delete from campaign_members where campaign_id = ? and user_id = ?", campaignID, userID)
+	return err
+}
+
+const invitationColumns = "id, campaign_id, coalesce(email, ''), role, code, created_by, expires_at, created_at"
+
+func scanInvitation(row rowScanner) (*models.Invitation, error) {
+	var invitation models.Invitation
+	err := row.Scan(&invitation.ID, &invitation.CampaignID, &invitation.Email, &invitation.Role,
+		&invitation.Code, &invitation.CreatedBy, &invitation.ExpiresAt, &invitation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (s *SQLStore) listInvitations(ctx context.Context, where string, arg string) ([]models.Invitation, error) {
+	rows, err := s.query(ctx, "select "+invitationColumns+" from campaign_invitations where "+where+" order by created_at", arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []models.Invitation{}
+	for rows.Next() {
+		invitation, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *invitation)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (s *SQLStore) ListInvitations(ctx context.Context, campaignID string) ([]models.Invitation, error) {
+	return s.listInvitations(ctx, "campaign_id = ?", campaignID)
+}
+
+func (s *SQLStore) ListInvitationsForEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	return s.listInvitations(ctx, "lower(email) = lower(?)", email)
+}
+
+func (s *SQLStore) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	invitation, err := scanInvitation(s.queryRow(ctx, "select "+invitationColumns+" from campaign_invitations where id = ?", id))
+	return invitation, s.translate(err)
+}
+
+func (s *SQLStore) GetInvitationByCode(ctx context.Context, code string) (*models.Invitation, error) {
+	invitation, err := scanInvitation(s.queryRow(ctx, "select "+invitationColumns+" from campaign_invitations where code = ?", code))
+	return invitation, s.translate(err)
+}
+
+func (s *SQLStore) CreateInvitation(ctx context.Context, invitation *models.Invitation) (*models.Invitation, error) {
+	created, err := scanInvitation(s.queryRow(ctx,
+		`insert into campaign_invitations (id, campaign_id, email, role, code, created_by, expires_at, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+invitationColumns,
+		uuid.NewString(), invitation.CampaignID, nullIfEmpty(invitation.Email), invitation.Role,
+		invitation.Code, invitation.CreatedBy, invitation.ExpiresAt.UTC(), time.Now().UTC()))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) DeleteInvitation(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from campaign_invitations where id = ?", id)
+	return err
+}
+
+const shareLinkColumns = "id, campaign_id, coalesce(label, ''), categories, created_by, token, expires_at, created_at"
+
+func scanShareLink(row rowScanner) (*models.ShareLink, error) {
+	var link models.ShareLink
+	var categories []byte
+	var expiresAt sql.NullTime
+	err := row.Scan(&link.ID, &link.CampaignID, &link.Label, &categories, &link.CreatedBy,
+		&link.Token, &expiresAt, &link.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(categories, &link.Categories); err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		link.ExpiresAt = &expiresAt.Time
+	}
+	return &link, nil
+}
+
+func (s *SQLStore) ListShareLinks(ctx context.Context, campaignID string) ([]models.ShareLink, error) {
+	rows, err := s.query(ctx, "select "+shareLinkColumns+" from campaign_share_links where campaign_id = ? order by created_at", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	links := []models.ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, *link)
+	}
+
+	return links, rows.Err()
+}
+
+func (s *SQLStore) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	link, err := scanShareLink(s.queryRow(ctx, "select "+shareLinkColumns+" from campaign_share_links where id = ?", id))
+	return link, s.translate(err)
+}
+
+func (s *SQLStore) GetShareLinkByToken(ctx context.Context, token string) (*models.ShareLink, error) {
+	link, err := scanShareLink(s.queryRow(ctx, "select "+shareLinkColumns+" from campaign_share_links where token = ?", token))
+	return link, s.translate(err)
+}
+
+func (s *SQLStore) CreateShareLink(ctx context.Context, link *models.ShareLink) (*models.ShareLink, error) {
+	categories, err := marshalCategories(link.Categories)
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt interface{}
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.UTC()
+	}
+
+	created, err := scanShareLink(s.queryRow(ctx,
+		`insert into campaign_share_links (id, campaign_id, label, categories, created_by, token, expires_at, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+shareLinkColumns,
+		uuid.NewString(), link.CampaignID, nullIfEmpty(link.Label), categories, link.CreatedBy,
+		link.Token, expiresAt, time.Now().UTC()))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) DeleteShareLink(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from campaign_share_links where id = ?", id)
+	return err
+}
+
+func marshalCategories(categories []string) (string, error) {
+	if categories == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(categories)
+	return string(data), err
+}
+
+const characterColumns = "id, campaign_id, name, coalesce(role, ''), attributes, coalesce(background, ''), " +
+	"visibility, attribute_visibility, created_at, updated_at"
+
+// characterJSON holds the JSON columns of a character while it is scanned.
+type characterJSON struct {
+	attributes, attributeVisibility []byte
+}
+
+// characterDest returns the scan destinations for characterColumns.
+func characterDest(character *models.Character, j *characterJSON) []interface{} {
+	return []interface{}{&character.ID, &character.CampaignID, &character.Name, &character.Role,
+		&j.attributes, &character.Background, &character.Visibility, &j.attributeVisibility,
+		&character.CreatedAt, &character.UpdatedAt}
+}
+
+func (j *characterJSON) decode(character *models.Character) error {
+	if len(j.attributes) > 0 {
+		if err := json.Unmarshal(j.attributes, &character.Attributes); err != nil {
+			return err
+		}
+	}
+	if len(j.attributeVisibility) > 0 {
+		if err := json.Unmarshal(j.attributeVisibility, &character.AttributeVisibility); err != nil {
+			return err
+		}
+		if len(character.AttributeVisibility) == 0 {
+			character.AttributeVisibility = nil
+		}
+	}
+	return nil
+}
+
+func scanCharacter(row rowScanner) (*models.Character, error) {
+	var character models.Character
+	var j characterJSON
+	if err := row.Scan(characterDest(&character, &j)...); err != nil {
+		return nil, err
+	}
+	if err := j.decode(&character); err != nil {
+		return nil, err
+	}
+	return &character, nil
+}
+
+func marshalAttributes(attributes map[string]interface{}) (string, error) {
+	if attributes == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(attributes)
+	return string(data), err
+}
+
+func marshalAttributeVisibility(visibility map[string]string) (string, error) {
+	if visibility == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(visibility)
+	return string(data), err
+}
+
+func (s *SQLStore) ListCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	rows, err := s.query(ctx, "select "+characterColumns+" from characters where campaign_id = ? and deleted_at is null order by created_at", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	characters := []models.Character{}
+	for rows.Next() {
+		character, err := scanCharacter(rows)
+		if err != nil {
+			return nil, err
+		}
+		characters = append(characters, *character)
+	}
+
+	return characters, rows.Err()
+}
+
+func (s *SQLStore) GetCharacter(ctx context.Context, id string) (*models.Character, error) {
+	character, err := scanCharacter(s.queryRow(ctx, "select "+characterColumns+" from characters where id = ? and deleted_at is null", id))
+	return character, s.translate(err)
+}
+
+func (s *SQLStore) CreateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	attributes, err := marshalAttributes(character.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	attributeVisibility, err := marshalAttributeVisibility(character.AttributeVisibility)
+	if err != nil {
+		return nil, err
+	}
+
+	role := character.Role
+	if role == "" {
+		role = "NPC"
+	}
+
+	now := time.Now().UTC()
+	created, err := scanCharacter(s.queryRow(ctx,
+		`insert into characters (id, campaign_id, name, role, attributes, background, visibility, attribute_visibility,
+			embedding, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+characterColumns,
+		uuid.NewString(), character.CampaignID, character.Name, role, attributes, character.Background,
+		visibility(character.Visibility), attributeVisibility, s.dialect.vector(character.Embedding), now, now))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	attributes, err := marshalAttributes(character.Attributes)
+	if err != nil {
+		return nil, err
+	}
+	attributeVisibility, err := marshalAttributeVisibility(character.AttributeVisibility)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := scanCharacter(s.queryRow(ctx,
+		`update characters set name = ?, role = ?, attributes = ?, background = ?, visibility = ?,
+			attribute_visibility = ?, embedding = coalesce(?, embedding), updated_at = ?
+		where id = ? and deleted_at is null
+		returning `+characterColumns,
+		character.Name, character.Role, attributes, character.Background, visibility(character.Visibility),
+		attributeVisibility, s.dialect.vector(character.Embedding), time.Now().UTC(), character.ID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteCharacter(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from characters where id = ?", id)
+	return err
+}
+
+const relationshipColumns = "id, campaign_id, source_character_id, target_character_id, relation_type, coalesce(description, ''), " +
+	"visibility, created_at"
+
+func relationshipDest(relationship *models.Relationship) []interface{} {
+	return []interface{}{&relationship.ID, &relationship.CampaignID, &relationship.SourceCharacterID,
+		&relationship.TargetCharacterID, &relationship.RelationType, &relationship.Description,
+		&relationship.Visibility, &relationship.CreatedAt}
+}
+
+func scanRelationship(row rowScanner) (*models.Relationship, error) {
+	var relationship models.Relationship
+	if err := row.Scan(relationshipDest(&relationship)...); err != nil {
+		return nil, err
+	}
+	return &relationship, nil
+}
+
+func (s *SQLStore) ListRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	rows, err := s.query(ctx, `select `+relationshipColumns+` from relationships
+		where campaign_id = ? and deleted_at is null
+			and source_character_id in (select id from characters where deleted_at is null)
+			and target_character_id in (select id from characters where deleted_at is null)
+		order by created_at`, campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationships := []models.Relationship{}
+	for rows.Next() {
+		relationship, err := scanRelationship(rows)
+		if err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, *relationship)
+	}
+
+	return relationships, rows.Err()
+}
+
+func (s *SQLStore) GetRelationship(ctx context.Context, id string) (*models.Relationship, error) {
+	relationship, err := scanRelationship(s.queryRow(ctx, "select "+relationshipColumns+" from relationships where id = ? and deleted_at is null", id))
+	return relationship, s.translate(err)
+}
+
+func (s *SQLStore) CreateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	created, err := scanRelationship(s.queryRow(ctx,
+		`insert into relationships (id, campaign_id, source_character_id, target_character_id, relation_type, description,
+			visibility, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+relationshipColumns,
+		uuid.NewString(), relationship.CampaignID, relationship.SourceCharacterID, relationship.TargetCharacterID,
+		relationship.RelationType, relationship.Description, visibility(relationship.Visibility), time.Now().UTC()))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	updated, err := scanRelationship(s.queryRow(ctx,
+		`update relationships set relation_type = ?, description = ?, visibility = ?
+		where id = ? and deleted_at is null
+		returning `+relationshipColumns,
+		relationship.RelationType, relationship.Description, visibility(relationship.Visibility), relationship.ID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteRelationship(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from relationships where id = ?", id)
+	return err
+}
+
+const relationTypeColumns = "id, campaign_id, name, coalesce(inverse_name, ''), symmetric, created_at, updated_at"
+
+func scanRelationType(row rowScanner) (*models.RelationType, error) {
+	var relationType models.RelationType
+	err := row.Scan(&relationType.ID, &relationType.CampaignID, &relationType.Name, &relationType.InverseName,
+		&relationType.Symmetric, &relationType.CreatedAt, &relationType.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &relationType, nil
+}
+
+func (s *SQLStore) ListRelationTypes(ctx context.Context, campaignID string) ([]models.RelationType, error) {
+	rows, err := s.query(ctx, "select "+relationTypeColumns+" from relation_types where campaign_id = ? order by name", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationTypes := []models.RelationType{}
+	for rows.Next() {
+		relationType, err := scanRelationType(rows)
+		if err != nil {
+			return nil, err
+		}
+		relationTypes = append(relationTypes, *relationType)
+	}
+
+	return relationTypes, rows.Err()
+}
+
+func (s *SQLStore) GetRelationType(ctx context.Context, id string) (*models.RelationType, error) {
+	relationType, err := scanRelationType(s.queryRow(ctx, "select "+relationTypeColumns+" from relation_types where id = ?", id))
+	return relationType, s.translate(err)
+}
+
+func (s *SQLStore) CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	now := time.Now().UTC()
+	created, err := scanRelationType(s.queryRow(ctx,
+		`insert into relation_types (id, campaign_id, name, inverse_name, symmetric, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?)
+		returning `+relationTypeColumns,
+		uuid.NewString(), relationType.CampaignID, relationType.Name, nullIfEmpty(relationType.InverseName),
+		relationType.Symmetric, now, now))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	updated, err := scanRelationType(s.queryRow(ctx,
+		`update relation_types set name = ?, inverse_name = ?, symmetric = ?, updated_at = ?
+		where id = ?
+		returning `+relationTypeColumns,
+		relationType.Name, nullIfEmpty(relationType.InverseName), relationType.Symmetric,
+		time.Now().UTC(), relationType.ID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteRelationType(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from relation_types where id = ?", id)
+	return err
+}
+
+const loreEntryColumns = "id, campaign_id, title, coalesce(category, ''), content, visibility, created_at, updated_at"
+
+func loreEntryDest(loreEntry *models.LoreEntry) []interface{} {
+	return []interface{}{&loreEntry.ID, &loreEntry.CampaignID, &loreEntry.Title, &loreEntry.Category,
+		&loreEntry.Content, &loreEntry.Visibility, &loreEntry.CreatedAt, &loreEntry.UpdatedAt}
+}
+
+func scanLoreEntry(row rowScanner) (*models.LoreEntry, error) {
+	var loreEntry models.LoreEntry
+	if err := row.Scan(loreEntryDest(&loreEntry)...); err != nil {
+		return nil, err
+	}
+	return &loreEntry, nil
+}
+
+func (s *SQLStore) ListLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	rows, err := s.query(ctx, "select "+loreEntryColumns+" from lore_entries where campaign_id = ? and deleted_at is null order by created_at", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loreEntries := []models.LoreEntry{}
+	for rows.Next() {
+		loreEntry, err := scanLoreEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		loreEntries = append(loreEntries, *loreEntry)
+	}
+
+	return loreEntries, rows.Err()
+}
+
+func (s *SQLStore) GetLoreEntry(ctx context.Context, id string) (*models.LoreEntry, error) {
+	loreEntry, err := scanLoreEntry(s.queryRow(ctx, "select "+loreEntryColumns+" from lore_entries where id = ? and deleted_at is null", id))
+	return loreEntry, s.translate(err)
+}
+
+func (s *SQLStore) CreateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	now := time.Now().UTC()
+	created, err := scanLoreEntry(s.queryRow(ctx,
+		`insert into lore_entries (id, campaign_id, title, category, content, visibility, embedding, created_at, updated_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+loreEntryColumns,
+		uuid.NewString(), loreEntry.CampaignID, loreEntry.Title, loreEntry.Category, loreEntry.Content,
+		visibility(loreEntry.Visibility), s.dialect.vector(loreEntry.Embedding), now, now))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) UpdateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	updated, err := scanLoreEntry(s.queryRow(ctx,
+		`update lore_entries set title = ?, category = ?, content = ?, visibility = ?,
+			embedding = coalesce(?, embedding), updated_at = ?
+		where id = ? and deleted_at is null
+		returning `+loreEntryColumns,
+		loreEntry.Title, loreEntry.Category, loreEntry.Content, visibility(loreEntry.Visibility),
+		s.dialect.vector(loreEntry.Embedding), time.Now().UTC(), loreEntry.ID))
+	return updated, s.translate(err)
+}
+
+func (s *SQLStore) DeleteLoreEntry(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from lore_entries where id = ?", id)
+	return err
+}
+
+// SimilarCharacters returns the campaign's characters closest to query by
+// cosine similarity, best match first.
+func (s *SQLStore) SimilarCharacters(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredCharacter, error) {
+	if s.dialect.vectorSearch {
+		rows, err := s.query(ctx,
+			"select "+characterColumns+", 1 - (embedding <=> ?) from characters"+
+				" where campaign_id = ? and deleted_at is null and embedding is not null order by embedding <=> ? limit ?",
+			s.dialect.vector(query), campaignID, s.dialect.vector(query), limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		scored := []ScoredCharacter{}
+		for rows.Next() {
+			var result ScoredCharacter
+			var j characterJSON
+			if err := rows.Scan(append(characterDest(&result.Character, &j), &result.Score)...); err != nil {
+				return nil, err
+			}
+			if err := j.decode(&result.Character); err != nil {
+				return nil, err
+			}
+			scored = append(scored, result)
+		}
+		return scored, rows.Err()
+	}
+
+	rows, err := s.query(ctx,
+		"select "+characterColumns+", embedding from characters where campaign_id = ? and deleted_at is null and embedding is not null",
+		campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var characters []models.Character
+	for rows.Next() {
+		var character models.Character
+		var j characterJSON
+		var embedding []byte
+		if err := rows.Scan(append(characterDest(&character, &j), &embedding)...); err != nil {
+			return nil, err
+		}
+		if err := j.decode(&character); err != nil {
+			return nil, err
+		}
+		if character.Embedding, err = s.dialect.decodeVector(embedding); err != nil {
+			return nil, err
+		}
+		characters = append(characters, character)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rankCharacters(characters, query, limit), nil
+}
+
+// SimilarLoreEntries returns the campaign's lore entries closest to query by
+// cosine similarity, best match first.
+func (s *SQLStore) SimilarLoreEntries(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredLoreEntry, error) {
+	if s.dialect.vectorSearch {
+		rows, err := s.query(ctx,
+			"select "+loreEntryColumns+", 1 - (embedding <=> ?) from lore_entries"+
+				" where campaign_id = ? and deleted_at is null and embedding is not null order by embedding <=> ? limit ?",
+			s.dialect.vector(query), campaignID, s.dialect.vector(query), limit)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		scored := []ScoredLoreEntry{}
+		for rows.Next() {
+			var result ScoredLoreEntry
+			if err := rows.Scan(append(loreEntryDest(&result.LoreEntry), &result.Score)...); err != nil {
+				return nil, err
+			}
+			scored = append(scored, result)
+		}
+		return scored, rows.Err()
+	}
+
+	rows, err := s.query(ctx,
+		"select "+loreEntryColumns+", embedding from lore_entries where campaign_id = ? and deleted_at is null and embedding is not null",
+		campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loreEntries []models.LoreEntry
+	for rows.Next() {
+		var loreEntry models.LoreEntry
+		var embedding []byte
+		if err := rows.Scan(append(loreEntryDest(&loreEntry), &embedding)...); err != nil {
+			return nil, err
+		}
+		if loreEntry.Embedding, err = s.dialect.decodeVector(embedding); err != nil {
+			return nil, err
+		}
+		loreEntries = append(loreEntries, loreEntry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rankLoreEntries(loreEntries, query, limit), nil
+}
+
+func (s *SQLStore) ListCharactersMissingEmbedding(ctx context.Context, limit, offset int) ([]models.Character, error) {
+	rows, err := s.query(ctx,
+		"select "+characterColumns+" from characters where embedding is null and deleted_at is null order by created_at limit ? offset ?",
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	characters := []models.Character{}
+	for rows.Next() {
+		character, err := scanCharacter(rows)
+		if err != nil {
+			return nil, err
+		}
+		characters = append(characters, *character)
+	}
+
+	return characters, rows.Err()
+}
+
+func (s *SQLStore) ListLoreEntriesMissingEmbedding(ctx context.Context, limit, offset int) ([]models.LoreEntry, error) {
+	rows, err := s.query(ctx,
+		"select "+loreEntryColumns+" from lore_entries where embedding is null and deleted_at is null order by created_at limit ? offset ?",
+		limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loreEntries := []models.LoreEntry{}
+	for rows.Next() {
+		loreEntry, err := scanLoreEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		loreEntries = append(loreEntries, *loreEntry)
+	}
+
+	return loreEntries, rows.Err()
+}
+
+func (s *SQLStore) SetCharacterEmbedding(ctx context.Context, id string, embedding []float32) error {
+	_, err := s.exec(ctx, "update characters set embedding = ? where id = ?", s.dialect.vector(embedding), id)
+	return err
+}
+
+func (s *SQLStore) SetLoreEntryEmbedding(ctx context.Context, id string, embedding []float32) error {
+	_, err := s.exec(ctx, "update lore_entries set embedding = ? where id = ?", s.dialect.vector(embedding), id)
+	return err
+}
+
+const provenanceColumns = "id, campaign_id, entity_type, entity_id, field, coalesce(content, ''), source, coalesce(proposal_id, ''), coalesce(created_by, ''), created_at"
+
+func scanProvenance(row rowScanner) (*models.Provenance, error) {
+	var provenance models.Provenance
+	err := row.Scan(&provenance.ID, &provenance.CampaignID, &provenance.EntityType, &provenance.EntityID,
+		&provenance.Field, &provenance.Content, &provenance.Source, &provenance.ProposalID,
+		&provenance.CreatedBy, &provenance.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &provenance, nil
+}
+
+func (s *SQLStore) ListProvenance(ctx context.Context, campaignID string) ([]models.Provenance, error) {
+	rows, err := s.query(ctx, "select "+provenanceColumns+" from ai_provenance where campaign_id = ? order by created_at", campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.Provenance{}
+	for rows.Next() {
+		provenance, err := scanProvenance(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, *provenance)
+	}
+
+	return records, rows.Err()
+}
+
+func (s *SQLStore) CreateProvenance(ctx context.Context, provenance *models.Provenance) (*models.Provenance, error) {
+	created, err := scanProvenance(s.queryRow(ctx,
+		`insert into ai_provenance (id, campaign_id, entity_type, entity_id, field, content, source, proposal_id, created_by, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+provenanceColumns,
+		uuid.NewString(), provenance.CampaignID, provenance.EntityType, provenance.EntityID, provenance.Field,
+		provenance.Content, provenance.Source, nullIfEmpty(provenance.ProposalID), nullIfEmpty(provenance.CreatedBy),
+		time.Now().UTC()))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) DeleteProvenance(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from ai_provenance where id = ?", id)
+	return err
+}
+
+const revisionColumns = "id, campaign_id, entity_type, entity_id, number, summary, snapshot, coalesce(created_by, ''), created_at"
+
+func scanRevision(row rowScanner) (*models.Revision, error) {
+	var revision models.Revision
+	var snapshot []byte
+	err := row.Scan(&revision.ID, &revision.CampaignID, &revision.EntityType, &revision.EntityID,
+		&revision.Number, &revision.Summary, &snapshot, &revision.CreatedBy, &revision.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	revision.Snapshot = snapshot
+	return &revision, nil
+}
+
+func (s *SQLStore) ListRevisions(ctx context.Context, entityType, entityID string) ([]models.Revision, error) {
+	rows, err := s.query(ctx, "select "+revisionColumns+" from revisions where entity_type = ? and entity_id = ? order by number",
+		entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, *revision)
+	}
+
+	return revisions, rows.Err()
+}
+
+func (s *SQLStore) GetRevision(ctx context.Context, id string) (*models.Revision, error) {
+	revision, err := scanRevision(s.queryRow(ctx, "select "+revisionColumns+" from revisions where id = ?", id))
+	return revision, s.translate(err)
+}
+
+func (s *SQLStore) CreateRevision(ctx context.Context, revision *models.Revision) (*models.Revision, error) {
+	created, err := scanRevision(s.queryRow(ctx,
+		`insert into revisions (id, campaign_id, entity_type, entity_id, number, summary, snapshot, created_by, created_at)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		returning `+revisionColumns,
+		uuid.NewString(), revision.CampaignID, revision.EntityType, revision.EntityID, revision.Number,
+		revision.Summary, string(revision.Snapshot), nullIfEmpty(revision.CreatedBy), time.Now().UTC()))
+	return created, s.translate(err)
+}
+
+func (s *SQLStore) DeleteRevision(ctx context.Context, id string) error {
+	_, err := s.exec(ctx, "delete from revisions where id = ?", id)
+	return err
+}
+
+func (s *SQLStore) setDeletedAt(ctx context.Context, table, id string, deletedAt *time.Time) error {
+	var value interface{}
+	if deletedAt != nil {
+		value = deletedAt.UTC()
+	}
+	_, err := s.exec(ctx, "update "+table+" set deleted_at = ? where id = ?", value, id)
+	return s.translate(err)
+}
+
+func (s *SQLStore) SetCampaignDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "campaigns", id, deletedAt)
+}
+
+func (s *SQLStore) SetCharacterDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "characters", id, deletedAt)
+}
+
+func (s *SQLStore) SetRelationshipDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "relationships", id, deletedAt)
+}
+
+func (s *SQLStore) SetLoreEntryDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "lore_entries", id, deletedAt)
+}
+
+func (s *SQLStore) ListTrashedCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	rows, err := s.query(ctx,
+		"select "+campaignColumns+", deleted_at from campaigns where user_id = ? and deleted_at is not null order by deleted_at desc",
+		userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	campaigns := []models.Campaign{}
+	for rows.Next() {
+		var campaign models.Campaign
+		var deletedAt time.Time
+		if err := rows.Scan(append(campaignDest(&campaign), &deletedAt)...); err != nil {
+			return nil, err
+		}
+		campaign.DeletedAt = &deletedAt
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+func (s *SQLStore) ListTrashedCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	rows, err := s.query(ctx,
+		"select "+characterColumns+", deleted_at from characters where campaign_id = ? and deleted_at is not null order by deleted_at desc",
+		campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	characters := []models.Character{}
+	for rows.Next() {
+		var character models.Character
+		var j characterJSON
+		var deletedAt time.Time
+		if err := rows.Scan(append(characterDest(&character, &j), &deletedAt)...); err != nil {
+			return nil, err
+		}
+		if err := j.decode(&character); err != nil {
+			return nil, err
+		}
+		character.DeletedAt = &deletedAt
+		characters = append(characters, character)
+	}
+
+	return characters, rows.Err()
+}
+
+func (s *SQLStore) ListTrashedRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	rows, err := s.query(ctx,
+		"select "+relationshipColumns+", deleted_at from relationships where campaign_id = ? and deleted_at is not null order by deleted_at desc",
+		campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	relationships := []models.Relationship{}
+	for rows.Next() {
+		var relationship models.Relationship
+		var deletedAt time.Time
+		if err := rows.Scan(append(relationshipDest(&relationship), &deletedAt)...); err != nil {
+			return nil, err
+		}
+		relationship.DeletedAt = &deletedAt
+		relationships = append(relationships, relationship)
+	}
+
+	return relationships, rows.Err()
+}
+
+func (s *SQLStore) ListTrashedLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	rows, err := s.query(ctx,
+		"select "+loreEntryColumns+", deleted_at from lore_entries where campaign_id = ? and deleted_at is not null order by deleted_at desc",
+		campaignID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	loreEntries := []models.LoreEntry{}
+	for rows.Next() {
+		var loreEntry models.LoreEntry
+		var deletedAt time.Time
+		if err := rows.Scan(append(loreEntryDest(&loreEntry), &deletedAt)...); err != nil {
+			return nil, err
+		}
+		loreEntry.DeletedAt = &deletedAt
+		loreEntries = append(loreEntries, loreEntry)
+	}
+
+	return loreEntries, rows.Err()
+}
+
+// PurgeTrash deletes the tables' trashed rows. Their children that were not
+// trashed themselves go with them through the foreign keys.
+func (s *SQLStore) PurgeTrash(ctx context.Context, cutoff time.Time) error {
+	for _, table := range []string{"relationships", "lore_entries", "characters", "campaigns"} {
+		if _, err := s.exec(ctx, "delete from "+table+" where deleted_at < ?", cutoff.UTC()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullIfEmpty stores optional IDs as NULL; "" is not a valid uuid in Postgres.
+func nullIfEmpty(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
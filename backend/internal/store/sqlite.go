@@ -0,0 +1,42 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+var sqliteDialect = dialect{
+	name: "sqlite",
+	isConflict: func(err error) bool {
+		return strings.Contains(err.Error(), "UNIQUE constraint failed")
+	},
+	encodeVector: func(vector []float32) interface{} {
+		return encodeVectorBlob(vector)
+	},
+	decodeVector: decodeVectorBlob,
+}
+
+// NewSQLiteStore opens (or creates) a SQLite database file for the offline
+// single-user mode and brings the schema up to date before returning.
+func NewSQLiteStore(ctx context.Context, path string) (*SQLStore, error) {
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrate(ctx, db, sqliteDialect); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return newSQLStore(db, sqliteDialect), nil
+}
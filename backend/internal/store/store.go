@@ -0,0 +1,168 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	ErrConflict = errors.New("conflict")
+)
+
+type CampaignStore interface {
+	// ListCampaigns returns the campaigns userID owns or is a member of.
+	ListCampaigns(ctx context.Context, userID string) ([]models.Campaign, error)
+	GetCampaign(ctx context.Context, id string) (*models.Campaign, error)
+	CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error)
+	UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error)
+	DeleteCampaign(ctx context.Context, id string) error
+}
+
+// MemberStore holds who else a campaign is shared with and the invitations
+// to join it. The owner is the campaign's user and has no member row.
+type MemberStore interface {
+	ListCampaignMembers(ctx context.Context, campaignID string) ([]models.CampaignMember, error)
+	GetCampaignMember(ctx context.Context, campaignID, userID string) (*models.CampaignMember, error)
+	CreateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error)
+	UpdateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error)
+	DeleteCampaignMember(ctx context.Context, campaignID, userID string) error
+
+	ListInvitations(ctx context.Context, campaignID string) ([]models.Invitation, error)
+	ListInvitationsForEmail(ctx context.Context, email string) ([]models.Invitation, error)
+	GetInvitation(ctx context.Context, id string) (*models.Invitation, error)
+	GetInvitationByCode(ctx context.Context, code string) (*models.Invitation, error)
+	CreateInvitation(ctx context.Context, invitation *models.Invitation) (*models.Invitation, error)
+	DeleteInvitation(ctx context.Context, id string) error
+}
+
+// ShareLinkStore holds the read-only links to a campaign.
+type ShareLinkStore interface {
+	ListShareLinks(ctx context.Context, campaignID string) ([]models.ShareLink, error)
+	GetShareLink(ctx context.Context, id string) (*models.ShareLink, error)
+	GetShareLinkByToken(ctx context.Context, token string) (*models.ShareLink, error)
+	CreateShareLink(ctx context.Context, link *models.ShareLink) (*models.ShareLink, error)
+	DeleteShareLink(ctx context.Context, id string) error
+}
+
+type CharacterStore interface {
+	ListCharacters(ctx context.Context, campaignID string) ([]models.Character, error)
+	GetCharacter(ctx context.Context, id string) (*models.Character, error)
+	CreateCharacter(ctx context.Context, character *models.Character) (*models.Character, error)
+	UpdateCharacter(ctx context.Context, character *models.Character) (*models.Character, error)
+	DeleteCharacter(ctx context.Context, id string) error
+}
+
+type RelationshipStore interface {
+	// ListRelationships leaves out relationships to a character in the trash,
+	// so no list of a campaign has edges to characters missing from it.
+	ListRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error)
+	GetRelationship(ctx context.Context, id string) (*models.Relationship, error)
+	CreateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error)
+	UpdateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error)
+	DeleteRelationship(ctx context.Context, id string) error
+}
+
+type RelationTypeStore interface {
+	ListRelationTypes(ctx context.Context, campaignID string) ([]models.RelationType, error)
+	GetRelationType(ctx context.Context, id string) (*models.RelationType, error)
+	CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error)
+	UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error)
+	DeleteRelationType(ctx context.Context, id string) error
+}
+
+type LoreEntryStore interface {
+	ListLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error)
+	GetLoreEntry(ctx context.Context, id string) (*models.LoreEntry, error)
+	CreateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error)
+	UpdateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error)
+	DeleteLoreEntry(ctx context.Context, id string) error
+}
+
+// EmbeddingStore is used to fill in embeddings for rows that were saved
+// without one (written before embeddings existed, or when the provider failed).
+type EmbeddingStore interface {
+	ListCharactersMissingEmbedding(ctx context.Context, limit, offset int) ([]models.Character, error)
+	ListLoreEntriesMissingEmbedding(ctx context.Context, limit, offset int) ([]models.LoreEntry, error)
+	SetCharacterEmbedding(ctx context.Context, id string, embedding []float32) error
+	SetLoreEntryEmbedding(ctx context.Context, id string, embedding []float32) error
+}
+
+// SearchStore ranks a campaign's rows by cosine similarity to a query embedding.
+// Rows without an embedding are never returned.
+type SearchStore interface {
+	SimilarCharacters(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredCharacter, error)
+	SimilarLoreEntries(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredLoreEntry, error)
+}
+
+// ProvenanceStore records AI-generated content accepted into a campaign.
+type ProvenanceStore interface {
+	ListProvenance(ctx context.Context, campaignID string) ([]models.Provenance, error)
+	CreateProvenance(ctx context.Context, provenance *models.Provenance) (*models.Provenance, error)
+	DeleteProvenance(ctx context.Context, id string) error
+}
+
+// RevisionStore keeps the saved versions of characters and lore entries.
+// Revisions outlive the entity they belong to.
+type RevisionStore interface {
+	// ListRevisions returns an entity's revisions, oldest first.
+	ListRevisions(ctx context.Context, entityType, entityID string) ([]models.Revision, error)
+	GetRevision(ctx context.Context, id string) (*models.Revision, error)
+	CreateRevision(ctx context.Context, revision *models.Revision) (*models.Revision, error)
+	DeleteRevision(ctx context.Context, id string) error
+}
+
+// TrashStore moves campaigns, characters, relationships and lore entries to
+// and from the trash. Trashed rows are left out of every other list, get,
+// update and search, while Delete* still removes them for good.
+type TrashStore interface {
+	// Set*DeletedAt moves a row to the trash at deletedAt, or takes it out
+	// again when deletedAt is nil.
+	SetCampaignDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error
+	SetCharacterDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error
+	SetRelationshipDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error
+	SetLoreEntryDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error
+
+	// ListTrashedCampaigns returns the trashed campaigns userID owns.
+	ListTrashedCampaigns(ctx context.Context, userID string) ([]models.Campaign, error)
+	ListTrashedCharacters(ctx context.Context, campaignID string) ([]models.Character, error)
+	ListTrashedRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error)
+	ListTrashedLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error)
+
+	// PurgeTrash deletes everything that was moved to the trash before cutoff.
+	PurgeTrash(ctx context.Context, cutoff time.Time) error
+}
+
+// TxStore groups writes. WithTx runs fn against a Store whose writes are kept
+// only if fn returns nil.
+type TxStore interface {
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// Store is the full persistence surface used by the API handlers.
+type Store interface {
+	CampaignStore
+	MemberStore
+	ShareLinkStore
+	CharacterStore
+	RelationshipStore
+	RelationTypeStore
+	LoreEntryStore
+	EmbeddingStore
+	SearchStore
+	ProvenanceStore
+	RevisionStore
+	TrashStore
+	TxStore
+}
+
+// visibility stores an empty visibility as public.
+func visibility(v string) string {
+	if v == "" {
+		return models.VisibilityPublic
+	}
+	return v
+}
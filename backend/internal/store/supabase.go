@@ -0,0 +1,1207 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+	"github.com/supabase-community/supabase-go"
+)
+
+// SupabaseStore talks to the Supabase PostgREST API using the service role client.
+type SupabaseStore struct {
+	client *supabase.Client
+}
+
+func NewSupabaseStore(client *supabase.Client) *SupabaseStore {
+	return &SupabaseStore{client: client}
+}
+
+func (s *SupabaseStore) ListCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	var memberships []models.CampaignMember
+	_, err := s.client.From("campaign_members").
+		Select("campaign_id", "", false).
+		Eq("user_id", userID).
+		ExecuteToWithContext(ctx, &memberships)
+
+	if err != nil {
+		return nil, err
+	}
+
+	filter := "user_id.eq." + userID
+	if len(memberships) > 0 {
+		ids := make([]string, len(memberships))
+		for i, m := range memberships {
+			ids[i] = m.CampaignID
+		}
+		filter += ",id.in.(" + strings.Join(ids, ",") + ")"
+	}
+
+	var campaigns []models.Campaign
+	_, err = s.client.From("campaigns").
+		Select("*", "", false).
+		Or(filter, "").
+		Is("deleted_at", "null").
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &campaigns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return campaigns, nil
+}
+
+func (s *SupabaseStore) ListCampaignMembers(ctx context.Context, campaignID string) ([]models.CampaignMember, error) {
+	var members []models.CampaignMember
+	_, err := s.client.From("campaign_members").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &members)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (s *SupabaseStore) GetCampaignMember(ctx context.Context, campaignID, userID string) (*models.CampaignMember, error) {
+	// Not being a member is the common case, so look for it without Single,
+	// which reports no rows as an error
+	var members []models.CampaignMember
+	_, err := s.client.From("campaign_members").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Eq("user_id", userID).
+		ExecuteToWithContext(ctx, &members)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(members) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &members[0], nil
+}
+
+func (s *SupabaseStore) CreateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	row := map[string]interface{}{
+		"campaign_id": member.CampaignID,
+		"user_id":     member.UserID,
+		"email":       nullIfEmpty(member.Email),
+		"role":        member.Role,
+	}
+
+	var result []models.CampaignMember
+	_, err := s.client.From("campaign_members").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("campaign_members")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) UpdateCampaignMember(ctx context.Context, member *models.CampaignMember) (*models.CampaignMember, error) {
+	update := map[string]interface{}{
+		"role": member.Role,
+	}
+
+	var result []models.CampaignMember
+	_, err := s.client.From("campaign_members").
+		Update(update, "", "").
+		Eq("campaign_id", member.CampaignID).
+		Eq("user_id", member.UserID).
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteCampaignMember(ctx context.Context, campaignID, userID string) error {
+	_, _, err := s.client.From("campaign_members").
+		Delete("", "").
+		Eq("campaign_id", campaignID).
+		Eq("user_id", userID).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListInvitations(ctx context.Context, campaignID string) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	_, err := s.client.From("campaign_invitations").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &invitations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// ListInvitationsForEmail matches case-insensitively with ilike, so an email
+// containing _ may also match others; callers compare the addresses exactly.
+func (s *SupabaseStore) ListInvitationsForEmail(ctx context.Context, email string) ([]models.Invitation, error) {
+	var invitations []models.Invitation
+	_, err := s.client.From("campaign_invitations").
+		Select("*", "", false).
+		Ilike("email", strings.ReplaceAll(email, "%", "")).
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &invitations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (s *SupabaseStore) GetInvitation(ctx context.Context, id string) (*models.Invitation, error) {
+	var invitation models.Invitation
+	_, err := s.client.From("campaign_invitations").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &invitation)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (s *SupabaseStore) GetInvitationByCode(ctx context.Context, code string) (*models.Invitation, error) {
+	var invitations []models.Invitation
+	_, err := s.client.From("campaign_invitations").
+		Select("*", "", false).
+		Eq("code", code).
+		ExecuteToWithContext(ctx, &invitations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(invitations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &invitations[0], nil
+}
+
+func (s *SupabaseStore) CreateInvitation(ctx context.Context, invitation *models.Invitation) (*models.Invitation, error) {
+	row := map[string]interface{}{
+		"campaign_id": invitation.CampaignID,
+		"email":       nullIfEmpty(invitation.Email),
+		"role":        invitation.Role,
+		"code":        invitation.Code,
+		"created_by":  invitation.CreatedBy,
+		"expires_at":  invitation.ExpiresAt.UTC(),
+	}
+
+	var result []models.Invitation
+	_, err := s.client.From("campaign_invitations").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("campaign_invitations")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteInvitation(ctx context.Context, id string) error {
+	_, _, err := s.client.From("campaign_invitations").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListShareLinks(ctx context.Context, campaignID string) ([]models.ShareLink, error) {
+	var links []models.ShareLink
+	_, err := s.client.From("campaign_share_links").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &links)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+func (s *SupabaseStore) GetShareLink(ctx context.Context, id string) (*models.ShareLink, error) {
+	var links []models.ShareLink
+	_, err := s.client.From("campaign_share_links").
+		Select("*", "", false).
+		Eq("id", id).
+		ExecuteToWithContext(ctx, &links)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &links[0], nil
+}
+
+func (s *SupabaseStore) GetShareLinkByToken(ctx context.Context, token string) (*models.ShareLink, error) {
+	var links []models.ShareLink
+	_, err := s.client.From("campaign_share_links").
+		Select("*", "", false).
+		Eq("token", token).
+		ExecuteToWithContext(ctx, &links)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(links) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &links[0], nil
+}
+
+func (s *SupabaseStore) CreateShareLink(ctx context.Context, link *models.ShareLink) (*models.ShareLink, error) {
+	categories := link.Categories
+	if categories == nil {
+		categories = []string{}
+	}
+	row := map[string]interface{}{
+		"campaign_id": link.CampaignID,
+		"label":       nullIfEmpty(link.Label),
+		"categories":  categories,
+		"created_by":  link.CreatedBy,
+		"token":       link.Token,
+	}
+	if link.ExpiresAt != nil {
+		row["expires_at"] = link.ExpiresAt.UTC()
+	}
+
+	var result []models.ShareLink
+	_, err := s.client.From("campaign_share_links").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("campaign_share_links")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteShareLink(ctx context.Context, id string) error {
+	_, _, err := s.client.From("campaign_share_links").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) GetCampaign(ctx context.Context, id string) (*models.Campaign, error) {
+	// Without Single, so a missing campaign is ErrNotFound rather than an
+	// error that would be reported as a failure of the database
+	var campaigns []models.Campaign
+	_, err := s.client.From("campaigns").
+		Select("*", "", false).
+		Eq("id", id).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &campaigns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(campaigns) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &campaigns[0], nil
+}
+
+func (s *SupabaseStore) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	row := map[string]interface{}{
+		"user_id":     campaign.UserID,
+		"title":       campaign.Title,
+		"description": campaign.Description,
+	}
+
+	var result []models.Campaign
+	_, err := s.client.From("campaigns").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("campaigns")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	update := map[string]interface{}{
+		"title":       campaign.Title,
+		"description": campaign.Description,
+	}
+
+	var result []models.Campaign
+	_, err := s.client.From("campaigns").
+		Update(update, "", "").
+		Eq("id", campaign.ID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteCampaign(ctx context.Context, id string) error {
+	_, _, err := s.client.From("campaigns").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	var characters []models.Character
+	_, err := s.client.From("characters").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &characters)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return characters, nil
+}
+
+func (s *SupabaseStore) GetCharacter(ctx context.Context, id string) (*models.Character, error) {
+	var character models.Character
+	_, err := s.client.From("characters").
+		Select("*", "", false).
+		Eq("id", id).
+		Is("deleted_at", "null").
+		Single().
+		ExecuteToWithContext(ctx, &character)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &character, nil
+}
+
+func (s *SupabaseStore) CreateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	row := map[string]interface{}{
+		"campaign_id":          character.CampaignID,
+		"name":                 character.Name,
+		"role":                 character.Role,
+		"attributes":           character.Attributes,
+		"background":           character.Background,
+		"visibility":           visibility(character.Visibility),
+		"attribute_visibility": attributeVisibility(character.AttributeVisibility),
+	}
+	if character.Embedding != nil {
+		row["embedding"] = formatVector(character.Embedding)
+	}
+
+	var result []models.Character
+	_, err := s.client.From("characters").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("characters")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) UpdateCharacter(ctx context.Context, character *models.Character) (*models.Character, error) {
+	update := map[string]interface{}{
+		"name":                 character.Name,
+		"role":                 character.Role,
+		"attributes":           character.Attributes,
+		"background":           character.Background,
+		"visibility":           visibility(character.Visibility),
+		"attribute_visibility": attributeVisibility(character.AttributeVisibility),
+	}
+	if character.Embedding != nil {
+		update["embedding"] = formatVector(character.Embedding)
+	}
+
+	var result []models.Character
+	_, err := s.client.From("characters").
+		Update(update, "", "").
+		Eq("id", character.ID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &result[0], nil
+}
+
+// attributeVisibility writes no attribute visibility as an empty object, as
+// the column is not nullable.
+func attributeVisibility(v map[string]string) map[string]string {
+	if v == nil {
+		return map[string]string{}
+	}
+	return v
+}
+
+func (s *SupabaseStore) DeleteCharacter(ctx context.Context, id string) error {
+	_, _, err := s.client.From("characters").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	var relationships []models.Relationship
+	_, err := s.client.From("relationships").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &relationships)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var characters []models.Character
+	_, err = s.client.From("characters").
+		Select("id", "", false).
+		Eq("campaign_id", campaignID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &characters)
+
+	if err != nil {
+		return nil, err
+	}
+
+	live := make(map[string]bool, len(characters))
+	for _, character := range characters {
+		live[character.ID] = true
+	}
+	listed := []models.Relationship{}
+	for _, relationship := range relationships {
+		if live[relationship.SourceCharacterID] && live[relationship.TargetCharacterID] {
+			listed = append(listed, relationship)
+		}
+	}
+
+	return listed, nil
+}
+
+func (s *SupabaseStore) GetRelationship(ctx context.Context, id string) (*models.Relationship, error) {
+	var relationship models.Relationship
+	_, err := s.client.From("relationships").
+		Select("*", "", false).
+		Eq("id", id).
+		Is("deleted_at", "null").
+		Single().
+		ExecuteToWithContext(ctx, &relationship)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationship, nil
+}
+
+func (s *SupabaseStore) CreateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	row := map[string]interface{}{
+		"campaign_id":         relationship.CampaignID,
+		"source_character_id": relationship.SourceCharacterID,
+		"target_character_id": relationship.TargetCharacterID,
+		"relation_type":       relationship.RelationType,
+		"description":         relationship.Description,
+		"visibility":          visibility(relationship.Visibility),
+	}
+
+	var result []models.Relationship
+	_, err := s.client.From("relationships").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("relationships")
+	}
+
+	return &result[0], nil
+}
+
+// restoreRelationship inserts relationship as it was, to undo its deletion in
+// WithTx.
+func (s *SupabaseStore) restoreRelationship(ctx context.Context, relationship *models.Relationship) error {
+	row := map[string]interface{}{
+		"id":                  relationship.ID,
+		"campaign_id":         relationship.CampaignID,
+		"source_character_id": relationship.SourceCharacterID,
+		"target_character_id": relationship.TargetCharacterID,
+		"relation_type":       relationship.RelationType,
+		"description":         relationship.Description,
+		"visibility":          visibility(relationship.Visibility),
+		"created_at":          relationship.CreatedAt.UTC(),
+		"deleted_at":          relationship.DeletedAt,
+	}
+
+	_, _, err := s.client.From("relationships").
+		Insert(row, false, "", "", "").
+		ExecuteWithContext(ctx)
+	return err
+}
+
+func (s *SupabaseStore) UpdateRelationship(ctx context.Context, relationship *models.Relationship) (*models.Relationship, error) {
+	update := map[string]interface{}{
+		"relation_type": relationship.RelationType,
+		"description":   relationship.Description,
+		"visibility":    visibility(relationship.Visibility),
+	}
+
+	var result []models.Relationship
+	_, err := s.client.From("relationships").
+		Update(update, "", "").
+		Eq("id", relationship.ID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteRelationship(ctx context.Context, id string) error {
+	_, _, err := s.client.From("relationships").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListRelationTypes(ctx context.Context, campaignID string) ([]models.RelationType, error) {
+	var relationTypes []models.RelationType
+	_, err := s.client.From("relation_types").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Order("name", nil).
+		ExecuteToWithContext(ctx, &relationTypes)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return relationTypes, nil
+}
+
+func (s *SupabaseStore) GetRelationType(ctx context.Context, id string) (*models.RelationType, error) {
+	var relationType models.RelationType
+	_, err := s.client.From("relation_types").
+		Select("*", "", false).
+		Eq("id", id).
+		Single().
+		ExecuteToWithContext(ctx, &relationType)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &relationType, nil
+}
+
+func (s *SupabaseStore) CreateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	row := map[string]interface{}{
+		"campaign_id":  relationType.CampaignID,
+		"name":         relationType.Name,
+		"inverse_name": nullIfEmpty(relationType.InverseName),
+		"symmetric":    relationType.Symmetric,
+	}
+
+	var result []models.RelationType
+	_, err := s.client.From("relation_types").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("relation_types")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) UpdateRelationType(ctx context.Context, relationType *models.RelationType) (*models.RelationType, error) {
+	update := map[string]interface{}{
+		"name":         relationType.Name,
+		"inverse_name": nullIfEmpty(relationType.InverseName),
+		"symmetric":    relationType.Symmetric,
+	}
+
+	var result []models.RelationType
+	_, err := s.client.From("relation_types").
+		Update(update, "", "").
+		Eq("id", relationType.ID).
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("relation_types")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteRelationType(ctx context.Context, id string) error {
+	_, _, err := s.client.From("relation_types").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	var loreEntries []models.LoreEntry
+	_, err := s.client.From("lore_entries").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &loreEntries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return loreEntries, nil
+}
+
+func (s *SupabaseStore) GetLoreEntry(ctx context.Context, id string) (*models.LoreEntry, error) {
+	var loreEntry models.LoreEntry
+	_, err := s.client.From("lore_entries").
+		Select("*", "", false).
+		Eq("id", id).
+		Is("deleted_at", "null").
+		Single().
+		ExecuteToWithContext(ctx, &loreEntry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &loreEntry, nil
+}
+
+func (s *SupabaseStore) CreateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	row := map[string]interface{}{
+		"campaign_id": loreEntry.CampaignID,
+		"title":       loreEntry.Title,
+		"category":    loreEntry.Category,
+		"content":     loreEntry.Content,
+		"visibility":  visibility(loreEntry.Visibility),
+	}
+	if loreEntry.Embedding != nil {
+		row["embedding"] = formatVector(loreEntry.Embedding)
+	}
+
+	var result []models.LoreEntry
+	_, err := s.client.From("lore_entries").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("lore_entries")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) UpdateLoreEntry(ctx context.Context, loreEntry *models.LoreEntry) (*models.LoreEntry, error) {
+	update := map[string]interface{}{
+		"title":      loreEntry.Title,
+		"category":   loreEntry.Category,
+		"content":    loreEntry.Content,
+		"visibility": visibility(loreEntry.Visibility),
+	}
+	if loreEntry.Embedding != nil {
+		update["embedding"] = formatVector(loreEntry.Embedding)
+	}
+
+	var result []models.LoreEntry
+	_, err := s.client.From("lore_entries").
+		Update(update, "", "").
+		Eq("id", loreEntry.ID).
+		Is("deleted_at", "null").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteLoreEntry(ctx context.Context, id string) error {
+	_, _, err := s.client.From("lore_entries").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListCharactersMissingEmbedding(ctx context.Context, limit, offset int) ([]models.Character, error) {
+	var characters []models.Character
+	_, err := s.client.From("characters").
+		Select("*", "", false).
+		Is("embedding", "null").
+		Is("deleted_at", "null").
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		ExecuteToWithContext(ctx, &characters)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return characters, nil
+}
+
+func (s *SupabaseStore) ListLoreEntriesMissingEmbedding(ctx context.Context, limit, offset int) ([]models.LoreEntry, error) {
+	var loreEntries []models.LoreEntry
+	_, err := s.client.From("lore_entries").
+		Select("*", "", false).
+		Is("embedding", "null").
+		Is("deleted_at", "null").
+		Order("created_at", nil).
+		Range(offset, offset+limit-1, "").
+		ExecuteToWithContext(ctx, &loreEntries)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return loreEntries, nil
+}
+
+func (s *SupabaseStore) SetCharacterEmbedding(ctx context.Context, id string, embedding []float32) error {
+	_, _, err := s.client.From("characters").
+		Update(map[string]interface{}{"embedding": formatVector(embedding)}, "minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) SetLoreEntryEmbedding(ctx context.Context, id string, embedding []float32) error {
+	_, _, err := s.client.From("lore_entries").
+		Update(map[string]interface{}{"embedding": formatVector(embedding)}, "minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListProvenance(ctx context.Context, campaignID string) ([]models.Provenance, error) {
+	var records []models.Provenance
+	_, err := s.client.From("ai_provenance").
+		Select("*", "", false).
+		Eq("campaign_id", campaignID).
+		Order("created_at", nil).
+		ExecuteToWithContext(ctx, &records)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (s *SupabaseStore) CreateProvenance(ctx context.Context, provenance *models.Provenance) (*models.Provenance, error) {
+	row := map[string]interface{}{
+		"campaign_id": provenance.CampaignID,
+		"entity_type": provenance.EntityType,
+		"entity_id":   provenance.EntityID,
+		"field":       provenance.Field,
+		"content":     provenance.Content,
+		"source":      provenance.Source,
+	}
+	if provenance.ProposalID != "" {
+		row["proposal_id"] = provenance.ProposalID
+	}
+	if provenance.CreatedBy != "" {
+		row["created_by"] = provenance.CreatedBy
+	}
+
+	var result []models.Provenance
+	_, err := s.client.From("ai_provenance").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("ai_provenance")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteProvenance(ctx context.Context, id string) error {
+	_, _, err := s.client.From("ai_provenance").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) ListRevisions(ctx context.Context, entityType, entityID string) ([]models.Revision, error) {
+	var revisions []models.Revision
+	_, err := s.client.From("revisions").
+		Select("*", "", false).
+		Eq("entity_type", entityType).
+		Eq("entity_id", entityID).
+		Order("number", nil).
+		ExecuteToWithContext(ctx, &revisions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (s *SupabaseStore) GetRevision(ctx context.Context, id string) (*models.Revision, error) {
+	var revisions []models.Revision
+	_, err := s.client.From("revisions").
+		Select("*", "", false).
+		Eq("id", id).
+		ExecuteToWithContext(ctx, &revisions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(revisions) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return &revisions[0], nil
+}
+
+func (s *SupabaseStore) CreateRevision(ctx context.Context, revision *models.Revision) (*models.Revision, error) {
+	row := map[string]interface{}{
+		"campaign_id": revision.CampaignID,
+		"entity_type": revision.EntityType,
+		"entity_id":   revision.EntityID,
+		"number":      revision.Number,
+		"summary":     revision.Summary,
+		"snapshot":    revision.Snapshot,
+	}
+	if revision.CreatedBy != "" {
+		row["created_by"] = revision.CreatedBy
+	}
+
+	var result []models.Revision
+	_, err := s.client.From("revisions").
+		Insert(row, false, "", "", "").
+		ExecuteToWithContext(ctx, &result)
+
+	// A concurrent save took the number first
+	if isUniqueViolation(err) {
+		return nil, fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, errNoRows("revisions")
+	}
+
+	return &result[0], nil
+}
+
+func (s *SupabaseStore) DeleteRevision(ctx context.Context, id string) error {
+	_, _, err := s.client.From("revisions").
+		Delete("", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) setDeletedAt(ctx context.Context, table, id string, deletedAt *time.Time) error {
+	var value interface{}
+	if deletedAt != nil {
+		value = deletedAt.UTC()
+	}
+
+	_, _, err := s.client.From(table).
+		Update(map[string]interface{}{"deleted_at": value}, "minimal", "").
+		Eq("id", id).
+		ExecuteWithContext(ctx)
+
+	return err
+}
+
+func (s *SupabaseStore) SetCampaignDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "campaigns", id, deletedAt)
+}
+
+func (s *SupabaseStore) SetCharacterDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "characters", id, deletedAt)
+}
+
+func (s *SupabaseStore) SetRelationshipDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "relationships", id, deletedAt)
+}
+
+func (s *SupabaseStore) SetLoreEntryDeletedAt(ctx context.Context, id string, deletedAt *time.Time) error {
+	return s.setDeletedAt(ctx, "lore_entries", id, deletedAt)
+}
+
+// listTrashed reads the trashed rows of table whose column equals value,
+// most recently trashed first.
+func (s *SupabaseStore) listTrashed(ctx context.Context, table, column, value string, to interface{}) error {
+	_, err := s.client.From(table).
+		Select("*", "", false).
+		Eq(column, value).
+		Not("deleted_at", "is", "null").
+		ExecuteToWithContext(ctx, to)
+
+	return err
+}
+
+func (s *SupabaseStore) ListTrashedCampaigns(ctx context.Context, userID string) ([]models.Campaign, error) {
+	var campaigns []models.Campaign
+	if err := s.listTrashed(ctx, "campaigns", "user_id", userID, &campaigns); err != nil {
+		return nil, err
+	}
+	sort.Slice(campaigns, func(i, j int) bool {
+		return campaigns[i].DeletedAt.After(*campaigns[j].DeletedAt)
+	})
+
+	return campaigns, nil
+}
+
+func (s *SupabaseStore) ListTrashedCharacters(ctx context.Context, campaignID string) ([]models.Character, error) {
+	var characters []models.Character
+	if err := s.listTrashed(ctx, "characters", "campaign_id", campaignID, &characters); err != nil {
+		return nil, err
+	}
+	sort.Slice(characters, func(i, j int) bool {
+		return characters[i].DeletedAt.After(*characters[j].DeletedAt)
+	})
+
+	return characters, nil
+}
+
+func (s *SupabaseStore) ListTrashedRelationships(ctx context.Context, campaignID string) ([]models.Relationship, error) {
+	var relationships []models.Relationship
+	if err := s.listTrashed(ctx, "relationships", "campaign_id", campaignID, &relationships); err != nil {
+		return nil, err
+	}
+	sort.Slice(relationships, func(i, j int) bool {
+		return relationships[i].DeletedAt.After(*relationships[j].DeletedAt)
+	})
+
+	return relationships, nil
+}
+
+func (s *SupabaseStore) ListTrashedLoreEntries(ctx context.Context, campaignID string) ([]models.LoreEntry, error) {
+	var loreEntries []models.LoreEntry
+	if err := s.listTrashed(ctx, "lore_entries", "campaign_id", campaignID, &loreEntries); err != nil {
+		return nil, err
+	}
+	sort.Slice(loreEntries, func(i, j int) bool {
+		return loreEntries[i].DeletedAt.After(*loreEntries[j].DeletedAt)
+	})
+
+	return loreEntries, nil
+}
+
+// PurgeTrash deletes the tables' trashed rows. Their children that were not
+// trashed themselves go with them through the foreign keys.
+func (s *SupabaseStore) PurgeTrash(ctx context.Context, cutoff time.Time) error {
+	for _, table := range []string{"relationships", "lore_entries", "characters", "campaigns"} {
+		_, _, err := s.client.From(table).
+			Delete("", "").
+			Lt("deleted_at", cutoff.UTC().Format(time.RFC3339Nano)).
+			ExecuteWithContext(ctx)
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithTx cannot open a database transaction through PostgREST, so it is not
+// atomic: it undoes fn's creates and updates on failure instead, and returns
+// the undo's errors along with fn's if that fails too. See journalStore.
+func (s *SupabaseStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return withJournal(ctx, s, fn)
+}
+
+// SimilarCharacters calls the match_characters function from database/init.sql,
+// since PostgREST cannot order by a pgvector distance on its own.
+func (s *SupabaseStore) SimilarCharacters(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredCharacter, error) {
+	var scored []ScoredCharacter
+	err := s.rpc("match_characters", map[string]interface{}{
+		"query_embedding":   formatVector(query),
+		"match_campaign_id": campaignID,
+		"match_count":       limit,
+	}, &scored)
+	if err != nil {
+		return nil, err
+	}
+
+	return scored, nil
+}
+
+// SimilarLoreEntries calls the match_lore_entries function from database/init.sql.
+func (s *SupabaseStore) SimilarLoreEntries(ctx context.Context, campaignID string, query []float32, limit int) ([]ScoredLoreEntry, error) {
+	var scored []ScoredLoreEntry
+	err := s.rpc("match_lore_entries", map[string]interface{}{
+		"query_embedding":   formatVector(query),
+		"match_campaign_id": campaignID,
+		"match_count":       limit,
+	}, &scored)
+	if err != nil {
+		return nil, err
+	}
+
+	return scored, nil
+}
+
+// rpc decodes the result of a Postgres function call. The supabase-go Rpc
+// wrapper swallows transport errors and returns PostgREST errors as the body,
+// so both cases are detected here.
+func (s *SupabaseStore) rpc(name string, body interface{}, to interface{}) error {
+	result := s.client.Rpc(name, "", body)
+	if result == "" {
+		return fmt.Errorf("rpc %s failed", name)
+	}
+
+	if err := json.Unmarshal([]byte(result), to); err != nil {
+		var rpcErr struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal([]byte(result), &rpcErr) == nil && rpcErr.Message != "" {
+			return fmt.Errorf("rpc %s: %s", name, rpcErr.Message)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func errNoRows(table string) error {
+	return fmt.Errorf("insert into %s returned no rows", table)
+}
+
+// isUniqueViolation reports whether err is PostgREST's error for a unique
+// constraint violation, which it formats as "(23505) message".
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "(23505)")
+}
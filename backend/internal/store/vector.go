@@ -0,0 +1,129 @@
+package store
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minato-wing/lore-keeper/backend/internal/models"
+)
+
+type ScoredCharacter struct {
+	models.Character
+	Score float64 `json:"score"`
+}
+
+type ScoredLoreEntry struct {
+	models.LoreEntry
+	Score float64 `json:"score"`
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, or 0 when
+// the vectors differ in length or either one is all zeros.
+func CosineSimilarity(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func rankCharacters(characters []models.Character, query []float32, limit int) []ScoredCharacter {
+	scored := make([]ScoredCharacter, 0, len(characters))
+	for _, character := range characters {
+		if len(character.Embedding) == 0 {
+			continue
+		}
+		scored = append(scored, ScoredCharacter{Character: character, Score: CosineSimilarity(query, character.Embedding)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+func rankLoreEntries(loreEntries []models.LoreEntry, query []float32, limit int) []ScoredLoreEntry {
+	scored := make([]ScoredLoreEntry, 0, len(loreEntries))
+	for _, loreEntry := range loreEntries {
+		if len(loreEntry.Embedding) == 0 {
+			continue
+		}
+		scored = append(scored, ScoredLoreEntry{LoreEntry: loreEntry, Score: CosineSimilarity(query, loreEntry.Embedding)})
+	}
+	sort.SliceStable(scored, func(i, j int) bool { return scored[i].Score > scored[j].Score })
+	if limit > 0 && len(scored) > limit {
+		scored = scored[:limit]
+	}
+	return scored
+}
+
+// encodeVectorBlob packs a vector as little-endian float32s for SQLite.
+func encodeVectorBlob(vector []float32) []byte {
+	if vector == nil {
+		return nil
+	}
+	blob := make([]byte, 4*len(vector))
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(blob[4*i:], math.Float32bits(v))
+	}
+	return blob
+}
+
+func decodeVectorBlob(blob []byte) ([]float32, error) {
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	if len(blob)%4 != 0 {
+		return nil, fmt.Errorf("invalid embedding blob length %d", len(blob))
+	}
+	vector := make([]float32, len(blob)/4)
+	for i := range vector {
+		vector[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[4*i:]))
+	}
+	return vector, nil
+}
+
+// formatVector renders a vector in pgvector's text format, e.g. "[0.1,0.2]".
+func formatVector(vector []float32) string {
+	parts := make([]string, len(vector))
+	for i, v := range vector {
+		parts[i] = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	}
+	return "[" + strings.Join(parts, ",") + "]"
+}
+
+func parseVector(text string) ([]float32, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "["), "]")
+	if text == "" {
+		return []float32{}, nil
+	}
+
+	parts := strings.Split(text, ",")
+	vector := make([]float32, len(parts))
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil {
+			return nil, err
+		}
+		vector[i] = float32(v)
+	}
+	return vector, nil
+}
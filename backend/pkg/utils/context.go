@@ -1,8 +1,6 @@
 package utils
 
 import (
-	"log"
-
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,3 +19,19 @@ func GetUserID(c *gin.Context) (string, bool) {
 	
 	return userID, true
 }
+
+// GetUserEmail retrieves the user_email from the Gin context. It is only set
+// when the token carries an email address.
+func GetUserEmail(c *gin.Context) (string, bool) {
+	value, exists := c.Get("user_email")
+	if !exists {
+		return "", false
+	}
+
+	email, ok := value.(string)
+	if !ok || email == "" {
+		return "", false
+	}
+
+	return email, true
+}
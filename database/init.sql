@@ -7,13 +7,106 @@ create table campaigns (
   title text not null,
   description text,
   created_at timestamptz default now(),
-  updated_at timestamptz default now()
+  updated_at timestamptz default now(),
+  -- ゴミ箱に移した日時。入っている行は一覧・取得・検索に出ず、保持期間 (TRASH_RETENTION_DAYS) を過ぎると
+  -- 完全に削除される。characters・relationships・lore_entries も同じ
+  deleted_at timestamptz
 );
 
--- Row Level Security (RLS) ポリシー: 自分のデータだけ見れるように
+create index on campaigns (deleted_at) where deleted_at is not null;
+
+-- キャンペーンの共有: 作成者 (campaigns.user_id) がオーナーで、
+-- それ以外のメンバーは編集者 (共同GM)・プレイヤー・閲覧者のいずれか
+create table campaign_members (
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  user_id uuid references auth.users on delete cascade not null,
+  email text, -- 招待を受けたときのメールアドレス (表示用)
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  primary key (campaign_id, user_id)
+);
+
+create index on campaign_members (user_id);
+
+-- 招待: コードを入力すると参加できる。メールアドレス付きの招待は
+-- そのアドレスでログインしたユーザーだけが受けられる。受けると削除される
+create table campaign_invitations (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  email text,
+  role text not null check (role in ('editor', 'player', 'viewer')),
+  code text not null unique,
+  created_by uuid references auth.users not null,
+  expires_at timestamptz not null,
+  created_at timestamptz default now()
+);
+
+create index on campaign_invitations (campaign_id);
+create index on campaign_invitations (lower(email));
+
+-- 共有リンク: トークンを知っていれば、ログインせずに公開範囲が public の内容だけを閲覧できる。
+-- categories が空でなければ、世界設定はそのカテゴリのものだけ。トークンはリンクごとにランダムに
+-- 発行して行に保存し、行を削除すると無効になる
+create table campaign_share_links (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  label text,
+  categories jsonb not null default '[]'::jsonb,
+  created_by uuid references auth.users not null,
+  token text not null unique, -- リンクに含めるランダムな値。リンクを削除すると無効になる
+  expires_at timestamptz, -- null は無期限
+  created_at timestamptz default now()
+);
+
+create index on campaign_share_links (campaign_id);
+
+-- ログイン中のユーザーのキャンペーンでのロール (メンバーでなければ null)。
+-- ポリシーから campaign_members を参照すると再帰するため security definer にする
+create or replace function campaign_role(target_campaign_id uuid)
+returns text
+language sql stable security definer set search_path = public
+as $$
+  select case
+    when exists (select 1 from campaigns where id = target_campaign_id and user_id = auth.uid()) then 'owner'
+    else (select role from campaign_members where campaign_id = target_campaign_id and user_id = auth.uid())
+  end;
+$$;
+
+-- Row Level Security (RLS) ポリシー: メンバーは閲覧、オーナーと編集者は編集、
+-- 削除と共有の管理はオーナーだけ
 alter table campaigns enable row level security;
-create policy "Users can only access their own campaigns"
-  on campaigns for all using (auth.uid() = user_id);
+create policy "Members can read campaigns"
+  on campaigns for select using (campaign_role(id) is not null);
+create policy "Users can create their own campaigns"
+  on campaigns for insert with check (auth.uid() = user_id);
+create policy "Editors can update campaigns"
+  on campaigns for update using (campaign_role(id) in ('owner', 'editor'));
+create policy "Owners can delete campaigns"
+  on campaigns for delete using (auth.uid() = user_id);
+
+alter table campaign_members enable row level security;
+create policy "Members can read members"
+  on campaign_members for select using (campaign_role(campaign_id) is not null);
+create policy "Owners can manage members"
+  on campaign_members for all using (campaign_role(campaign_id) = 'owner')
+  with check (campaign_role(campaign_id) = 'owner');
+create policy "Members can leave"
+  on campaign_members for delete using (auth.uid() = user_id);
+
+alter table campaign_invitations enable row level security;
+create policy "Owners can manage invitations"
+  on campaign_invitations for all using (campaign_role(campaign_id) = 'owner')
+  with check (campaign_role(campaign_id) = 'owner');
+create policy "Invitees can read their invitations"
+  on campaign_invitations for select using (lower(email) = lower(auth.jwt() ->> 'email'));
+
+-- 共有リンクでの閲覧はバックエンドが行うため、テーブルはオーナーだけが扱える
+alter table campaign_share_links enable row level security;
+create policy "Owners can manage share links"
+  on campaign_share_links for all using (campaign_role(campaign_id) = 'owner')
+  with check (campaign_role(campaign_id) = 'owner');
 
 create table characters (
   id uuid primary key default gen_random_uuid(),
@@ -22,14 +115,30 @@ create table characters (
   role text default 'NPC', -- PC, NPC, Villain etc.
   attributes jsonb default '{}'::jsonb, -- 自由なステータス管理 (例: {"str": 10, "class": "wizard"})
   background text, -- AI生成した詳細設定や過去
+  visibility text not null default 'public' check (visibility in ('public', 'gm')), -- 'gm' はオーナーと編集者のみ閲覧可
+  attribute_visibility jsonb not null default '{}'::jsonb, -- 属性キーごとの公開範囲 (例: {"true_identity": "gm"})
   embedding vector(1536), -- OpenAIのtext-embedding-3-small等は1536次元
   created_at timestamptz default now(),
-  updated_at timestamptz default now()
+  updated_at timestamptz default now(),
+  deleted_at timestamptz -- キャラクターを削除すると関係性も同じ日時でゴミ箱に入り、一緒に復元される
 );
 
+create index on characters (deleted_at) where deleted_at is not null;
+
 -- 検索速度向上のためのインデックス
 create index on characters using ivfflat (embedding vector_cosine_ops);
 
+alter table characters enable row level security;
+-- プレイヤー・閲覧者は公開のキャラクターのみ (属性キー単位の非公開はAPI側で除外)
+create policy "Members can read characters"
+  on characters for select using (
+    campaign_role(campaign_id) in ('owner', 'editor')
+    or (campaign_role(campaign_id) is not null and visibility = 'public')
+  );
+create policy "Editors can write characters"
+  on characters for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
 create table relationships (
   id uuid primary key default gen_random_uuid(),
   campaign_id uuid references campaigns(id) on delete cascade not null,
@@ -37,12 +146,26 @@ create table relationships (
   target_character_id uuid references characters(id) on delete cascade not null,
   relation_type text not null, -- "friend", "rival", "family"
   description text, -- "幼馴染だが、過去の事件で疎遠になった" 等
+  visibility text not null default 'public' check (visibility in ('public', 'gm')),
   created_at timestamptz default now(),
-  
-  -- 同じペアの重複登録を防ぐ（A→Bは1つだけ）
-  unique(source_character_id, target_character_id)
+  deleted_at timestamptz
 );
 
+create index on relationships (deleted_at) where deleted_at is not null;
+-- 同じペアの重複登録を防ぐ（A→Bは1つだけ）。ゴミ箱にある行は数えないので、同じペアの関係性を作り直してもゴミ箱に残る
+create unique index relationships_live_pair_idx
+  on relationships (source_character_id, target_character_id) where deleted_at is null;
+
+alter table relationships enable row level security;
+create policy "Members can read relationships"
+  on relationships for select using (
+    campaign_role(campaign_id) in ('owner', 'editor')
+    or (campaign_role(campaign_id) is not null and visibility = 'public')
+  );
+create policy "Editors can write relationships"
+  on relationships for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
 
 create table lore_entries (
   id uuid primary key default gen_random_uuid(),
@@ -50,11 +173,142 @@ create table lore_entries (
   title text not null,
   category text, -- "History", "Geography", "Magic", "Item"
   content text not null,
+  visibility text not null default 'public' check (visibility in ('public', 'gm')),
   embedding vector(1536), -- AI検索用
   created_at timestamptz default now(),
-  updated_at timestamptz default now()
+  updated_at timestamptz default now(),
+  deleted_at timestamptz
 );
 
+create index on lore_entries (deleted_at) where deleted_at is not null;
+
 -- ベクトルインデックス
 create index on lore_entries using ivfflat (embedding vector_cosine_ops);
 
+alter table lore_entries enable row level security;
+create policy "Members can read lore entries"
+  on lore_entries for select using (
+    campaign_role(campaign_id) in ('owner', 'editor')
+    or (campaign_role(campaign_id) is not null and visibility = 'public')
+  );
+create policy "Editors can write lore entries"
+  on lore_entries for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
+
+-- 関係の種類の登録: 対になる関係（師匠 ↔ 弟子）や向きのない関係（兄弟）を
+-- 登録しておくと、関係性を作成・更新・削除したときに逆向きの関係性も保たれる
+create table relation_types (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  name text not null,
+  inverse_name text, -- "mentor" に対する "apprentice" 等
+  symmetric boolean not null default false, -- "sibling" のように向きのない関係
+  created_at timestamptz default now(),
+  updated_at timestamptz default now(),
+
+  unique(campaign_id, name)
+);
+
+alter table relation_types enable row level security;
+create policy "Members can read relation types"
+  on relation_types for select using (campaign_role(campaign_id) is not null);
+create policy "Editors can write relation types"
+  on relation_types for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
+
+-- AIが生成し、ユーザーが採用した内容の記録
+create table ai_provenance (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  entity_type text not null, -- "character", "relationship", "lore_entry"
+  entity_id uuid not null,
+  field text not null, -- "background", "attributes.<key>", "record"
+  content text,
+  source text not null, -- "deep_dive" 等
+  proposal_id text,
+  created_by uuid,
+  created_at timestamptz default now()
+);
+
+create index on ai_provenance (campaign_id);
+create index on ai_provenance (entity_id);
+
+alter table ai_provenance enable row level security;
+-- 非公開の内容を含みうるため、閲覧もオーナーと編集者のみ
+create policy "Editors can write provenance"
+  on ai_provenance for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
+-- 変更履歴: キャラクター・世界設定を更新するたびに、保存した内容の全体 (snapshot) を1行ずつ残す。
+-- 最初の変更では変更前の内容も残す。エンティティを削除しても履歴は残る
+create table revisions (
+  id uuid primary key default gen_random_uuid(),
+  campaign_id uuid references campaigns(id) on delete cascade not null,
+  entity_type text not null check (entity_type in ('character', 'lore_entry')),
+  entity_id uuid not null,
+  number integer not null, -- エンティティごとの版番号 (1から)
+  summary text not null default '', -- 変更した項目の要約
+  snapshot jsonb not null,
+  created_by uuid,
+  created_at timestamptz default now(),
+
+  unique (entity_type, entity_id, number)
+);
+
+create index on revisions (campaign_id);
+
+alter table revisions enable row level security;
+-- 過去の非公開の内容を含むため、閲覧もオーナーと編集者のみ
+create policy "Editors can manage revisions"
+  on revisions for all using (campaign_role(campaign_id) in ('owner', 'editor'))
+  with check (campaign_role(campaign_id) in ('owner', 'editor'));
+
+-- 類似検索用の関数 (PostgRESTの /rpc 経由で呼び出す)
+create or replace function match_characters(query_embedding vector(1536), match_campaign_id uuid, match_count int)
+returns table (
+  id uuid,
+  campaign_id uuid,
+  name text,
+  role text,
+  attributes jsonb,
+  background text,
+  visibility text,
+  attribute_visibility jsonb,
+  created_at timestamptz,
+  updated_at timestamptz,
+  score float
+)
+language sql stable
+as $$
+  select c.id, c.campaign_id, c.name, c.role, c.attributes, c.background, c.visibility, c.attribute_visibility,
+    c.created_at, c.updated_at,
+    1 - (c.embedding <=> query_embedding) as score
+  from characters c
+  where c.campaign_id = match_campaign_id and c.deleted_at is null and c.embedding is not null
+  order by c.embedding <=> query_embedding
+  limit match_count;
+$$;
+
+create or replace function match_lore_entries(query_embedding vector(1536), match_campaign_id uuid, match_count int)
+returns table (
+  id uuid,
+  campaign_id uuid,
+  title text,
+  category text,
+  content text,
+  visibility text,
+  created_at timestamptz,
+  updated_at timestamptz,
+  score float
+)
+language sql stable
+as $$
+  select l.id, l.campaign_id, l.title, l.category, l.content, l.visibility, l.created_at, l.updated_at,
+    1 - (l.embedding <=> query_embedding) as score
+  from lore_entries l
+  where l.campaign_id = match_campaign_id and l.deleted_at is null and l.embedding is not null
+  order by l.embedding <=> query_embedding
+  limit match_count;
+$$;
@@ -2,10 +2,12 @@
 
 import { useEffect, useState } from 'react'
 import { useParams } from 'next/navigation'
-import { api, Character } from '@/lib/api'
+import { api, Character, Proposal, Visibility } from '@/lib/api'
 import Link from 'next/link'
 import { ArrowLeft, Plus, Sparkles } from 'lucide-react'
 import AuthGuard from '@/components/AuthGuard'
+import VisibilityBadge from '@/components/VisibilityBadge'
+import RevisionHistory from '@/components/RevisionHistory'
 
 function CharactersContent() {
   const params = useParams()
@@ -14,13 +16,16 @@ function CharactersContent() {
   const [loading, setLoading] = useState(true)
   const [showCreateForm, setShowCreateForm] = useState(false)
   const [showDeepDive, setShowDeepDive] = useState(false)
+  const [historyId, setHistoryId] = useState<string | null>(null)
   const [formData, setFormData] = useState({
     name: '',
     role: 'NPC',
     background: '',
+    visibility: 'public' as Visibility,
   })
   const [deepDiveInput, setDeepDiveInput] = useState('')
   const [suggestions, setSuggestions] = useState<string[]>([])
+  const [proposals, setProposals] = useState<Proposal[]>([])
   const [generatingAI, setGeneratingAI] = useState(false)
 
   useEffect(() => {
@@ -45,7 +50,7 @@ function CharactersContent() {
         campaign_id: campaignId,
         ...formData,
       })
-      setFormData({ name: '', role: 'NPC', background: '' })
+      setFormData({ name: '', role: 'NPC', background: '', visibility: 'public' })
       setShowCreateForm(false)
       loadCharacters()
     } catch (error) {
@@ -57,8 +62,9 @@ function CharactersContent() {
     setGeneratingAI(true)
     try {
       const input = JSON.parse(deepDiveInput)
-      const result = await api.ai.deepDive(input)
+      const result = await api.ai.deepDive(campaignId, input)
       setSuggestions(result.suggestions)
+      setProposals(result.proposals)
     } catch (error) {
       console.error('Failed to generate deep dive:', error)
       alert('AI生成に失敗しました。入力形式を確認してください。')
@@ -67,8 +73,19 @@ function CharactersContent() {
     }
   }
 
+  const handleApply = async (proposal: Proposal) => {
+    try {
+      await api.ai.applyProposals(campaignId, [proposal])
+      setProposals(proposals.filter((p) => p.id !== proposal.id))
+      loadCharacters()
+    } catch (error) {
+      console.error('Failed to apply proposal:', error)
+      alert('提案の反映に失敗しました')
+    }
+  }
+
   const handleDelete = async (id: string) => {
-    if (!confirm('このキャラクターを削除しますか？')) return
+    if (!confirm('このキャラクターをゴミ箱に移動しますか？（関係性も一緒に移動します）')) return
     try {
       await api.characters.delete(id)
       loadCharacters()
@@ -143,11 +160,22 @@ function CharactersContent() {
             {suggestions.length > 0 && (
               <div className="mt-6 space-y-4">
                 <h3 className="text-xl font-bold text-white">提案:</h3>
-                {suggestions.map((suggestion, index) => (
-                  <div key={index} className="bg-slate-700 p-4 rounded-lg">
-                    <p className="text-slate-300">{suggestion}</p>
-                  </div>
-                ))}
+                {suggestions.map((suggestion, index) => {
+                  const proposal = proposals.find((p) => p.summary === suggestion)
+                  return (
+                    <div key={index} className="bg-slate-700 p-4 rounded-lg flex justify-between items-start gap-4">
+                      <p className="text-slate-300">{suggestion}</p>
+                      {proposal && (
+                        <button
+                          onClick={() => handleApply(proposal)}
+                          className="shrink-0 px-3 py-1 text-sm bg-purple-600 text-white rounded hover:bg-purple-700 transition-colors"
+                        >
+                          反映
+                        </button>
+                      )}
+                    </div>
+                  )
+                })}
               </div>
             )}
           </div>
@@ -189,6 +217,14 @@ function CharactersContent() {
                   rows={4}
                 />
               </div>
+              <label className="flex items-center gap-2 text-slate-300">
+                <input
+                  type="checkbox"
+                  checked={formData.visibility === 'gm'}
+                  onChange={(e) => setFormData({ ...formData, visibility: e.target.checked ? 'gm' : 'public' })}
+                />
+                GMのみに公開（プレイヤー・閲覧者には表示しない）
+              </label>
               <div className="flex gap-2">
                 <button
                   type="submit"
@@ -212,23 +248,39 @@ function CharactersContent() {
           {characters.map((character) => (
             <div
               key={character.id}
-              className="bg-slate-800 p-6 rounded-lg hover:bg-slate-700 transition-colors"
+              className={`bg-slate-800 p-6 rounded-lg hover:bg-slate-700 transition-colors ${
+                historyId === character.id ? 'md:col-span-2 lg:col-span-3' : ''
+              }`}
             >
               <div className="flex justify-between items-start mb-4">
                 <div>
-                  <h3 className="text-xl font-bold text-white">{character.name}</h3>
+                  <h3 className="text-xl font-bold text-white flex items-center gap-2">
+                    {character.name}
+                    <VisibilityBadge visibility={character.visibility} />
+                  </h3>
                   <span className="text-sm text-slate-400">{character.role}</span>
                 </div>
-                <button
-                  onClick={() => handleDelete(character.id)}
-                  className="text-red-400 hover:text-red-300 text-sm"
-                >
-                  削除
-                </button>
+                <div className="flex gap-3">
+                  <button
+                    onClick={() => setHistoryId(historyId === character.id ? null : character.id)}
+                    className="text-slate-400 hover:text-slate-300 text-sm"
+                  >
+                    履歴
+                  </button>
+                  <button
+                    onClick={() => handleDelete(character.id)}
+                    className="text-red-400 hover:text-red-300 text-sm"
+                  >
+                    削除
+                  </button>
+                </div>
               </div>
               {character.background && (
                 <p className="text-slate-300 line-clamp-4">{character.background}</p>
               )}
+              {historyId === character.id && (
+                <RevisionHistory entity="characters" id={character.id} onRestored={loadCharacters} />
+              )}
             </div>
           ))}
         </div>
@@ -2,10 +2,12 @@
 
 import { useEffect, useState } from 'react'
 import { useParams } from 'next/navigation'
-import { api, LoreEntry } from '@/lib/api'
+import { api, LoreEntry, Visibility } from '@/lib/api'
 import Link from 'next/link'
 import { ArrowLeft, Plus, AlertTriangle } from 'lucide-react'
 import AuthGuard from '@/components/AuthGuard'
+import VisibilityBadge from '@/components/VisibilityBadge'
+import RevisionHistory from '@/components/RevisionHistory'
 
 function LoreContent() {
   const params = useParams()
@@ -14,15 +16,26 @@ function LoreContent() {
   const [loading, setLoading] = useState(true)
   const [showCreateForm, setShowCreateForm] = useState(false)
   const [showConsistencyCheck, setShowConsistencyCheck] = useState(false)
+  const [historyId, setHistoryId] = useState<string | null>(null)
   const [formData, setFormData] = useState({
     title: '',
     category: 'History',
     content: '',
+    visibility: 'public' as Visibility,
   })
   const [checkContent, setCheckContent] = useState('')
   const [checkResult, setCheckResult] = useState<{
     is_consistent: boolean
-    warnings: string[]
+    warnings: {
+      severity: 'low' | 'medium' | 'high'
+      message: string
+      entity_type?: 'character' | 'lore_entry'
+      entity_id?: string
+      entity_title?: string
+      existing_excerpt?: string
+      new_excerpt?: string
+      suggested_resolution?: string
+    }[]
   } | null>(null)
   const [checking, setChecking] = useState(false)
 
@@ -48,7 +61,7 @@ function LoreContent() {
         campaign_id: campaignId,
         ...formData,
       })
-      setFormData({ title: '', category: 'History', content: '' })
+      setFormData({ title: '', category: 'History', content: '', visibility: 'public' })
       setShowCreateForm(false)
       loadLoreEntries()
     } catch (error) {
@@ -70,7 +83,7 @@ function LoreContent() {
   }
 
   const handleDelete = async (id: string) => {
-    if (!confirm('この設定を削除しますか？')) return
+    if (!confirm('この設定をゴミ箱に移動しますか？')) return
     try {
       await api.loreEntries.delete(id)
       loadLoreEntries()
@@ -157,9 +170,23 @@ function LoreContent() {
                   {checkResult.warnings.length > 0 && (
                     <div className="space-y-2">
                       {checkResult.warnings.map((warning, index) => (
-                        <p key={index} className="text-slate-300">
-                          • {warning}
-                        </p>
+                        <div key={index} className="text-slate-300">
+                          <p>
+                            • [{warning.severity}] {warning.message}
+                            {warning.entity_title && (
+                              <span className="text-slate-500"> （{warning.entity_title}）</span>
+                            )}
+                          </p>
+                          {warning.existing_excerpt && (
+                            <p className="ml-4 text-sm text-slate-400">既存: 「{warning.existing_excerpt}」</p>
+                          )}
+                          {warning.new_excerpt && (
+                            <p className="ml-4 text-sm text-slate-400">新規: 「{warning.new_excerpt}」</p>
+                          )}
+                          {warning.suggested_resolution && (
+                            <p className="ml-4 text-sm text-slate-400">提案: {warning.suggested_resolution}</p>
+                          )}
+                        </div>
                       ))}
                     </div>
                   )}
@@ -208,6 +235,14 @@ function LoreContent() {
                   required
                 />
               </div>
+              <label className="flex items-center gap-2 text-slate-300">
+                <input
+                  type="checkbox"
+                  checked={formData.visibility === 'gm'}
+                  onChange={(e) => setFormData({ ...formData, visibility: e.target.checked ? 'gm' : 'public' })}
+                />
+                GMのみに公開（プレイヤー・閲覧者には表示しない）
+              </label>
               <div className="flex gap-2">
                 <button
                   type="submit"
@@ -232,24 +267,38 @@ function LoreContent() {
             <div key={entry.id} className="bg-slate-800 p-6 rounded-lg">
               <div className="flex justify-between items-start mb-4">
                 <div>
-                  <h3 className="text-2xl font-bold text-white">{entry.title}</h3>
+                  <h3 className="text-2xl font-bold text-white flex items-center gap-2">
+                    {entry.title}
+                    <VisibilityBadge visibility={entry.visibility} />
+                  </h3>
                   {entry.category && (
                     <span className="inline-block mt-2 px-3 py-1 bg-slate-700 text-slate-300 text-sm rounded-full">
                       {entry.category}
                     </span>
                   )}
                 </div>
-                <button
-                  onClick={() => handleDelete(entry.id)}
-                  className="text-red-400 hover:text-red-300 text-sm"
-                >
-                  削除
-                </button>
+                <div className="flex gap-3">
+                  <button
+                    onClick={() => setHistoryId(historyId === entry.id ? null : entry.id)}
+                    className="text-slate-400 hover:text-slate-300 text-sm"
+                  >
+                    履歴
+                  </button>
+                  <button
+                    onClick={() => handleDelete(entry.id)}
+                    className="text-red-400 hover:text-red-300 text-sm"
+                  >
+                    削除
+                  </button>
+                </div>
               </div>
               <p className="text-slate-300 whitespace-pre-wrap">{entry.content}</p>
               <p className="text-slate-500 text-sm mt-4">
                 作成日: {new Date(entry.created_at).toLocaleDateString('ja-JP')}
               </p>
+              {historyId === entry.id && (
+                <RevisionHistory entity="lore-entries" id={entry.id} onRestored={loadLoreEntries} />
+              )}
             </div>
           ))}
         </div>
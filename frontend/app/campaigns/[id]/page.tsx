@@ -2,10 +2,12 @@
 
 import { useEffect, useState } from 'react'
 import { useParams } from 'next/navigation'
-import { api, Campaign, Character } from '@/lib/api'
+import { api, Campaign, Character, Proposal } from '@/lib/api'
 import Link from 'next/link'
-import { ArrowLeft, Users, BookOpen, Network } from 'lucide-react'
+import { ArrowLeft, Users, BookOpen, Network, NotebookPen, Download, FileArchive } from 'lucide-react'
 import AuthGuard from '@/components/AuthGuard'
+import CampaignSharing, { roleLabels } from '@/components/CampaignSharing'
+import Trash from '@/components/Trash'
 
 function CampaignDetailContent() {
   const params = useParams()
@@ -13,6 +15,11 @@ function CampaignDetailContent() {
   const [campaign, setCampaign] = useState<Campaign | null>(null)
   const [characters, setCharacters] = useState<Character[]>([])
   const [loading, setLoading] = useState(true)
+  const [notes, setNotes] = useState('')
+  const [ingesting, setIngesting] = useState(false)
+  const [changeset, setChangeset] = useState<Proposal[] | null>(null)
+  const [selected, setSelected] = useState<Set<string>>(new Set())
+  const canEdit = campaign?.role === 'owner' || campaign?.role === 'editor'
 
   useEffect(() => {
     loadData()
@@ -33,6 +40,84 @@ function CampaignDetailContent() {
     }
   }
 
+  const handleExport = async () => {
+    try {
+      const archive = await api.campaigns.export(campaignId)
+      const blob = new Blob([JSON.stringify(archive, null, 2)], { type: 'application/json' })
+      const url = URL.createObjectURL(blob)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `campaign-${campaignId}.json`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (error) {
+      console.error('Failed to export campaign:', error)
+      alert('エクスポートに失敗しました')
+    }
+  }
+
+  const handleExportMarkdown = async () => {
+    try {
+      const blob = await api.campaigns.exportMarkdown(campaignId)
+      const url = URL.createObjectURL(blob)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `campaign-${campaignId}.zip`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (error) {
+      console.error('Failed to export campaign as Markdown:', error)
+      alert('Markdownエクスポートに失敗しました')
+    }
+  }
+
+  const handleIngest = async () => {
+    setIngesting(true)
+    try {
+      const result = await api.campaigns.ingestSessionNotes(campaignId, notes)
+      setChangeset(result.proposals)
+      setSelected(new Set(result.proposals.map((p) => p.id)))
+    } catch (error) {
+      console.error('Failed to ingest session notes:', error)
+      alert('セッションメモの解析に失敗しました')
+    } finally {
+      setIngesting(false)
+    }
+  }
+
+  const handleApplyChangeset = async () => {
+    if (!changeset) return
+    try {
+      await api.ai.applyProposals(
+        campaignId,
+        changeset.filter((p) => selected.has(p.id)),
+        'session_notes'
+      )
+      setChangeset(null)
+      setNotes('')
+      loadData()
+    } catch (error) {
+      console.error('Failed to apply changeset:', error)
+      alert('変更の反映に失敗しました')
+    }
+  }
+
+  const toggleSelected = (id: string) => {
+    const next = new Set(selected)
+    if (next.has(id)) {
+      next.delete(id)
+    } else {
+      next.add(id)
+    }
+    setSelected(next)
+  }
+
+  const proposalDetail = (proposal: Proposal) =>
+    proposal.character?.background ||
+    proposal.lore_entry?.content ||
+    proposal.background ||
+    (proposal.attribute_key && `${proposal.attribute_key}: ${JSON.stringify(proposal.attribute_value)}`)
+
   if (loading) {
     return (
       <div className="min-h-screen bg-slate-900 flex items-center justify-center">
@@ -61,7 +146,30 @@ function CampaignDetailContent() {
         </Link>
 
         <div className="bg-slate-800 p-8 rounded-lg mb-8">
-          <h1 className="text-4xl font-bold text-white mb-4">{campaign.title}</h1>
+          <div className="flex justify-between items-start mb-4">
+            <div>
+              <h1 className="text-4xl font-bold text-white">{campaign.title}</h1>
+              {campaign.role && campaign.role !== 'owner' && (
+                <p className="text-slate-400 mt-2">{roleLabels[campaign.role]}として参加中</p>
+              )}
+            </div>
+            <div className="flex gap-2">
+              <button
+                onClick={handleExport}
+                className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors"
+              >
+                <Download size={20} />
+                エクスポート
+              </button>
+              <button
+                onClick={handleExportMarkdown}
+                className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors"
+              >
+                <FileArchive size={20} />
+                Markdown
+              </button>
+            </div>
+          </div>
           {campaign.description && (
             <p className="text-slate-300 text-lg">{campaign.description}</p>
           )}
@@ -107,6 +215,69 @@ function CampaignDetailContent() {
             </p>
           </Link>
         </div>
+
+        {canEdit && (
+        <div className="bg-slate-800 p-6 rounded-lg mt-8">
+          <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+            <NotebookPen size={24} className="text-yellow-400" />
+            セッションメモの取り込み
+          </h2>
+          <p className="text-slate-400 mb-4">
+            セッション後のメモを貼り付けると、新しいNPC・場所・アイテム・設定と既存項目への追記を提案します
+          </p>
+          <textarea
+            value={notes}
+            onChange={(e) => setNotes(e.target.value)}
+            className="w-full px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-yellow-500 mb-4"
+            rows={6}
+          />
+          <button
+            onClick={handleIngest}
+            disabled={ingesting || !notes.trim()}
+            className="px-4 py-2 bg-yellow-600 text-white rounded-lg hover:bg-yellow-700 transition-colors disabled:opacity-50"
+          >
+            {ingesting ? '解析中...' : '解析'}
+          </button>
+
+          {changeset && (
+            <div className="mt-6 space-y-2">
+              {changeset.length === 0 && (
+                <p className="text-slate-400">新しい情報は見つかりませんでした</p>
+              )}
+              {changeset.map((proposal) => (
+                <label key={proposal.id} className="flex items-start gap-3 bg-slate-700 p-3 rounded-lg">
+                  <input
+                    type="checkbox"
+                    checked={selected.has(proposal.id)}
+                    onChange={() => toggleSelected(proposal.id)}
+                    className="mt-1"
+                  />
+                  <div>
+                    <p className="text-white">{proposal.summary}</p>
+                    <p className="text-slate-300 text-sm whitespace-pre-wrap">{proposalDetail(proposal)}</p>
+                    {proposal.evidence && (
+                      <p className="text-slate-400 text-sm">「{proposal.evidence}」</p>
+                    )}
+                  </div>
+                </label>
+              ))}
+              {changeset.length > 0 && (
+                <button
+                  onClick={handleApplyChangeset}
+                  disabled={selected.size === 0}
+                  className="mt-4 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors disabled:opacity-50"
+                >
+                  選択した変更を反映
+                </button>
+              )}
+            </div>
+          )}
+        </div>
+        )}
+
+        {canEdit && <Trash campaignId={campaignId} characters={characters} onRestore={loadData} />}
+
+        <CampaignSharing campaign={campaign} />
       </div>
     </div>
   )
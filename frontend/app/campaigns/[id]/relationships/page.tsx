@@ -2,9 +2,9 @@
 
 import { useEffect, useState, useCallback } from 'react'
 import { useParams } from 'next/navigation'
-import { api, Character, Relationship } from '@/lib/api'
+import { api, Character, ExtractRelationshipsResponse, GraphAnalysis, GraphPath, Relationship, RelationType, Visibility } from '@/lib/api'
 import Link from 'next/link'
-import { ArrowLeft, Plus } from 'lucide-react'
+import { ArrowLeft, Plus, Sparkles, Download, Activity, Tags, Trash2 } from 'lucide-react'
 import AuthGuard from '@/components/AuthGuard'
 import ReactFlow, {
   Node,
@@ -30,6 +30,26 @@ function RelationshipsContent() {
     target_character_id: '',
     relation_type: 'friend',
     description: '',
+    visibility: 'public' as Visibility,
+  })
+
+  const [showExtract, setShowExtract] = useState(false)
+  const [extractText, setExtractText] = useState('')
+  const [extracting, setExtracting] = useState(false)
+  const [extracted, setExtracted] = useState<ExtractRelationshipsResponse | null>(null)
+  const [selected, setSelected] = useState<Set<string>>(new Set())
+
+  const [analysis, setAnalysis] = useState<GraphAnalysis | null>(null)
+  const [pathFrom, setPathFrom] = useState('')
+  const [pathTo, setPathTo] = useState('')
+  const [path, setPath] = useState<GraphPath | null>(null)
+
+  const [relationTypes, setRelationTypes] = useState<RelationType[]>([])
+  const [showRelationTypes, setShowRelationTypes] = useState(false)
+  const [relationTypeForm, setRelationTypeForm] = useState({
+    name: '',
+    inverse_name: '',
+    symmetric: false,
   })
 
   const [nodes, setNodes, onNodesChange] = useNodesState([])
@@ -47,12 +67,14 @@ function RelationshipsContent() {
 
   const loadData = async () => {
     try {
-      const [charactersData, relationshipsData] = await Promise.all([
+      const [charactersData, relationshipsData, relationTypesData] = await Promise.all([
         api.characters.list(campaignId),
         api.relationships.list(campaignId),
+        api.relationTypes.list(campaignId),
       ])
       setCharacters(charactersData)
       setRelationships(relationshipsData)
+      setRelationTypes(relationTypesData)
     } catch (error) {
       console.error('Failed to load data:', error)
     } finally {
@@ -82,10 +104,11 @@ function RelationshipsContent() {
       id: rel.id,
       source: rel.source_character_id,
       target: rel.target_character_id,
-      label: rel.relation_type,
+      // GM-only relationships are dashed and only drawn for GMs
+      label: rel.visibility === 'gm' ? `🔒 ${rel.relation_type}` : rel.relation_type,
       type: 'smoothstep',
       animated: true,
-      style: { stroke: '#3b82f6' },
+      style: rel.visibility === 'gm' ? { stroke: '#f43f5e', strokeDasharray: '6 4' } : { stroke: '#3b82f6' },
       labelStyle: { fill: '#fff', fontWeight: 700 },
       labelBgStyle: { fill: '#1e293b' },
     }))
@@ -111,6 +134,7 @@ function RelationshipsContent() {
         target_character_id: '',
         relation_type: 'friend',
         description: '',
+        visibility: 'public',
       })
       setShowCreateForm(false)
       loadData()
@@ -119,6 +143,111 @@ function RelationshipsContent() {
     }
   }
 
+  const handleCreateRelationType = async (e: React.FormEvent) => {
+    e.preventDefault()
+    try {
+      await api.relationTypes.create(campaignId, {
+        name: relationTypeForm.name,
+        inverse_name: relationTypeForm.symmetric ? undefined : relationTypeForm.inverse_name,
+        symmetric: relationTypeForm.symmetric,
+      })
+      setRelationTypeForm({ name: '', inverse_name: '', symmetric: false })
+      setRelationTypes(await api.relationTypes.list(campaignId))
+    } catch (error) {
+      console.error('Failed to create relation type:', error)
+    }
+  }
+
+  const handleDeleteRelationType = async (id: string) => {
+    try {
+      await api.relationTypes.delete(campaignId, id)
+      setRelationTypes(relationTypes.filter((t) => t.id !== id))
+    } catch (error) {
+      console.error('Failed to delete relation type:', error)
+    }
+  }
+
+  const handleExtract = async () => {
+    setExtracting(true)
+    try {
+      const result = await api.relationships.extract(campaignId, extractText)
+      setExtracted(result)
+      setSelected(new Set(result.proposals.map((p) => p.id)))
+    } catch (error) {
+      console.error('Failed to extract relationships:', error)
+      alert('関係性の抽出に失敗しました')
+    } finally {
+      setExtracting(false)
+    }
+  }
+
+  const handleConfirm = async () => {
+    if (!extracted) return
+    try {
+      await api.ai.applyProposals(
+        campaignId,
+        extracted.proposals.filter((p) => selected.has(p.id)),
+        'relationship_extraction'
+      )
+      setExtracted(null)
+      setExtractText('')
+      setShowExtract(false)
+      loadData()
+    } catch (error) {
+      console.error('Failed to apply relationships:', error)
+      alert('関係性の追加に失敗しました')
+    }
+  }
+
+  const handleAnalyze = async () => {
+    if (analysis) {
+      setAnalysis(null)
+      return
+    }
+    try {
+      setAnalysis(await api.relationships.analysis(campaignId))
+    } catch (error) {
+      console.error('Failed to analyze graph:', error)
+      alert('相関図の分析に失敗しました')
+    }
+  }
+
+  const handleFindPath = async () => {
+    if (!pathFrom || !pathTo) return
+    try {
+      setPath(await api.relationships.path(campaignId, pathFrom, pathTo))
+    } catch (error) {
+      console.error('Failed to find path:', error)
+      alert('経路の検索に失敗しました')
+    }
+  }
+
+  const handleExportGraph = async (format: 'graphml' | 'dot' | 'cytoscape') => {
+    try {
+      const blob = await api.campaigns.exportGraph(campaignId, format)
+      const extension = { graphml: 'graphml', dot: 'gv', cytoscape: 'json' }[format]
+      const url = URL.createObjectURL(blob)
+      const link = document.createElement('a')
+      link.href = url
+      link.download = `campaign-${campaignId}.${extension}`
+      link.click()
+      URL.revokeObjectURL(url)
+    } catch (error) {
+      console.error('Failed to export graph:', error)
+      alert('相関図のエクスポートに失敗しました')
+    }
+  }
+
+  const toggleSelected = (id: string) => {
+    const next = new Set(selected)
+    if (next.has(id)) {
+      next.delete(id)
+    } else {
+      next.add(id)
+    }
+    setSelected(next)
+  }
+
   if (loading) {
     return (
       <div className="min-h-screen bg-slate-900 flex items-center justify-center">
@@ -140,15 +269,257 @@ function RelationshipsContent() {
 
         <div className="flex justify-between items-center mb-8">
           <h1 className="text-4xl font-bold text-white">相関図</h1>
-          <button
-            onClick={() => setShowCreateForm(!showCreateForm)}
-            className="flex items-center gap-2 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors"
-          >
-            <Plus size={20} />
-            関係を追加
-          </button>
+          <div className="flex gap-2">
+            <label className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg">
+              <Download size={20} />
+              <select
+                value=""
+                onChange={(e) => handleExportGraph(e.target.value as 'graphml' | 'dot' | 'cytoscape')}
+                className="bg-slate-700 text-white focus:outline-none"
+              >
+                <option value="" disabled>エクスポート</option>
+                <option value="graphml">GraphML (Gephi)</option>
+                <option value="dot">DOT (Graphviz)</option>
+                <option value="cytoscape">Cytoscape JSON</option>
+              </select>
+            </label>
+            <button
+              onClick={() => setShowRelationTypes(!showRelationTypes)}
+              className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors"
+            >
+              <Tags size={20} />
+              関係タイプ
+            </button>
+            <button
+              onClick={handleAnalyze}
+              className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors"
+            >
+              <Activity size={20} />
+              分析
+            </button>
+            <button
+              onClick={() => setShowExtract(!showExtract)}
+              className="flex items-center gap-2 px-4 py-2 bg-purple-600 text-white rounded-lg hover:bg-purple-700 transition-colors"
+            >
+              <Sparkles size={20} />
+              テキストから抽出
+            </button>
+            <button
+              onClick={() => setShowCreateForm(!showCreateForm)}
+              className="flex items-center gap-2 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors"
+            >
+              <Plus size={20} />
+              関係を追加
+            </button>
+          </div>
         </div>
 
+        {showRelationTypes && (
+          <div className="bg-slate-800 p-6 rounded-lg mb-8">
+            <h2 className="text-2xl font-bold text-white mb-4">関係タイプ</h2>
+            <p className="text-slate-400 mb-4">
+              対称（兄弟など）または逆の関係（師匠 ↔ 弟子など）を登録すると、関係を追加・編集・削除したときに逆向きの関係も自動で更新されます
+            </p>
+            <div className="space-y-2 mb-6">
+              {relationTypes.length === 0 && <p className="text-slate-400">登録されていません</p>}
+              {relationTypes.map((t) => (
+                <div key={t.id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+                  <span className="text-white">
+                    {t.symmetric ? `${t.name}（対称）` : `${t.name} ↔ ${t.inverse_name ?? ''}`}
+                  </span>
+                  <button
+                    onClick={() => handleDeleteRelationType(t.id)}
+                    className="text-slate-400 hover:text-red-400 transition-colors"
+                  >
+                    <Trash2 size={18} />
+                  </button>
+                </div>
+              ))}
+            </div>
+            <form onSubmit={handleCreateRelationType} className="flex flex-wrap items-center gap-4">
+              <input
+                type="text"
+                value={relationTypeForm.name}
+                onChange={(e) => setRelationTypeForm({ ...relationTypeForm, name: e.target.value })}
+                placeholder="mentor"
+                className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500"
+                required
+              />
+              <input
+                type="text"
+                value={relationTypeForm.inverse_name}
+                onChange={(e) => setRelationTypeForm({ ...relationTypeForm, inverse_name: e.target.value })}
+                placeholder="apprentice"
+                disabled={relationTypeForm.symmetric}
+                className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-blue-500 disabled:opacity-50"
+              />
+              <label className="flex items-center gap-2 text-slate-300">
+                <input
+                  type="checkbox"
+                  checked={relationTypeForm.symmetric}
+                  onChange={(e) => setRelationTypeForm({ ...relationTypeForm, symmetric: e.target.checked })}
+                />
+                対称
+              </label>
+              <button
+                type="submit"
+                className="px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors"
+              >
+                登録
+              </button>
+            </form>
+          </div>
+        )}
+
+        {showExtract && (
+          <div className="bg-slate-800 p-6 rounded-lg mb-8">
+            <h2 className="text-2xl font-bold text-white mb-4">テキストから関係性を抽出</h2>
+            <p className="text-slate-400 mb-4">
+              「AはBの師匠」のような文章から関係性を抽出します。空欄の場合はキャラクターの背景と世界設定から抽出します
+            </p>
+            <textarea
+              value={extractText}
+              onChange={(e) => setExtractText(e.target.value)}
+              className="w-full px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-purple-500 mb-4"
+              rows={4}
+            />
+            <button
+              onClick={handleExtract}
+              disabled={extracting}
+              className="px-4 py-2 bg-purple-600 text-white rounded-lg hover:bg-purple-700 transition-colors disabled:opacity-50"
+            >
+              {extracting ? '抽出中...' : '抽出'}
+            </button>
+
+            {extracted && (
+              <div className="mt-6 space-y-2">
+                {extracted.proposals.length === 0 && (
+                  <p className="text-slate-400">新しい関係性は見つかりませんでした</p>
+                )}
+                {extracted.proposals.map((proposal) => (
+                  <label key={proposal.id} className="flex items-start gap-3 bg-slate-700 p-3 rounded-lg">
+                    <input
+                      type="checkbox"
+                      checked={selected.has(proposal.id)}
+                      onChange={() => toggleSelected(proposal.id)}
+                      className="mt-1"
+                    />
+                    <div>
+                      <p className="text-white">{proposal.summary}</p>
+                      {proposal.relationship?.description && (
+                        <p className="text-slate-300 text-sm">{proposal.relationship.description}</p>
+                      )}
+                      {proposal.evidence && (
+                        <p className="text-slate-400 text-sm">「{proposal.evidence}」</p>
+                      )}
+                    </div>
+                  </label>
+                ))}
+                {extracted.unresolved.length > 0 && (
+                  <div className="text-slate-400 text-sm">
+                    <p className="mt-4">キャラクターが見つからなかった関係性:</p>
+                    {extracted.unresolved.map((r, index) => (
+                      <p key={index}>
+                        {r.source} → {r.target}: {r.relation_type}
+                      </p>
+                    ))}
+                  </div>
+                )}
+                {extracted.proposals.length > 0 && (
+                  <button
+                    onClick={handleConfirm}
+                    disabled={selected.size === 0}
+                    className="mt-4 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors disabled:opacity-50"
+                  >
+                    選択した関係を追加
+                  </button>
+                )}
+              </div>
+            )}
+          </div>
+        )}
+
+        {analysis && (
+          <div className="bg-slate-800 p-6 rounded-lg mb-8 space-y-6">
+            <h2 className="text-2xl font-bold text-white">相関図の分析</h2>
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-6">
+              <div>
+                <h3 className="text-lg font-bold text-white mb-2">中心人物</h3>
+                {analysis.centrality.slice(0, 5).map((c) => (
+                  <p key={c.character_id} className="text-slate-300">
+                    {c.name}
+                    <span className="text-slate-400 text-sm ml-2">
+                      関係 {c.degree}人・媒介 {(c.betweenness * 100).toFixed(0)}%
+                    </span>
+                  </p>
+                ))}
+              </div>
+              <div>
+                <h3 className="text-lg font-bold text-white mb-2">孤立しているキャラクター</h3>
+                {analysis.isolated.length === 0 ? (
+                  <p className="text-slate-400">なし</p>
+                ) : (
+                  <p className="text-slate-300">{analysis.isolated.map((c) => c.name).join('、')}</p>
+                )}
+              </div>
+              <div>
+                <h3 className="text-lg font-bold text-white mb-2">グループ</h3>
+                {analysis.communities.length === 0 && <p className="text-slate-400">なし</p>}
+                {analysis.communities.map((group, index) => (
+                  <p key={index} className="text-slate-300">
+                    {group.characters.map((c) => c.name).join('、')}
+                  </p>
+                ))}
+              </div>
+              <div>
+                <h3 className="text-lg font-bold text-white mb-2">つながりを探す</h3>
+                <div className="flex gap-2 mb-2">
+                  <select
+                    value={pathFrom}
+                    onChange={(e) => setPathFrom(e.target.value)}
+                    className="flex-1 px-2 py-1 bg-slate-700 text-white rounded-lg"
+                  >
+                    <option value="">選択</option>
+                    {characters.map((c) => (
+                      <option key={c.id} value={c.id}>{c.name}</option>
+                    ))}
+                  </select>
+                  <select
+                    value={pathTo}
+                    onChange={(e) => setPathTo(e.target.value)}
+                    className="flex-1 px-2 py-1 bg-slate-700 text-white rounded-lg"
+                  >
+                    <option value="">選択</option>
+                    {characters.map((c) => (
+                      <option key={c.id} value={c.id}>{c.name}</option>
+                    ))}
+                  </select>
+                  <button
+                    onClick={handleFindPath}
+                    disabled={!pathFrom || !pathTo}
+                    className="px-3 py-1 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors disabled:opacity-50"
+                  >
+                    検索
+                  </button>
+                </div>
+                {path && !path.found && <p className="text-slate-400">つながりはありません</p>}
+                {path?.found && (
+                  <p className="text-slate-300">
+                    {path.characters.map((c, index) => (
+                      <span key={c.id}>
+                        {index > 0 && (
+                          <span className="text-slate-400"> —{path.relationships[index - 1].relation_type}— </span>
+                        )}
+                        {c.name}
+                      </span>
+                    ))}
+                  </p>
+                )}
+              </div>
+            </div>
+          </div>
+        )}
+
         {showCreateForm && (
           <form onSubmit={handleCreate} className="bg-slate-800 p-6 rounded-lg mb-8">
             <h2 className="text-2xl font-bold text-white mb-4">新しい関係</h2>
@@ -217,6 +588,14 @@ function RelationshipsContent() {
                   rows={3}
                 />
               </div>
+              <label className="flex items-center gap-2 text-slate-300">
+                <input
+                  type="checkbox"
+                  checked={formData.visibility === 'gm'}
+                  onChange={(e) => setFormData({ ...formData, visibility: e.target.checked ? 'gm' : 'public' })}
+                />
+                GMのみに公開（プレイヤー・閲覧者には表示しない）
+              </label>
               <div className="flex gap-2">
                 <button
                   type="submit"
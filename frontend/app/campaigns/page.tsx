@@ -1,10 +1,11 @@
 'use client'
 
 import { useEffect, useState } from 'react'
-import { api, Campaign } from '@/lib/api'
+import { api, Campaign, Invitation } from '@/lib/api'
 import Link from 'next/link'
-import { Plus } from 'lucide-react'
+import { Plus, RotateCcw, Trash2, Upload, UserPlus } from 'lucide-react'
 import AuthGuard from '@/components/AuthGuard'
+import { roleLabels } from '@/components/CampaignSharing'
 
 function CampaignsContent() {
   const [campaigns, setCampaigns] = useState<Campaign[]>([])
@@ -12,6 +13,9 @@ function CampaignsContent() {
   const [showCreateForm, setShowCreateForm] = useState(false)
   const [title, setTitle] = useState('')
   const [description, setDescription] = useState('')
+  const [invitations, setInvitations] = useState<Invitation[]>([])
+  const [code, setCode] = useState('')
+  const [trashed, setTrashed] = useState<Campaign[]>([])
 
   useEffect(() => {
     loadCampaigns()
@@ -19,8 +23,14 @@ function CampaignsContent() {
 
   const loadCampaigns = async () => {
     try {
-      const data = await api.campaigns.list()
+      const [data, invitationData, trashedData] = await Promise.all([
+        api.campaigns.list(),
+        api.invitations.mine(),
+        api.trash.campaigns(),
+      ])
       setCampaigns(data)
+      setInvitations(invitationData)
+      setTrashed(trashedData)
     } catch (error) {
       console.error('Failed to load campaigns:', error)
     } finally {
@@ -41,6 +51,54 @@ function CampaignsContent() {
     }
   }
 
+  const handleRestore = async (campaign: Campaign) => {
+    try {
+      await api.trash.restoreCampaign(campaign.id)
+      loadCampaigns()
+    } catch (error) {
+      console.error('Failed to restore campaign:', error)
+    }
+  }
+
+  const handlePurge = async (campaign: Campaign) => {
+    if (!confirm(`「${campaign.title}」を完全に削除しますか？キャラクター・関係性・世界設定もすべて削除され、取り消せません`)) return
+    try {
+      await api.trash.purgeCampaign(campaign.id)
+      loadCampaigns()
+    } catch (error) {
+      console.error('Failed to purge campaign:', error)
+    }
+  }
+
+  const handleAccept = async (invitationCode: string) => {
+    try {
+      await api.invitations.accept(invitationCode)
+      setCode('')
+      loadCampaigns()
+    } catch (error) {
+      console.error('Failed to accept invitation:', error)
+      alert('招待コードが無効か、有効期限が切れています')
+    }
+  }
+
+  const handleImport = async (e: React.ChangeEvent<HTMLInputElement>) => {
+    const file = e.target.files?.[0]
+    e.target.value = ''
+    if (!file) return
+    try {
+      if (file.name.toLowerCase().endsWith('.zip')) {
+        // Markdown / Obsidian vault
+        await api.campaigns.importMarkdown(file)
+      } else {
+        await api.campaigns.import(JSON.parse(await file.text()))
+      }
+      loadCampaigns()
+    } catch (error) {
+      console.error('Failed to import campaign:', error)
+      alert('インポートに失敗しました。ファイル形式を確認してください。')
+    }
+  }
+
   if (loading) {
     return (
       <div className="min-h-screen bg-slate-900 flex items-center justify-center">
@@ -54,13 +112,20 @@ function CampaignsContent() {
       <div className="max-w-6xl mx-auto">
         <div className="flex justify-between items-center mb-8">
           <h1 className="text-4xl font-bold text-white">キャンペーン一覧</h1>
-          <button
-            onClick={() => setShowCreateForm(!showCreateForm)}
-            className="flex items-center gap-2 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors"
-          >
-            <Plus size={20} />
-            新規作成
-          </button>
+          <div className="flex gap-2">
+            <label className="flex items-center gap-2 px-4 py-2 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors cursor-pointer">
+              <Upload size={20} />
+              インポート
+              <input type="file" accept="application/json,.json,application/zip,.zip" onChange={handleImport} className="hidden" />
+            </label>
+            <button
+              onClick={() => setShowCreateForm(!showCreateForm)}
+              className="flex items-center gap-2 px-4 py-2 bg-blue-600 text-white rounded-lg hover:bg-blue-700 transition-colors"
+            >
+              <Plus size={20} />
+              新規作成
+            </button>
+          </div>
         </div>
 
         {showCreateForm && (
@@ -105,6 +170,49 @@ function CampaignsContent() {
           </form>
         )}
 
+        <div className="bg-slate-800 p-6 rounded-lg mb-8">
+          <form
+            onSubmit={(e) => {
+              e.preventDefault()
+              handleAccept(code)
+            }}
+            className="flex items-center gap-4"
+          >
+            <input
+              type="text"
+              value={code}
+              onChange={(e) => setCode(e.target.value)}
+              placeholder="招待コード"
+              className="flex-1 px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-cyan-500"
+            />
+            <button
+              type="submit"
+              disabled={!code.trim()}
+              className="flex items-center gap-2 px-4 py-2 bg-cyan-600 text-white rounded-lg hover:bg-cyan-700 transition-colors disabled:opacity-50"
+            >
+              <UserPlus size={20} />
+              参加
+            </button>
+          </form>
+          {invitations.length > 0 && (
+            <div className="mt-4 space-y-2">
+              {invitations.map((invitation) => (
+                <div key={invitation.id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+                  <span className="text-white">
+                    {invitation.campaign_title || 'キャンペーン'}に{roleLabels[invitation.role]}として招待されています
+                  </span>
+                  <button
+                    onClick={() => handleAccept(invitation.code)}
+                    className="px-3 py-1 bg-cyan-600 text-white rounded-lg hover:bg-cyan-700 transition-colors"
+                  >
+                    参加する
+                  </button>
+                </div>
+              ))}
+            </div>
+          )}
+        </div>
+
         <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-3 gap-6">
           {campaigns.map((campaign) => (
             <Link
@@ -113,6 +221,9 @@ function CampaignsContent() {
               className="bg-slate-800 p-6 rounded-lg hover:bg-slate-700 transition-colors"
             >
               <h3 className="text-xl font-bold text-white mb-2">{campaign.title}</h3>
+              {campaign.role && campaign.role !== 'owner' && (
+                <p className="text-cyan-400 text-sm mb-2">{roleLabels[campaign.role]}</p>
+              )}
               {campaign.description && (
                 <p className="text-slate-400 line-clamp-3">{campaign.description}</p>
               )}
@@ -129,6 +240,46 @@ function CampaignsContent() {
             <p className="mt-2">「新規作成」ボタンから最初のキャンペーンを作成しましょう</p>
           </div>
         )}
+
+        {trashed.length > 0 && (
+          <div className="bg-slate-800 p-6 rounded-lg mt-8">
+            <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+              <Trash2 size={24} className="text-red-400" />
+              ゴミ箱
+            </h2>
+            <div className="space-y-2">
+              {trashed.map((campaign) => (
+                <div key={campaign.id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+                  <span className="text-white">
+                    {campaign.title}
+                    {campaign.deleted_at && (
+                      <span className="text-slate-500 text-sm">
+                        {' '}
+                        {new Date(campaign.deleted_at).toLocaleString('ja-JP')}に削除
+                      </span>
+                    )}
+                  </span>
+                  <div className="flex items-center gap-3">
+                    <button
+                      onClick={() => handleRestore(campaign)}
+                      className="text-slate-400 hover:text-cyan-400 transition-colors"
+                      title="復元"
+                    >
+                      <RotateCcw size={18} />
+                    </button>
+                    <button
+                      onClick={() => handlePurge(campaign)}
+                      className="text-slate-400 hover:text-red-400 transition-colors"
+                      title="完全に削除"
+                    >
+                      <Trash2 size={18} />
+                    </button>
+                  </div>
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
       </div>
     </div>
   )
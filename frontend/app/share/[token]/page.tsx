@@ -0,0 +1,152 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { useParams } from 'next/navigation'
+import { api, Character, LoreEntry, Relationship, SharedCampaign } from '@/lib/api'
+import { BookOpen, Network, Users } from 'lucide-react'
+
+// Read-only campaign wiki opened from a share link. It needs no login, so it
+// is not wrapped in AuthGuard.
+export default function SharedCampaignPage() {
+  const params = useParams()
+  const token = params.token as string
+  const [campaign, setCampaign] = useState<SharedCampaign | null>(null)
+  const [characters, setCharacters] = useState<Character[]>([])
+  const [relationships, setRelationships] = useState<Relationship[]>([])
+  const [loreEntries, setLoreEntries] = useState<LoreEntry[]>([])
+  const [loading, setLoading] = useState(true)
+
+  useEffect(() => {
+    loadData()
+  }, [token])
+
+  const loadData = async () => {
+    try {
+      const [campaignData, charactersData, relationshipsData, loreData] = await Promise.all([
+        api.shared.campaign(token),
+        api.shared.characters(token),
+        api.shared.relationships(token),
+        api.shared.loreEntries(token),
+      ])
+      setCampaign(campaignData)
+      setCharacters(charactersData)
+      setRelationships(relationshipsData)
+      setLoreEntries(loreData)
+    } catch (error) {
+      console.error('Failed to load shared campaign:', error)
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const characterName = (id: string) => characters.find((c) => c.id === id)?.name ?? '不明'
+
+  const loreByCategory = loreEntries.reduce<Record<string, LoreEntry[]>>((groups, entry) => {
+    const category = entry.category || 'その他'
+    groups[category] = [...(groups[category] ?? []), entry]
+    return groups
+  }, {})
+
+  if (loading) {
+    return (
+      <div className="min-h-screen bg-slate-900 flex items-center justify-center">
+        <div className="text-white text-xl">読み込み中...</div>
+      </div>
+    )
+  }
+
+  if (!campaign) {
+    return (
+      <div className="min-h-screen bg-slate-900 flex items-center justify-center">
+        <div className="text-white text-xl">共有リンクが無効か、期限が切れています</div>
+      </div>
+    )
+  }
+
+  return (
+    <div className="min-h-screen bg-slate-900 p-8">
+      <div className="max-w-6xl mx-auto">
+        <div className="bg-slate-800 p-8 rounded-lg mb-8">
+          <h1 className="text-4xl font-bold text-white mb-2">{campaign.title}</h1>
+          {campaign.description && <p className="text-slate-300">{campaign.description}</p>}
+          <p className="text-slate-500 text-sm mt-4">
+            閲覧専用の共有ページです
+            {campaign.expires_at && `（${new Date(campaign.expires_at).toLocaleDateString('ja-JP')}まで）`}
+          </p>
+        </div>
+
+        <section className="mb-8">
+          <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+            <Users size={24} className="text-blue-400" />
+            キャラクター
+          </h2>
+          <div className="grid grid-cols-1 md:grid-cols-2 gap-4">
+            {characters.map((character) => (
+              <div key={character.id} id={character.id} className="bg-slate-800 p-6 rounded-lg">
+                <h3 className="text-xl font-bold text-white">{character.name}</h3>
+                <p className="text-slate-400 mb-2">{character.role}</p>
+                {Object.keys(character.attributes ?? {}).length > 0 && (
+                  <dl className="text-sm text-slate-300 mb-2">
+                    {Object.entries(character.attributes).map(([key, value]) => (
+                      <div key={key}>
+                        <dt className="inline text-slate-500">{key}: </dt>
+                        <dd className="inline">{typeof value === 'object' ? JSON.stringify(value) : String(value)}</dd>
+                      </div>
+                    ))}
+                  </dl>
+                )}
+                {character.background && (
+                  <p className="text-slate-300 whitespace-pre-wrap">{character.background}</p>
+                )}
+              </div>
+            ))}
+          </div>
+        </section>
+
+        {relationships.length > 0 && (
+          <section className="mb-8">
+            <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+              <Network size={24} className="text-purple-400" />
+              関係性
+            </h2>
+            <div className="bg-slate-800 p-6 rounded-lg space-y-2">
+              {relationships.map((relationship) => (
+                <p key={relationship.id} className="text-slate-300">
+                  <a href={`#${relationship.source_character_id}`} className="text-white hover:underline">
+                    {characterName(relationship.source_character_id)}
+                  </a>
+                  {' → '}
+                  <a href={`#${relationship.target_character_id}`} className="text-white hover:underline">
+                    {characterName(relationship.target_character_id)}
+                  </a>
+                  <span className="text-purple-300">（{relationship.relation_type}）</span>
+                  {relationship.description && <span className="text-slate-400"> {relationship.description}</span>}
+                </p>
+              ))}
+            </div>
+          </section>
+        )}
+
+        <section>
+          <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+            <BookOpen size={24} className="text-green-400" />
+            世界設定
+          </h2>
+          {Object.entries(loreByCategory).map(([category, entries]) => (
+            <div key={category} className="mb-6">
+              <h3 className="text-lg font-bold text-green-300 mb-2">{category}</h3>
+              <div className="space-y-4">
+                {entries.map((entry) => (
+                  <div key={entry.id} className="bg-slate-800 p-6 rounded-lg">
+                    <h4 className="text-xl font-bold text-white mb-2">{entry.title}</h4>
+                    <p className="text-slate-300 whitespace-pre-wrap">{entry.content}</p>
+                  </div>
+                ))}
+              </div>
+            </div>
+          ))}
+        </section>
+      </div>
+    </div>
+  )
+}
@@ -0,0 +1,204 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { useRouter } from 'next/navigation'
+import { api, Campaign, CampaignMember, CampaignRole, Invitation } from '@/lib/api'
+import { supabase } from '@/lib/supabase'
+import { Share2, Trash2 } from 'lucide-react'
+import ShareLinks from './ShareLinks'
+
+export const roleLabels: Record<CampaignRole, string> = {
+  owner: 'オーナー',
+  editor: '編集者（共同GM）',
+  player: 'プレイヤー',
+  viewer: '閲覧者',
+}
+
+const memberRoles: Invitation['role'][] = ['editor', 'player', 'viewer']
+
+export default function CampaignSharing({ campaign }: { campaign: Campaign }) {
+  const router = useRouter()
+  const isOwner = campaign.role === 'owner'
+  const [userId, setUserId] = useState<string>()
+  const [members, setMembers] = useState<CampaignMember[]>([])
+  const [invitations, setInvitations] = useState<Invitation[]>([])
+  const [email, setEmail] = useState('')
+  const [role, setRole] = useState<Invitation['role']>('player')
+
+  useEffect(() => {
+    supabase.auth.getSession().then(({ data: { session } }) => setUserId(session?.user.id))
+  }, [])
+
+  useEffect(() => {
+    loadData()
+  }, [campaign.id])
+
+  const loadData = async () => {
+    try {
+      setMembers(await api.members.list(campaign.id))
+      if (isOwner) {
+        setInvitations(await api.invitations.list(campaign.id))
+      }
+    } catch (error) {
+      console.error('Failed to load members:', error)
+    }
+  }
+
+  const handleInvite = async (e: React.FormEvent) => {
+    e.preventDefault()
+    try {
+      await api.invitations.create(campaign.id, { email: email || undefined, role })
+      setEmail('')
+      loadData()
+    } catch (error) {
+      console.error('Failed to create invitation:', error)
+      alert('招待の作成に失敗しました')
+    }
+  }
+
+  const handleChangeRole = async (member: CampaignMember, next: Invitation['role']) => {
+    try {
+      await api.members.update(campaign.id, member.user_id, next)
+      loadData()
+    } catch (error) {
+      console.error('Failed to update member:', error)
+    }
+  }
+
+  const handleRemove = async (member: CampaignMember) => {
+    const leaving = member.user_id === userId
+    if (!confirm(leaving ? 'このキャンペーンから抜けますか？' : 'このメンバーを削除しますか？')) return
+    try {
+      await api.members.remove(campaign.id, member.user_id)
+      if (leaving) {
+        router.push('/campaigns')
+      } else {
+        loadData()
+      }
+    } catch (error) {
+      console.error('Failed to remove member:', error)
+    }
+  }
+
+  const handleRevoke = async (invitation: Invitation) => {
+    try {
+      await api.invitations.delete(campaign.id, invitation.id)
+      loadData()
+    } catch (error) {
+      console.error('Failed to revoke invitation:', error)
+    }
+  }
+
+  const handleTrash = async () => {
+    if (!confirm('このキャンペーンをゴミ箱に移動しますか？メンバーは閲覧できなくなります')) return
+    try {
+      await api.campaigns.delete(campaign.id)
+      router.push('/campaigns')
+    } catch (error) {
+      console.error('Failed to delete campaign:', error)
+    }
+  }
+
+  return (
+    <div className="bg-slate-800 p-6 rounded-lg mt-8">
+      <h2 className="text-2xl font-bold text-white mb-4 flex items-center gap-2">
+        <Share2 size={24} className="text-cyan-400" />
+        共有
+      </h2>
+
+      <div className="space-y-2 mb-6">
+        {members.map((member) => (
+          <div key={member.user_id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+            <span className="text-white">
+              {member.email || member.user_id}
+              {member.user_id === userId && <span className="text-slate-400">（あなた）</span>}
+            </span>
+            <div className="flex items-center gap-3">
+              {isOwner && member.role !== 'owner' ? (
+                <select
+                  value={member.role}
+                  onChange={(e) => handleChangeRole(member, e.target.value as Invitation['role'])}
+                  className="px-2 py-1 bg-slate-600 text-white rounded-lg focus:outline-none"
+                >
+                  {memberRoles.map((r) => (
+                    <option key={r} value={r}>{roleLabels[r]}</option>
+                  ))}
+                </select>
+              ) : (
+                <span className="text-slate-300">{roleLabels[member.role]}</span>
+              )}
+              {member.role !== 'owner' && (isOwner || member.user_id === userId) && (
+                <button
+                  onClick={() => handleRemove(member)}
+                  className="text-slate-400 hover:text-red-400 transition-colors"
+                >
+                  <Trash2 size={18} />
+                </button>
+              )}
+            </div>
+          </div>
+        ))}
+      </div>
+
+      {isOwner && (
+        <>
+          <h3 className="text-lg font-bold text-white mb-2">招待</h3>
+          <p className="text-slate-400 mb-4">
+            招待コードを相手に伝えてください。メールアドレスを指定すると、そのアドレスでログインしたユーザーだけが参加できます（有効期限7日・1回限り）
+          </p>
+          <form onSubmit={handleInvite} className="flex flex-wrap items-center gap-4 mb-4">
+            <input
+              type="email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              placeholder="メールアドレス（任意）"
+              className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-cyan-500"
+            />
+            <select
+              value={role}
+              onChange={(e) => setRole(e.target.value as Invitation['role'])}
+              className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none"
+            >
+              {memberRoles.map((r) => (
+                <option key={r} value={r}>{roleLabels[r]}</option>
+              ))}
+            </select>
+            <button
+              type="submit"
+              className="px-4 py-2 bg-cyan-600 text-white rounded-lg hover:bg-cyan-700 transition-colors"
+            >
+              招待を作成
+            </button>
+          </form>
+          <div className="space-y-2">
+            {invitations.map((invitation) => (
+              <div key={invitation.id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+                <span className="text-white">
+                  <code className="text-cyan-300 mr-3">{invitation.code}</code>
+                  {roleLabels[invitation.role]}
+                  {invitation.email && <span className="text-slate-400"> ・ {invitation.email}</span>}
+                  <span className="text-slate-500 text-sm">
+                    {' '}〜{new Date(invitation.expires_at).toLocaleDateString('ja-JP')}
+                  </span>
+                </span>
+                <button
+                  onClick={() => handleRevoke(invitation)}
+                  className="text-slate-400 hover:text-red-400 transition-colors"
+                >
+                  <Trash2 size={18} />
+                </button>
+              </div>
+            ))}
+          </div>
+          <ShareLinks campaignId={campaign.id} />
+          <button
+            onClick={handleTrash}
+            className="mt-6 px-4 py-2 bg-red-600 text-white rounded-lg hover:bg-red-700 transition-colors"
+          >
+            キャンペーンをゴミ箱に移動
+          </button>
+        </>
+      )}
+    </div>
+  )
+}
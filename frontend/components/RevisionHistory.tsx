@@ -0,0 +1,136 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { api, Revision, RevisionDiff } from '@/lib/api'
+import { History, RotateCcw } from 'lucide-react'
+
+// Revision list of a character or lore entry, with a diff between any two
+// revisions and restoring an earlier one. Only GMs can read revisions.
+export default function RevisionHistory({
+  entity,
+  id,
+  onRestored,
+}: {
+  entity: 'characters' | 'lore-entries'
+  id: string
+  onRestored: () => void
+}) {
+  const [revisions, setRevisions] = useState<Revision[]>([])
+  const [from, setFrom] = useState('')
+  const [to, setTo] = useState('')
+  const [diff, setDiff] = useState<RevisionDiff | null>(null)
+
+  useEffect(() => {
+    loadRevisions()
+  }, [entity, id])
+
+  const loadRevisions = async () => {
+    try {
+      const data = await api.revisions.list(entity, id)
+      setRevisions(data)
+      // Compare the latest revision with the one before it by default
+      if (data.length >= 2) {
+        setFrom(data[data.length - 2].id)
+        setTo(data[data.length - 1].id)
+      }
+    } catch (error) {
+      console.error('Failed to load revisions:', error)
+    }
+  }
+
+  const handleDiff = async () => {
+    try {
+      setDiff(await api.revisions.diff(entity, id, from, to))
+    } catch (error) {
+      console.error('Failed to diff revisions:', error)
+    }
+  }
+
+  const handleRestore = async (revision: Revision) => {
+    if (!confirm(`版 ${revision.number} の内容に戻しますか？（現在の内容も履歴に残ります）`)) return
+    try {
+      await api.revisions.restore(entity, id, revision.id)
+      setDiff(null)
+      loadRevisions()
+      onRestored()
+    } catch (error) {
+      console.error('Failed to restore revision:', error)
+      alert('復元に失敗しました')
+    }
+  }
+
+  const diffLineClass = (line: string) => {
+    if (line.startsWith('+++') || line.startsWith('---')) return 'text-slate-500'
+    if (line.startsWith('@@')) return 'text-cyan-400'
+    if (line.startsWith('+')) return 'text-green-400'
+    if (line.startsWith('-')) return 'text-red-400'
+    return 'text-slate-300'
+  }
+
+  if (revisions.length === 0) {
+    return <p className="text-slate-500 text-sm mt-4">変更履歴はまだありません</p>
+  }
+
+  return (
+    <div className="mt-4 border-t border-slate-700 pt-4">
+      <h4 className="text-lg font-bold text-white mb-2 flex items-center gap-2">
+        <History size={18} className="text-slate-400" />
+        変更履歴
+      </h4>
+      <div className="space-y-1 mb-4">
+        {revisions.map((revision, index) => (
+          <div key={revision.id} className="flex justify-between items-center text-sm">
+            <span className="text-slate-300">
+              <span className="text-slate-500 mr-2">版 {revision.number}</span>
+              {revision.summary}
+              <span className="text-slate-500"> ・ {new Date(revision.created_at).toLocaleString('ja-JP')}</span>
+            </span>
+            {index < revisions.length - 1 && (
+              <button
+                onClick={() => handleRestore(revision)}
+                className="flex items-center gap-1 text-slate-400 hover:text-white transition-colors"
+              >
+                <RotateCcw size={14} />
+                この版に戻す
+              </button>
+            )}
+          </div>
+        ))}
+      </div>
+      {revisions.length >= 2 && (
+        <div className="flex flex-wrap items-center gap-2 text-sm">
+          {[
+            { value: from, set: setFrom },
+            { value: to, set: setTo },
+          ].map(({ value, set }, i) => (
+            <select
+              key={i}
+              value={value}
+              onChange={(e) => set(e.target.value)}
+              className="px-2 py-1 bg-slate-700 text-white rounded-lg focus:outline-none"
+            >
+              {revisions.map((revision) => (
+                <option key={revision.id} value={revision.id}>版 {revision.number}</option>
+              ))}
+            </select>
+          ))}
+          <button
+            onClick={handleDiff}
+            className="px-3 py-1 bg-slate-700 text-white rounded-lg hover:bg-slate-600 transition-colors"
+          >
+            差分を表示
+          </button>
+        </div>
+      )}
+      {diff && (
+        <pre className="mt-4 p-4 bg-slate-900 rounded-lg text-sm overflow-x-auto">
+          {diff.diff === ''
+            ? <span className="text-slate-500">差分はありません</span>
+            : diff.diff.split('\n').map((line, i) => (
+                <div key={i} className={diffLineClass(line)}>{line}</div>
+              ))}
+        </pre>
+      )}
+    </div>
+  )
+}
@@ -0,0 +1,133 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { api, ShareLink } from '@/lib/api'
+import { Copy, Trash2 } from 'lucide-react'
+
+export default function ShareLinks({ campaignId }: { campaignId: string }) {
+  const [links, setLinks] = useState<ShareLink[]>([])
+  const [label, setLabel] = useState('')
+  const [categories, setCategories] = useState('')
+  const [expiresOn, setExpiresOn] = useState('')
+
+  useEffect(() => {
+    loadLinks()
+  }, [campaignId])
+
+  const loadLinks = async () => {
+    try {
+      setLinks(await api.shareLinks.list(campaignId))
+    } catch (error) {
+      console.error('Failed to load share links:', error)
+    }
+  }
+
+  const shareURL = (link: ShareLink) => `${window.location.origin}/share/${link.token}`
+
+  const handleCreate = async (e: React.FormEvent) => {
+    e.preventDefault()
+    try {
+      await api.shareLinks.create(campaignId, {
+        label: label || undefined,
+        categories: categories.split(',').map((c) => c.trim()).filter(Boolean),
+        // The link works through the end of the chosen day
+        expires_at: expiresOn ? new Date(`${expiresOn}T23:59:59`).toISOString() : undefined,
+      })
+      setLabel('')
+      setCategories('')
+      setExpiresOn('')
+      loadLinks()
+    } catch (error) {
+      console.error('Failed to create share link:', error)
+      alert('共有リンクの作成に失敗しました')
+    }
+  }
+
+  const handleDelete = async (link: ShareLink) => {
+    if (!confirm('この共有リンクを無効にしますか？')) return
+    try {
+      await api.shareLinks.delete(campaignId, link.id)
+      loadLinks()
+    } catch (error) {
+      console.error('Failed to delete share link:', error)
+    }
+  }
+
+  return (
+    <>
+      <h3 className="text-lg font-bold text-white mt-6 mb-2">共有リンク</h3>
+      <p className="text-slate-400 mb-4">
+        リンクを知っていれば、ログインせずに公開範囲が「GMのみ」でないキャラクター・関係性・世界設定を閲覧できます。カテゴリを指定すると、世界設定はそのカテゴリのものだけになります
+      </p>
+      <form onSubmit={handleCreate} className="flex flex-wrap items-center gap-4 mb-4">
+        <input
+          type="text"
+          value={label}
+          onChange={(e) => setLabel(e.target.value)}
+          placeholder="名前（任意）"
+          className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-cyan-500"
+        />
+        <input
+          type="text"
+          value={categories}
+          onChange={(e) => setCategories(e.target.value)}
+          placeholder="カテゴリ（カンマ区切り・任意）"
+          className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none focus:ring-2 focus:ring-cyan-500"
+        />
+        <label className="flex items-center gap-2 text-slate-300">
+          有効期限
+          <input
+            type="date"
+            value={expiresOn}
+            onChange={(e) => setExpiresOn(e.target.value)}
+            className="px-4 py-2 bg-slate-700 text-white rounded-lg focus:outline-none"
+          />
+        </label>
+        <button
+          type="submit"
+          className="px-4 py-2 bg-cyan-600 text-white rounded-lg hover:bg-cyan-700 transition-colors"
+        >
+          リンクを作成
+        </button>
+      </form>
+      <div className="space-y-2">
+        {links.map((link) => {
+          const expired = link.expires_at !== undefined && new Date(link.expires_at) <= new Date()
+          return (
+            <div key={link.id} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+              <span className={expired ? 'text-slate-500' : 'text-white'}>
+                {link.label || '共有リンク'}
+                {link.categories.length > 0 && (
+                  <span className="text-slate-400"> ・ {link.categories.join('、')}</span>
+                )}
+                <span className="text-slate-500 text-sm">
+                  {' '}
+                  {link.expires_at
+                    ? `〜${new Date(link.expires_at).toLocaleDateString('ja-JP')}${expired ? '（期限切れ）' : ''}`
+                    : '無期限'}
+                </span>
+              </span>
+              <div className="flex items-center gap-3">
+                {!expired && (
+                  <button
+                    onClick={() => navigator.clipboard.writeText(shareURL(link))}
+                    className="text-slate-400 hover:text-cyan-400 transition-colors"
+                    title="リンクをコピー"
+                  >
+                    <Copy size={18} />
+                  </button>
+                )}
+                <button
+                  onClick={() => handleDelete(link)}
+                  className="text-slate-400 hover:text-red-400 transition-colors"
+                >
+                  <Trash2 size={18} />
+                </button>
+              </div>
+            </div>
+          )
+        })}
+      </div>
+    </>
+  )
+}
@@ -0,0 +1,144 @@
+'use client'
+
+import { useEffect, useState } from 'react'
+import { api, Character, Trash as TrashContents } from '@/lib/api'
+import { RotateCcw, Trash2 } from 'lucide-react'
+
+type TrashType = 'characters' | 'relationships' | 'lore-entries'
+
+interface TrashItem {
+  type: TrashType
+  id: string
+  label: string
+  deleted_at: string
+}
+
+const typeLabels: Record<TrashType, string> = {
+  characters: 'キャラクター',
+  relationships: '関係性',
+  'lore-entries': '世界設定',
+}
+
+export default function Trash({
+  campaignId,
+  characters,
+  onRestore,
+}: {
+  campaignId: string
+  characters: Character[]
+  onRestore?: () => void
+}) {
+  const [trash, setTrash] = useState<TrashContents | null>(null)
+
+  useEffect(() => {
+    loadTrash()
+  }, [campaignId])
+
+  const loadTrash = async () => {
+    try {
+      setTrash(await api.trash.get(campaignId))
+    } catch (error) {
+      console.error('Failed to load trash:', error)
+    }
+  }
+
+  if (!trash) return null
+
+  // Relationships name their characters, which may be in the trash too
+  const names = new Map([...characters, ...trash.characters].map((c) => [c.id, c.name]))
+  const items: TrashItem[] = [
+    ...trash.characters.map((c) => ({ type: 'characters' as const, id: c.id, label: c.name, deleted_at: c.deleted_at! })),
+    ...trash.relationships.map((r) => ({
+      type: 'relationships' as const,
+      id: r.id,
+      label: `${names.get(r.source_character_id) ?? '?'} → ${names.get(r.target_character_id) ?? '?'}（${r.relation_type}）`,
+      deleted_at: r.deleted_at!,
+    })),
+    ...trash.lore_entries.map((e) => ({ type: 'lore-entries' as const, id: e.id, label: e.title, deleted_at: e.deleted_at! })),
+  ].sort((a, b) => b.deleted_at.localeCompare(a.deleted_at))
+
+  const handleRestore = async (item: TrashItem) => {
+    try {
+      await api.trash.restore(campaignId, item.type, item.id)
+      loadTrash()
+      onRestore?.()
+    } catch (error) {
+      console.error('Failed to restore:', error)
+      alert('復元できませんでした。関係性は、両方のキャラクターを先に復元してください')
+    }
+  }
+
+  const handlePurge = async (item: TrashItem) => {
+    if (!confirm('完全に削除しますか？この操作は取り消せません')) return
+    try {
+      await api.trash.purge(campaignId, item.type, item.id)
+      loadTrash()
+    } catch (error) {
+      console.error('Failed to purge:', error)
+    }
+  }
+
+  const handleEmpty = async () => {
+    if (!confirm('ゴミ箱を空にしますか？この操作は取り消せません')) return
+    try {
+      await api.trash.empty(campaignId)
+      loadTrash()
+    } catch (error) {
+      console.error('Failed to empty trash:', error)
+    }
+  }
+
+  return (
+    <div className="bg-slate-800 p-6 rounded-lg mt-8">
+      <div className="flex justify-between items-center mb-4">
+        <h2 className="text-2xl font-bold text-white flex items-center gap-2">
+          <Trash2 size={24} className="text-red-400" />
+          ゴミ箱
+        </h2>
+        {items.length > 0 && (
+          <button
+            onClick={handleEmpty}
+            className="px-4 py-2 bg-red-600 text-white rounded-lg hover:bg-red-700 transition-colors"
+          >
+            ゴミ箱を空にする
+          </button>
+        )}
+      </div>
+      <p className="text-slate-400 mb-4">
+        削除したキャラクター・関係性・世界設定は
+        {trash.retention_days > 0 ? `${trash.retention_days}日間` : '完全に削除するまで'}
+        ここに残り、復元できます。キャラクターを復元すると、一緒に削除された関係性も戻ります
+      </p>
+      {items.length === 0 && <p className="text-slate-500">ゴミ箱は空です</p>}
+      <div className="space-y-2">
+        {items.map((item) => (
+          <div key={`${item.type}/${item.id}`} className="flex justify-between items-center bg-slate-700 p-3 rounded-lg">
+            <span className="text-white">
+              <span className="text-slate-400">{typeLabels[item.type]}</span> {item.label}
+              <span className="text-slate-500 text-sm">
+                {' '}
+                {new Date(item.deleted_at).toLocaleString('ja-JP')}に削除
+              </span>
+            </span>
+            <div className="flex items-center gap-3">
+              <button
+                onClick={() => handleRestore(item)}
+                className="text-slate-400 hover:text-cyan-400 transition-colors"
+                title="復元"
+              >
+                <RotateCcw size={18} />
+              </button>
+              <button
+                onClick={() => handlePurge(item)}
+                className="text-slate-400 hover:text-red-400 transition-colors"
+                title="完全に削除"
+              >
+                <Trash2 size={18} />
+              </button>
+            </div>
+          </div>
+        ))}
+      </div>
+    </div>
+  )
+}
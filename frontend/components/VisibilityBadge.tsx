@@ -0,0 +1,13 @@
+import { Lock } from 'lucide-react'
+import { Visibility } from '@/lib/api'
+
+// Marks GM-only content, which players and viewers never receive
+export default function VisibilityBadge({ visibility }: { visibility?: Visibility }) {
+  if (visibility !== 'gm') return null
+  return (
+    <span className="inline-flex items-center gap-1 px-2 py-0.5 text-xs bg-rose-900 text-rose-200 rounded">
+      <Lock size={12} />
+      GMのみ
+    </span>
+  )
+}
@@ -1,5 +1,7 @@
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080'
 
+export type CampaignRole = 'owner' | 'editor' | 'player' | 'viewer'
+
 export interface Campaign {
   id: string
   user_id: string
@@ -7,8 +9,72 @@ export interface Campaign {
   description?: string
   created_at: string
   updated_at: string
+  role?: CampaignRole
+  deleted_at?: string
+}
+
+export interface CampaignMember {
+  campaign_id: string
+  user_id: string
+  email?: string
+  role: CampaignRole
+  created_at: string
+  updated_at: string
+}
+
+export interface Invitation {
+  id: string
+  campaign_id: string
+  email?: string
+  role: Exclude<CampaignRole, 'owner'>
+  code: string
+  created_by: string
+  expires_at: string
+  created_at: string
+  campaign_title?: string
 }
 
+// A saved version of a character or lore entry; snapshot is the entity as it was saved
+export interface Revision {
+  id: string
+  campaign_id: string
+  entity_type: 'character' | 'lore_entry'
+  entity_id: string
+  number: number
+  summary: string
+  snapshot: Record<string, any>
+  created_by?: string
+  created_at: string
+}
+
+export interface RevisionDiff {
+  from: Revision
+  to: Revision
+  diff: string
+}
+
+// A read-only link to a campaign's public content; categories limit the lore entries
+export interface ShareLink {
+  id: string
+  campaign_id: string
+  label?: string
+  categories: string[]
+  created_by: string
+  expires_at?: string
+  created_at: string
+  token: string
+}
+
+export interface SharedCampaign {
+  title: string
+  description?: string
+  categories: string[]
+  expires_at?: string
+}
+
+// 'gm' is only shown to the owner and editors
+export type Visibility = 'public' | 'gm'
+
 export interface Character {
   id: string
   campaign_id: string
@@ -16,8 +82,11 @@ export interface Character {
   role: string
   attributes: Record<string, any>
   background?: string
+  visibility: Visibility
+  attribute_visibility?: Record<string, Visibility>
   created_at: string
   updated_at: string
+  deleted_at?: string
 }
 
 export interface Relationship {
@@ -27,7 +96,19 @@ export interface Relationship {
   target_character_id: string
   relation_type: string
   description?: string
+  visibility: Visibility
+  created_at: string
+  deleted_at?: string
+}
+
+export interface RelationType {
+  id: string
+  campaign_id: string
+  name: string
+  inverse_name?: string
+  symmetric: boolean
   created_at: string
+  updated_at: string
 }
 
 export interface LoreEntry {
@@ -36,8 +117,87 @@ export interface LoreEntry {
   title: string
   category?: string
   content: string
+  visibility: Visibility
   created_at: string
   updated_at: string
+  deleted_at?: string
+}
+
+// A campaign's trash; retention_days is 0 when nothing is purged automatically
+export interface Trash {
+  characters: Character[]
+  relationships: Relationship[]
+  lore_entries: LoreEntry[]
+  retention_days: number
+}
+
+export interface Proposal {
+  id: string
+  kind: 'background' | 'attribute' | 'relationship' | 'lore_entry' | 'character' | 'lore_entry_update'
+  summary: string
+  character_id?: string
+  lore_entry_id?: string
+  background?: string
+  attribute_key?: string
+  attribute_value?: any
+  relationship?: {
+    source_character_id: string
+    target_character_id: string
+    relation_type: string
+    description?: string
+    visibility?: Visibility
+  }
+  character?: { name: string; role?: string; background?: string; attributes?: Record<string, any>; visibility?: Visibility }
+  lore_entry?: { title: string; category?: string; content: string; visibility?: Visibility }
+  evidence?: string
+  matched_by?: 'name' | 'embedding'
+}
+
+export interface ExtractRelationshipsResponse {
+  proposals: Proposal[]
+  unresolved: {
+    source: string
+    target: string
+    relation_type: string
+    description?: string
+    evidence?: string
+  }[]
+}
+
+export interface GraphCharacter {
+  id: string
+  name: string
+  role: string
+}
+
+export interface GraphAnalysis {
+  character_count: number
+  relationship_count: number
+  centrality: {
+    character_id: string
+    name: string
+    degree: number
+    in_degree: number
+    out_degree: number
+    degree_centrality: number
+    betweenness: number
+  }[]
+  components: { size: number; characters: GraphCharacter[] }[]
+  isolated: GraphCharacter[]
+  communities: { size: number; characters: GraphCharacter[] }[]
+  modularity: number
+}
+
+export interface GraphPath {
+  found: boolean
+  length: number
+  characters: GraphCharacter[]
+  relationships: Relationship[]
+}
+
+export interface DeepDiveResponse {
+  suggestions: string[]
+  proposals: Proposal[]
 }
 
 import { supabase } from './supabase'
@@ -67,6 +227,45 @@ async function fetchAPI(endpoint: string, options: RequestInit = {}) {
   return response.json()
 }
 
+async function fetchBlob(endpoint: string): Promise<Blob> {
+  const { data: { session } } = await supabase.auth.getSession()
+  const token = session?.access_token
+
+  const response = await fetch(`${API_URL}${endpoint}`, {
+    headers: {
+      ...(token && { Authorization: `Bearer ${token}` }),
+    },
+  })
+
+  if (!response.ok) {
+    throw new Error(`API error: ${response.statusText}`)
+  }
+
+  return response.blob()
+}
+
+async function uploadFile(endpoint: string, file: File) {
+  const { data: { session } } = await supabase.auth.getSession()
+  const token = session?.access_token
+
+  const body = new FormData()
+  body.append('file', file)
+
+  const response = await fetch(`${API_URL}${endpoint}`, {
+    method: 'POST',
+    headers: {
+      ...(token && { Authorization: `Bearer ${token}` }),
+    },
+    body,
+  })
+
+  if (!response.ok) {
+    throw new Error(`API error: ${response.statusText}`)
+  }
+
+  return response.json()
+}
+
 export const api = {
   campaigns: {
     list: () => fetchAPI('/api/campaigns'),
@@ -83,6 +282,22 @@ export const api = {
       }),
     delete: (id: string) =>
       fetchAPI(`/api/campaigns/${id}`, { method: 'DELETE' }),
+    export: (id: string) => fetchAPI(`/api/campaigns/${id}/export`),
+    exportMarkdown: (id: string) => fetchBlob(`/api/campaigns/${id}/export/markdown`),
+    exportGraph: (id: string, format: 'graphml' | 'dot' | 'cytoscape') =>
+      fetchBlob(`/api/campaigns/${id}/graph?format=${format}`),
+    import: (archive: unknown): Promise<Campaign> =>
+      fetchAPI('/api/campaigns/import', {
+        method: 'POST',
+        body: JSON.stringify(archive),
+      }),
+    importMarkdown: (file: File): Promise<Campaign> =>
+      uploadFile('/api/campaigns/import/markdown', file),
+    ingestSessionNotes: (id: string, notes: string): Promise<{ proposals: Proposal[] }> =>
+      fetchAPI(`/api/campaigns/${id}/session-notes/ingest`, {
+        method: 'POST',
+        body: JSON.stringify({ notes }),
+      }),
   },
   characters: {
     list: (campaignId: string) =>
@@ -94,6 +309,8 @@ export const api = {
       role?: string
       attributes?: Record<string, any>
       background?: string
+      visibility?: Visibility
+      attribute_visibility?: Record<string, Visibility>
     }) =>
       fetchAPI('/api/characters', {
         method: 'POST',
@@ -106,6 +323,8 @@ export const api = {
         role?: string
         attributes?: Record<string, any>
         background?: string
+        visibility?: Visibility
+        attribute_visibility?: Record<string, Visibility>
       }
     ) =>
       fetchAPI(`/api/characters/${id}`, {
@@ -116,14 +335,27 @@ export const api = {
       fetchAPI(`/api/characters/${id}`, { method: 'DELETE' }),
   },
   relationships: {
-    list: (campaignId: string) =>
-      fetchAPI(`/api/relationships?campaign_id=${campaignId}`),
+    list: (campaignId: string, characterId?: string) =>
+      fetchAPI(
+        `/api/relationships?campaign_id=${campaignId}` +
+          (characterId ? `&character_id=${characterId}` : '')
+      ),
+    extract: (campaignId: string, text?: string): Promise<ExtractRelationshipsResponse> =>
+      fetchAPI(`/api/campaigns/${campaignId}/relationships/extract`, {
+        method: 'POST',
+        body: JSON.stringify({ text }),
+      }),
+    analysis: (campaignId: string): Promise<GraphAnalysis> =>
+      fetchAPI(`/api/campaigns/${campaignId}/graph/analysis`),
+    path: (campaignId: string, from: string, to: string): Promise<GraphPath> =>
+      fetchAPI(`/api/campaigns/${campaignId}/graph/path?from=${from}&to=${to}`),
     create: (data: {
       campaign_id: string
       source_character_id: string
       target_character_id: string
       relation_type: string
       description?: string
+      visibility?: Visibility
     }) =>
       fetchAPI('/api/relationships', {
         method: 'POST',
@@ -131,7 +363,7 @@ export const api = {
       }),
     update: (
       id: string,
-      data: { relation_type: string; description?: string }
+      data: { relation_type: string; description?: string; visibility?: Visibility }
     ) =>
       fetchAPI(`/api/relationships/${id}`, {
         method: 'PUT',
@@ -140,6 +372,106 @@ export const api = {
     delete: (id: string) =>
       fetchAPI(`/api/relationships/${id}`, { method: 'DELETE' }),
   },
+  members: {
+    list: (campaignId: string): Promise<CampaignMember[]> =>
+      fetchAPI(`/api/campaigns/${campaignId}/members`),
+    update: (campaignId: string, userId: string, role: Invitation['role']): Promise<CampaignMember> =>
+      fetchAPI(`/api/campaigns/${campaignId}/members/${userId}`, {
+        method: 'PUT',
+        body: JSON.stringify({ role }),
+      }),
+    remove: (campaignId: string, userId: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/members/${userId}`, { method: 'DELETE' }),
+  },
+  invitations: {
+    list: (campaignId: string): Promise<Invitation[]> =>
+      fetchAPI(`/api/campaigns/${campaignId}/invitations`),
+    create: (
+      campaignId: string,
+      data: { email?: string; role: Invitation['role'] }
+    ): Promise<Invitation> =>
+      fetchAPI(`/api/campaigns/${campaignId}/invitations`, {
+        method: 'POST',
+        body: JSON.stringify(data),
+      }),
+    delete: (campaignId: string, id: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/invitations/${id}`, { method: 'DELETE' }),
+    mine: (): Promise<Invitation[]> => fetchAPI('/api/invitations'),
+    accept: (code: string): Promise<Campaign> =>
+      fetchAPI('/api/invitations/accept', {
+        method: 'POST',
+        body: JSON.stringify({ code }),
+      }),
+  },
+  // entity is 'characters' or 'lore-entries'
+  revisions: {
+    list: (entity: 'characters' | 'lore-entries', id: string): Promise<Revision[]> =>
+      fetchAPI(`/api/${entity}/${id}/revisions`),
+    diff: (entity: 'characters' | 'lore-entries', id: string, from: string, to: string): Promise<RevisionDiff> =>
+      fetchAPI(`/api/${entity}/${id}/revisions/diff?from=${from}&to=${to}`),
+    restore: (entity: 'characters' | 'lore-entries', id: string, revisionId: string) =>
+      fetchAPI(`/api/${entity}/${id}/revisions/${revisionId}/restore`, { method: 'POST' }),
+  },
+  shareLinks: {
+    list: (campaignId: string): Promise<ShareLink[]> =>
+      fetchAPI(`/api/campaigns/${campaignId}/share-links`),
+    create: (
+      campaignId: string,
+      data: { label?: string; categories?: string[]; expires_at?: string }
+    ): Promise<ShareLink> =>
+      fetchAPI(`/api/campaigns/${campaignId}/share-links`, {
+        method: 'POST',
+        body: JSON.stringify(data),
+      }),
+    delete: (campaignId: string, id: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/share-links/${id}`, { method: 'DELETE' }),
+  },
+  // type is 'characters', 'relationships' or 'lore-entries'
+  trash: {
+    get: (campaignId: string): Promise<Trash> => fetchAPI(`/api/campaigns/${campaignId}/trash`),
+    restore: (campaignId: string, type: 'characters' | 'relationships' | 'lore-entries', id: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/trash/${type}/${id}/restore`, { method: 'POST' }),
+    purge: (campaignId: string, type: 'characters' | 'relationships' | 'lore-entries', id: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/trash/${type}/${id}`, { method: 'DELETE' }),
+    empty: (campaignId: string) => fetchAPI(`/api/campaigns/${campaignId}/trash`, { method: 'DELETE' }),
+    campaigns: (): Promise<Campaign[]> => fetchAPI('/api/trash/campaigns'),
+    restoreCampaign: (id: string): Promise<Campaign> =>
+      fetchAPI(`/api/trash/campaigns/${id}/restore`, { method: 'POST' }),
+    purgeCampaign: (id: string) => fetchAPI(`/api/trash/campaigns/${id}`, { method: 'DELETE' }),
+  },
+  // Public endpoints read through a share link token, no login needed
+  shared: {
+    campaign: (token: string): Promise<SharedCampaign> => fetchAPI(`/api/share/${token}`),
+    characters: (token: string): Promise<Character[]> => fetchAPI(`/api/share/${token}/characters`),
+    relationships: (token: string): Promise<Relationship[]> =>
+      fetchAPI(`/api/share/${token}/relationships`),
+    loreEntries: (token: string): Promise<LoreEntry[]> => fetchAPI(`/api/share/${token}/lore-entries`),
+  },
+  relationTypes: {
+    list: (campaignId: string): Promise<RelationType[]> =>
+      fetchAPI(`/api/campaigns/${campaignId}/relation-types`),
+    create: (
+      campaignId: string,
+      data: { name: string; inverse_name?: string; symmetric?: boolean }
+    ): Promise<RelationType> =>
+      fetchAPI(`/api/campaigns/${campaignId}/relation-types`, {
+        method: 'POST',
+        body: JSON.stringify(data),
+      }),
+    update: (
+      campaignId: string,
+      id: string,
+      data: { name: string; inverse_name?: string; symmetric?: boolean }
+    ): Promise<RelationType> =>
+      fetchAPI(`/api/campaigns/${campaignId}/relation-types/${id}`, {
+        method: 'PUT',
+        body: JSON.stringify(data),
+      }),
+    delete: (campaignId: string, id: string) =>
+      fetchAPI(`/api/campaigns/${campaignId}/relation-types/${id}`, {
+        method: 'DELETE',
+      }),
+  },
   loreEntries: {
     list: (campaignId: string) =>
       fetchAPI(`/api/lore-entries?campaign_id=${campaignId}`),
@@ -149,6 +481,7 @@ export const api = {
       title: string
       category?: string
       content: string
+      visibility?: Visibility
     }) =>
       fetchAPI('/api/lore-entries', {
         method: 'POST',
@@ -156,7 +489,7 @@ export const api = {
       }),
     update: (
       id: string,
-      data: { title: string; category?: string; content: string }
+      data: { title: string; category?: string; content: string; visibility?: Visibility }
     ) =>
       fetchAPI(`/api/lore-entries/${id}`, {
         method: 'PUT',
@@ -166,10 +499,15 @@ export const api = {
       fetchAPI(`/api/lore-entries/${id}`, { method: 'DELETE' }),
   },
   ai: {
-    deepDive: (input: Record<string, any>) =>
+    deepDive: (campaignId: string, input: Record<string, any>, characterId?: string): Promise<DeepDiveResponse> =>
       fetchAPI('/api/ai/deep-dive', {
         method: 'POST',
-        body: JSON.stringify({ input }),
+        body: JSON.stringify({ campaign_id: campaignId, character_id: characterId, input }),
+      }),
+    applyProposals: (campaignId: string, proposals: Proposal[], source = 'deep_dive') =>
+      fetchAPI(`/api/campaigns/${campaignId}/proposals/apply`, {
+        method: 'POST',
+        body: JSON.stringify({ source, proposals }),
       }),
     consistencyCheck: (campaignId: string, newContent: string) =>
       fetchAPI('/api/ai/consistency-check', {